@@ -0,0 +1,347 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// approverPermissions are the permissions of users who decide leave requests and may delegate
+var approverPermissions = []string{"APPROVE_LEAVE_TEAM", "APPROVE_LEAVE_HR", "APPROVE_LEAVE_MANAGEMENT"}
+
+type ApprovalDelegationAPI struct {
+	db              *gorm.DB
+	delegationModel *model.ApprovalDelegationModel
+	userModel       *model.UserModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CreateDelegationRequest struct {
+	DelegateID uint     `json:"delegate_id" binding:"required"`
+	StartDate  string   `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate    string   `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	LeaveTypes []string `json:"leave_types"`                   // Empty for every leave type
+	Reason     string   `json:"reason"`
+}
+
+type AutoDelegationRequest struct {
+	DelegateID uint     `json:"delegate_id" binding:"required"`
+	LeaveTypes []string `json:"leave_types"`
+	IsEnabled  bool     `json:"is_enabled"`
+}
+
+type DelegationListResponse struct {
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+	Data    DelegationListResponseData `json:"data"`
+}
+
+type DelegationListResponseData struct {
+	Given    []model.ApprovalDelegation `json:"given"`    // Delegations the user set for others
+	Received []model.ApprovalDelegation `json:"received"` // Delegations the user may act on
+}
+
+type DelegationResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    model.ApprovalDelegation `json:"data"`
+}
+
+type AutoDelegationResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    model.AutoDelegation `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewApprovalDelegationAPI(db *gorm.DB) *ApprovalDelegationAPI {
+	return &ApprovalDelegationAPI{
+		db:              db,
+		delegationModel: model.NewApprovalDelegationModel(db),
+		userModel:       model.NewUserModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *ApprovalDelegationAPI) SetupRoutes(router *gin.RouterGroup) {
+	delegationGroup := router.Group("/approval-delegations")
+	delegationGroup.Use(middleware.AuthMiddleware())
+	{
+		delegationGroup.GET("", a.GetDelegations)
+		delegationGroup.POST("", a.CreateDelegation)
+		delegationGroup.DELETE("/:id", a.RevokeDelegation)
+		delegationGroup.GET("/auto", a.GetAutoDelegation)
+		delegationGroup.PUT("/auto", a.SaveAutoDelegation)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetDelegations lists the delegations the user has given and received
+func (a *ApprovalDelegationAPI) GetDelegations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	given, err := a.delegationModel.GetDelegationsByDelegator(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve delegations",
+		})
+		return
+	}
+	received, err := a.delegationModel.GetDelegationsToDelegate(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve delegations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, DelegationListResponse{
+		Success: true,
+		Message: "Delegations retrieved successfully",
+		Data: DelegationListResponseData{
+			Given:    given,
+			Received: received,
+		},
+	})
+}
+
+// CreateDelegation lets another user decide the caller's approvals between two dates
+func (a *ApprovalDelegationAPI) CreateDelegation(c *gin.Context) {
+	userID, ok := a.requireApprover(c)
+	if !ok {
+		return
+	}
+
+	var req CreateDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid start_date format. Expected YYYY-MM-DD",
+		})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid end_date format. Expected YYYY-MM-DD",
+		})
+		return
+	}
+
+	delegation := model.ApprovalDelegation{
+		DelegatorID: userID,
+		DelegateID:  req.DelegateID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		LeaveTypes:  toLeaveTypeList(req.LeaveTypes),
+		Reason:      req.Reason,
+	}
+	if err := a.delegationModel.CreateDelegation(&delegation); err != nil {
+		a.respondDelegationError(c, err, "Failed to create delegation")
+		return
+	}
+
+	c.JSON(http.StatusCreated, DelegationResponse{
+		Success: true,
+		Message: "Delegation created successfully",
+		Data:    delegation,
+	})
+}
+
+// RevokeDelegation ends a delegation the caller has given
+func (a *ApprovalDelegationAPI) RevokeDelegation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return
+	}
+
+	if err := a.delegationModel.RevokeDelegation(uint(id), userID.(uint)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Delegation not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to revoke delegation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Delegation revoked successfully",
+	})
+}
+
+// GetAutoDelegation returns the caller's standing delegate
+func (a *ApprovalDelegationAPI) GetAutoDelegation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	setting, err := a.delegationModel.GetAutoDelegation(userID.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "No standing delegate configured",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve standing delegate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AutoDelegationResponse{
+		Success: true,
+		Message: "Standing delegate retrieved successfully",
+		Data:    *setting,
+	})
+}
+
+// SaveAutoDelegation sets the delegate who takes over the caller's approvals whenever their own leave is approved
+func (a *ApprovalDelegationAPI) SaveAutoDelegation(c *gin.Context) {
+	userID, ok := a.requireApprover(c)
+	if !ok {
+		return
+	}
+
+	var req AutoDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	setting := model.AutoDelegation{
+		UserID:     userID,
+		DelegateID: req.DelegateID,
+		LeaveTypes: toLeaveTypeList(req.LeaveTypes),
+		IsEnabled:  req.IsEnabled,
+	}
+	if err := a.delegationModel.SaveAutoDelegation(&setting); err != nil {
+		a.respondDelegationError(c, err, "Failed to save standing delegate")
+		return
+	}
+
+	c.JSON(http.StatusOK, AutoDelegationResponse{
+		Success: true,
+		Message: "Standing delegate saved successfully",
+		Data:    setting,
+	})
+}
+
+//---------- HELPERS ----------
+
+// requireApprover checks that the caller decides leave requests and returns their user ID
+func (a *ApprovalDelegationAPI) requireApprover(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return 0, false
+	}
+
+	isTeamLead, err := a.userModel.IsUserTeamLead(userID.(uint))
+	if err == nil && isTeamLead {
+		return userID.(uint), true
+	}
+	for _, permission := range approverPermissions {
+		hasPermission, err := a.userModel.HasUserPermission(userID.(uint), permission)
+		if err == nil && hasPermission {
+			return userID.(uint), true
+		}
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{
+		Success: false,
+		Message: "Insufficient permissions to delegate approvals",
+	})
+	return 0, false
+}
+
+// respondDelegationError maps delegation validation errors to bad requests
+func (a *ApprovalDelegationAPI) respondDelegationError(c *gin.Context, err error, message string) {
+	if errors.Is(err, model.ErrSelfDelegation) || errors.Is(err, model.ErrInvalidDelegateDate) || errors.Is(err, model.ErrInactiveDelegate) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: message,
+	})
+}
+
+// toLeaveTypeList converts leave type names from a request body
+func toLeaveTypeList(names []string) model.LeaveTypeList {
+	if len(names) == 0 {
+		return nil
+	}
+	leaveTypes := make(model.LeaveTypeList, len(names))
+	for i, name := range names {
+		leaveTypes[i] = model.LeaveType(name)
+	}
+	return leaveTypes
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ApprovalInboxAPI struct {
+	db         *gorm.DB
+	inboxModel *model.ApprovalInboxModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type ApprovalInboxResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    model.ApprovalInbox `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewApprovalInboxAPI(db *gorm.DB) *ApprovalInboxAPI {
+	return &ApprovalInboxAPI{
+		db:         db,
+		inboxModel: model.NewApprovalInboxModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *ApprovalInboxAPI) SetupRoutes(router *gin.RouterGroup) {
+	approvalsGroup := router.Group("/approvals")
+	approvalsGroup.Use(middleware.AuthMiddleware())
+	{
+		approvalsGroup.GET("/inbox", a.GetInbox)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetInbox lists everything the caller can decide now, whatever their roles, team leadership, delegations and
+// escalations, with urgent items first, and what they decided recently
+func (a *ApprovalInboxAPI) GetInbox(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	inbox, err := a.inboxModel.GetInbox(userID.(uint), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve approval inbox",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ApprovalInboxResponse{
+		Success: true,
+		Message: "Approval inbox retrieved successfully",
+		Data:    *inbox,
+	})
+}
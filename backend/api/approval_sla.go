@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ApprovalSLAAPI struct {
+	db               *gorm.DB
+	approvalSLAModel *model.ApprovalSLAModel
+	userModel        *model.UserModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type UpdateApprovalSLARequest struct {
+	ReminderAfterHours    int `json:"reminder_after_hours" binding:"min=0"`
+	ReminderIntervalHours int `json:"reminder_interval_hours" binding:"min=1"`
+	EscalateAfterHours    int `json:"escalate_after_hours" binding:"min=0"`
+}
+
+type ApprovalSLAListResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    []model.ApprovalSLA `json:"data"`
+}
+
+type ApprovalSLAResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    model.ApprovalSLA `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewApprovalSLAAPI(db *gorm.DB) *ApprovalSLAAPI {
+	return &ApprovalSLAAPI{
+		db:               db,
+		approvalSLAModel: model.NewApprovalSLAModel(db),
+		userModel:        model.NewUserModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *ApprovalSLAAPI) SetupRoutes(router *gin.RouterGroup) {
+	slaGroup := router.Group("/admin/approval-slas")
+	slaGroup.Use(middleware.AuthMiddleware())
+	{
+		slaGroup.GET("", a.GetApprovalSLAs)
+		slaGroup.PUT("/:stage", a.UpdateApprovalSLA)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetApprovalSLAs lists the reminder and escalation settings of every approval stage
+func (a *ApprovalSLAAPI) GetApprovalSLAs(c *gin.Context) {
+	if !requirePolicyManager(c, a.userModel, "approval SLAs") {
+		return
+	}
+
+	slas, err := a.approvalSLAModel.GetApprovalSLAs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve approval SLAs",
+		})
+		return
+	}
+
+	list := make([]model.ApprovalSLA, 0, len(slas))
+	for _, stage := range model.ApprovalStages {
+		list = append(list, slas[stage])
+	}
+
+	c.JSON(http.StatusOK, ApprovalSLAListResponse{
+		Success: true,
+		Message: "Approval SLAs retrieved successfully",
+		Data:    list,
+	})
+}
+
+// UpdateApprovalSLA replaces the reminder and escalation settings of one approval stage
+func (a *ApprovalSLAAPI) UpdateApprovalSLA(c *gin.Context) {
+	if !requirePolicyManager(c, a.userModel, "approval SLAs") {
+		return
+	}
+
+	stage := model.ApprovalStage(c.Param("stage"))
+	if !stage.IsValid() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid approval stage, expected TEAM_LEAD, HR or MANAGEMENT",
+		})
+		return
+	}
+
+	var req UpdateApprovalSLARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request body",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+	if stage == model.StageManagement && req.EscalateAfterHours > 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "The management stage has no fallback approver to escalate to",
+		})
+		return
+	}
+
+	sla := model.ApprovalSLA{
+		Stage:                 stage,
+		ReminderAfterHours:    req.ReminderAfterHours,
+		ReminderIntervalHours: req.ReminderIntervalHours,
+		EscalateAfterHours:    req.EscalateAfterHours,
+	}
+	if err := a.approvalSLAModel.SaveApprovalSLA(&sla); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to update approval SLA",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ApprovalSLAResponse{
+		Success: true,
+		Message: "Approval SLA updated successfully",
+		Data:    sla,
+	})
+}
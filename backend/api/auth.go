@@ -146,6 +146,16 @@ func (a *AuthAPI) Login(c *gin.Context) {
 		return
 	}
 
+	// Offboarded users lose access once their termination date is reached
+	if user.IsTerminated(time.Now()) {
+		a.userModel.DeactivateUser(user.ID)
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "Account is deactivated",
+		})
+		return
+	}
+
 	// Verify password
 	if err := model.VerifyPassword(user.Password, req.Password); err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -270,6 +280,16 @@ func (a *AuthAPI) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// Offboarded users lose access once their termination date is reached
+	if user.IsTerminated(time.Now()) {
+		a.userModel.DeactivateUser(user.ID)
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "Account is deactivated",
+		})
+		return
+	}
+
 	// Verify refresh token matches stored token
 	if user.RefreshToken != req.RefreshToken {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
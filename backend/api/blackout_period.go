@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BlackoutPeriodAPI struct {
+	db            *gorm.DB
+	blackoutModel *model.BlackoutPeriodModel
+	userModel     *model.UserModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type BlackoutPeriodRequest struct {
+	Name             string   `json:"name" binding:"required,min=3,max=100"`
+	Description      string   `json:"description"`
+	Scope            string   `json:"scope" binding:"required,oneof=COMPANY TEAM LEAVE_TYPE"`
+	TeamID           *uint    `json:"team_id"`
+	LeaveTypes       []string `json:"leave_types"`
+	StartDate        string   `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate          string   `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	ExemptLeaveTypes []string `json:"exempt_leave_types"`            // E.g. SICK and EMERGENCY
+	IsActive         *bool    `json:"is_active,omitempty"`           // Defaults to true
+}
+
+type BlackoutPeriodListResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    []model.BlackoutPeriod `json:"data"`
+}
+
+type BlackoutPeriodResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    model.BlackoutPeriod `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewBlackoutPeriodAPI(db *gorm.DB) *BlackoutPeriodAPI {
+	return &BlackoutPeriodAPI{
+		db:            db,
+		blackoutModel: model.NewBlackoutPeriodModel(db),
+		userModel:     model.NewUserModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (b *BlackoutPeriodAPI) SetupRoutes(router *gin.RouterGroup) {
+	blackoutGroup := router.Group("/admin/blackout-periods")
+	blackoutGroup.Use(middleware.AuthMiddleware())
+	{
+		blackoutGroup.GET("", b.GetBlackoutPeriods)
+		blackoutGroup.POST("", b.CreateBlackoutPeriod)
+		blackoutGroup.GET("/:id", b.GetBlackoutPeriod)
+		blackoutGroup.PUT("/:id", b.UpdateBlackoutPeriod)
+		blackoutGroup.DELETE("/:id", b.DeleteBlackoutPeriod)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetBlackoutPeriods lists blackout periods, optionally only those overlapping ?year=
+func (b *BlackoutPeriodAPI) GetBlackoutPeriods(c *gin.Context) {
+	if !requirePolicyManager(c, b.userModel, "blackout periods") {
+		return
+	}
+
+	var startDate, endDate time.Time
+	if yearStr := c.Query("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid year parameter",
+			})
+			return
+		}
+		startDate = time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		endDate = time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	periods, err := b.blackoutModel.GetBlackoutPeriods(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve blackout periods",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, BlackoutPeriodListResponse{
+		Success: true,
+		Message: "Blackout periods retrieved successfully",
+		Data:    periods,
+	})
+}
+
+// GetBlackoutPeriod returns a single blackout period
+func (b *BlackoutPeriodAPI) GetBlackoutPeriod(c *gin.Context) {
+	if !requirePolicyManager(c, b.userModel, "blackout periods") {
+		return
+	}
+
+	period, ok := b.findBlackoutPeriod(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, BlackoutPeriodResponse{
+		Success: true,
+		Message: "Blackout period retrieved successfully",
+		Data:    *period,
+	})
+}
+
+// CreateBlackoutPeriod adds a blackout period
+func (b *BlackoutPeriodAPI) CreateBlackoutPeriod(c *gin.Context) {
+	if !requirePolicyManager(c, b.userModel, "blackout periods") {
+		return
+	}
+
+	var req BlackoutPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	period := model.BlackoutPeriod{CreatedByID: userID.(uint)}
+	if !applyBlackoutPeriodRequest(c, &period, req) {
+		return
+	}
+	if err := b.blackoutModel.CreateBlackoutPeriod(&period); err != nil {
+		respondBlackoutPeriodError(c, err, "Failed to create blackout period")
+		return
+	}
+
+	c.JSON(http.StatusCreated, BlackoutPeriodResponse{
+		Success: true,
+		Message: "Blackout period created successfully",
+		Data:    period,
+	})
+}
+
+// UpdateBlackoutPeriod replaces a blackout period
+func (b *BlackoutPeriodAPI) UpdateBlackoutPeriod(c *gin.Context) {
+	if !requirePolicyManager(c, b.userModel, "blackout periods") {
+		return
+	}
+
+	period, ok := b.findBlackoutPeriod(c)
+	if !ok {
+		return
+	}
+
+	var req BlackoutPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	if !applyBlackoutPeriodRequest(c, period, req) {
+		return
+	}
+	if err := b.blackoutModel.UpdateBlackoutPeriod(period); err != nil {
+		respondBlackoutPeriodError(c, err, "Failed to update blackout period")
+		return
+	}
+
+	c.JSON(http.StatusOK, BlackoutPeriodResponse{
+		Success: true,
+		Message: "Blackout period updated successfully",
+		Data:    *period,
+	})
+}
+
+// DeleteBlackoutPeriod removes a blackout period
+func (b *BlackoutPeriodAPI) DeleteBlackoutPeriod(c *gin.Context) {
+	if !requirePolicyManager(c, b.userModel, "blackout periods") {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return
+	}
+
+	if err := b.blackoutModel.DeleteBlackoutPeriod(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Blackout period not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to delete blackout period",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Blackout period deleted successfully",
+	})
+}
+
+//---------- HELPERS ----------
+
+// findBlackoutPeriod loads the blackout period in the path
+func (b *BlackoutPeriodAPI) findBlackoutPeriod(c *gin.Context) (*model.BlackoutPeriod, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return nil, false
+	}
+
+	period, err := b.blackoutModel.GetBlackoutPeriod(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Blackout period not found",
+		})
+		return nil, false
+	}
+	return period, true
+}
+
+// applyBlackoutPeriodRequest copies a request body onto a blackout period
+func applyBlackoutPeriodRequest(c *gin.Context, period *model.BlackoutPeriod, req BlackoutPeriodRequest) bool {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid start_date format. Expected YYYY-MM-DD",
+		})
+		return false
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid end_date format. Expected YYYY-MM-DD",
+		})
+		return false
+	}
+
+	period.Name = req.Name
+	period.Description = req.Description
+	period.Scope = model.BlackoutScope(req.Scope)
+	period.TeamID = req.TeamID
+	period.LeaveTypes = toLeaveTypeList(req.LeaveTypes)
+	period.StartDate = startDate
+	period.EndDate = endDate
+	period.ExemptLeaveTypes = toLeaveTypeList(req.ExemptLeaveTypes)
+	period.IsActive = req.IsActive == nil || *req.IsActive
+	return true
+}
+
+// respondBlackoutPeriodError maps blackout validation errors to bad requests
+func respondBlackoutPeriodError(c *gin.Context, err error, message string) {
+	if errors.Is(err, model.ErrInvalidBlackoutPeriod) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: message,
+	})
+}
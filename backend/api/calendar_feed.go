@@ -0,0 +1,368 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// feedHistory is how far back feeds reach; holidays are also listed this far ahead
+const feedHistory = 365 * 24 * time.Hour
+
+type CalendarFeedAPI struct {
+	db           *gorm.DB
+	feedModel    *model.CalendarFeedModel
+	holidayModel *model.CompanyHolidayModel
+	basePath     string
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CreateCalendarFeedRequest struct {
+	FeedType string `json:"feed_type" binding:"required,oneof=PERSONAL TEAM COMPANY"`
+}
+
+type CalendarFeedListResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    []model.CalendarFeed `json:"data"`
+}
+
+type CalendarFeedResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    CalendarFeedResponseData `json:"data"`
+}
+
+type CalendarFeedResponseData struct {
+	Feed model.CalendarFeed `json:"feed"`
+	URL  string             `json:"url"` // Shown once; creating a new feed of the same type revokes it
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewCalendarFeedAPI(db *gorm.DB) *CalendarFeedAPI {
+	return &CalendarFeedAPI{
+		db:           db,
+		feedModel:    model.NewCalendarFeedModel(db),
+		holidayModel: model.NewCompanyHolidayModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *CalendarFeedAPI) SetupRoutes(router *gin.RouterGroup) {
+	feedGroup := router.Group("/calendar-feeds")
+	a.basePath = feedGroup.BasePath()
+
+	// Calendar apps cannot send a bearer token, the secret token in the URL authenticates them
+	feedGroup.GET("/ics/:token", a.ServeCalendarFeed)
+
+	ownerGroup := feedGroup.Group("")
+	ownerGroup.Use(middleware.AuthMiddleware())
+	{
+		ownerGroup.GET("", a.GetCalendarFeeds)
+		ownerGroup.POST("", a.CreateCalendarFeed)
+		ownerGroup.DELETE("/:id", a.RevokeCalendarFeed)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetCalendarFeeds lists the caller's feeds, including revoked ones
+func (a *CalendarFeedAPI) GetCalendarFeeds(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	feeds, err := a.feedModel.GetUserFeeds(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve calendar feeds",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CalendarFeedListResponse{
+		Success: true,
+		Message: "Calendar feeds retrieved successfully",
+		Data:    feeds,
+	})
+}
+
+// CreateCalendarFeed issues a new secret feed URL for the caller
+func (a *CalendarFeedAPI) CreateCalendarFeed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	feed, token, err := a.feedModel.CreateFeed(userID.(uint), model.CalendarFeedType(req.FeedType))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to create calendar feed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CalendarFeedResponse{
+		Success: true,
+		Message: "Calendar feed created successfully",
+		Data: CalendarFeedResponseData{
+			Feed: *feed,
+			URL:  fmt.Sprintf("%s://%s%s/ics/%s.ics", requestScheme(c), c.Request.Host, a.basePath, token),
+		},
+	})
+}
+
+// RevokeCalendarFeed stops one of the caller's feed URLs from working
+func (a *CalendarFeedAPI) RevokeCalendarFeed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return
+	}
+
+	if err := a.feedModel.RevokeFeed(uint(id), userID.(uint)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Calendar feed not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to revoke calendar feed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Calendar feed revoked successfully",
+	})
+}
+
+// ServeCalendarFeed renders a feed as an iCalendar document
+func (a *CalendarFeedAPI) ServeCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	feed, err := a.feedModel.GetFeedByToken(token)
+	if err != nil {
+		c.String(http.StatusNotFound, "calendar feed not found")
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-feedHistory)
+	var events []icsEvent
+	switch feed.FeedType {
+	case model.FeedCompany:
+		holidays, err := a.holidayModel.GetHolidaysIncludingDeleted(since, now.Add(2*feedHistory))
+		if err != nil {
+			c.String(http.StatusInternalServerError, "failed to load calendar feed")
+			return
+		}
+		for _, holiday := range holidays {
+			events = append(events, holidayEvent(holiday))
+		}
+	default:
+		requests, err := a.feedModel.GetFeedLeaveRequests(feed, since)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "failed to load calendar feed")
+			return
+		}
+		for _, request := range requests {
+			events = append(events, leaveRequestEvent(request, feed.FeedType == model.FeedTeam))
+		}
+	}
+
+	// Losing the access time must not break the subscription
+	_ = a.feedModel.MarkAccessed(feed, now)
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="leave.ics"`)
+	c.Status(http.StatusOK)
+	writeICS(c.Writer, feedCalendarNames[feed.FeedType], events, now)
+}
+
+//---------- HELPERS ----------
+
+var feedCalendarNames = map[model.CalendarFeedType]string{
+	model.FeedPersonal: "My leave",
+	model.FeedTeam:     "Team leave",
+	model.FeedCompany:  "Company holidays",
+}
+
+// icsEvent is an all-day iCalendar event
+type icsEvent struct {
+	UID          string
+	Summary      string
+	Description  string
+	StartDate    time.Time
+	EndDate      time.Time // Inclusive
+	Status       string    // TENTATIVE, CONFIRMED or CANCELLED
+	Transparent  bool      // Does not block the subscriber's free/busy time
+	LastModified time.Time
+}
+
+// leaveRequestEvent converts a leave request; team feeds show whose leave it is but not the reason
+func leaveRequestEvent(request model.LeaveRequest, forTeam bool) icsEvent {
+	leaveName := strings.ToUpper(string(request.LeaveType[:1])) + strings.ToLower(string(request.LeaveType[1:])) + " leave"
+	event := icsEvent{
+		UID:          fmt.Sprintf("leave-request-%d@xmus-crm", request.ID),
+		Summary:      leaveName,
+		StartDate:    request.StartDate,
+		EndDate:      request.EndDate,
+		Status:       "TENTATIVE",
+		Transparent:  forTeam,
+		LastModified: request.UpdatedAt,
+	}
+	if forTeam {
+		event.Summary = fmt.Sprintf("%s %s - %s", request.User.FirstName, request.User.LastName, leaveName)
+	} else {
+		event.Description = request.Reason
+	}
+
+	switch {
+	case request.DeletedAt.Valid || request.Status == model.StatusCancelled || request.Status == model.StatusRejected:
+		event.Status = "CANCELLED"
+	case request.Status.IsBalanceDeducted():
+		event.Status = "CONFIRMED"
+	default:
+		event.Summary += " (pending)"
+	}
+	return event
+}
+
+// holidayEvent converts a company holiday, cancelled once it has been removed
+func holidayEvent(holiday model.CompanyHoliday) icsEvent {
+	event := icsEvent{
+		UID:          fmt.Sprintf("company-holiday-%d@xmus-crm", holiday.ID),
+		Summary:      holiday.Name,
+		Description:  holiday.Description,
+		StartDate:    holiday.Date,
+		EndDate:      holiday.Date,
+		Status:       "CONFIRMED",
+		Transparent:  true,
+		LastModified: holiday.UpdatedAt,
+	}
+	if holiday.DeletedAt.Valid {
+		event.Status = "CANCELLED"
+		event.LastModified = holiday.DeletedAt.Time
+	}
+	return event
+}
+
+// writeICS writes a calendar in RFC 5545 format
+func writeICS(w io.Writer, name string, events []icsEvent, now time.Time) {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//xmus-crm//Leave Calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeICSText(name),
+	}
+	for _, event := range events {
+		transparency := "OPAQUE"
+		if event.Transparent {
+			transparency = "TRANSPARENT"
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.UID,
+			"DTSTAMP:"+now.UTC().Format("20060102T150405Z"),
+			"LAST-MODIFIED:"+event.LastModified.UTC().Format("20060102T150405Z"),
+			"DTSTART;VALUE=DATE:"+event.StartDate.Format("20060102"),
+			"DTEND;VALUE=DATE:"+event.EndDate.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+escapeICSText(event.Summary),
+			"STATUS:"+event.Status,
+			"TRANSP:"+transparency,
+		)
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escapeICSText(event.Description))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		io.WriteString(w, foldICSLine(line)+"\r\n")
+	}
+}
+
+// escapeICSText escapes a TEXT property value
+func escapeICSText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// foldICSLine splits a content line into chunks of at most 75 octets without breaking UTF-8 sequences
+func foldICSLine(line string) string {
+	var folded strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	folded.WriteString(line)
+	return folded.String()
+}
+
+// requestScheme returns the scheme the client used, honouring a proxy's X-Forwarded-Proto
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
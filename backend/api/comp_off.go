@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CompOffAPI struct {
+	db           *gorm.DB
+	compOffModel *model.CompOffClaimModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CompOffWorkedDayRequest struct {
+	Date  string  `json:"date" binding:"required"` // YYYY-MM-DD
+	Hours float64 `json:"hours" binding:"required,gt=0,lte=24"`
+}
+
+type CreateCompOffClaimRequest struct {
+	WorkedDays []CompOffWorkedDayRequest `json:"worked_days" binding:"required,min=1,dive"`
+	Reason     string                    `json:"reason" binding:"required"`
+}
+
+type CompOffClaimListResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    []model.CompOffClaim `json:"data"`
+}
+
+type CompOffClaimResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+	Data    model.CompOffClaim `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewCompOffAPI(db *gorm.DB) *CompOffAPI {
+	return &CompOffAPI{
+		db:           db,
+		compOffModel: model.NewCompOffClaimModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *CompOffAPI) SetupRoutes(router *gin.RouterGroup) {
+	compOffGroup := router.Group("/comp-off/claims")
+	compOffGroup.Use(middleware.AuthMiddleware())
+	{
+		compOffGroup.GET("", a.GetMyClaims)
+		compOffGroup.POST("", a.SubmitClaim)
+		compOffGroup.GET("/pending", a.GetPendingClaims)
+		compOffGroup.POST("/:id/approve", a.ApproveClaim)
+		compOffGroup.POST("/:id/reject", a.RejectClaim)
+		compOffGroup.DELETE("/:id", a.WithdrawClaim)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetMyClaims lists the caller's comp-off claims with their credited and expired days
+func (a *CompOffAPI) GetMyClaims(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	claims, err := a.compOffModel.GetUserClaims(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve comp-off claims",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CompOffClaimListResponse{
+		Success: true,
+		Message: "Comp-off claims retrieved successfully",
+		Data:    claims,
+	})
+}
+
+// SubmitClaim claims time off for worked days; the team lead approves it before the days are credited
+func (a *CompOffAPI) SubmitClaim(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req CreateCompOffClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	claim := model.CompOffClaim{
+		UserID: userID.(uint),
+		Reason: req.Reason,
+	}
+	for _, day := range req.WorkedDays {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid worked day date format. Expected YYYY-MM-DD",
+			})
+			return
+		}
+		claim.WorkedDays = append(claim.WorkedDays, model.CompOffWorkedDay{Date: date, Hours: day.Hours})
+	}
+
+	if err := a.compOffModel.SubmitClaim(&claim); err != nil {
+		if errors.Is(err, model.ErrInvalidCompOffClaim) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Comp-off claim validation failed",
+				Errors:  []string{err.Error()},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to submit comp-off claim",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CompOffClaimResponse{
+		Success: true,
+		Message: "Comp-off claim submitted for approval",
+		Data:    claim,
+	})
+}
+
+// GetPendingClaims lists the comp-off claims waiting for the caller's decision
+func (a *CompOffAPI) GetPendingClaims(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	claims, err := a.compOffModel.GetPendingClaimsFor(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve pending comp-off claims",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CompOffClaimListResponse{
+		Success: true,
+		Message: "Pending comp-off claims retrieved successfully",
+		Data:    claims,
+	})
+}
+
+// ApproveClaim approves a claim and credits its days to the claimant's COMP_OFF balance
+func (a *CompOffAPI) ApproveClaim(c *gin.Context) {
+	a.decideClaim(c, true)
+}
+
+// RejectClaim rejects a claim; nothing is credited
+func (a *CompOffAPI) RejectClaim(c *gin.Context) {
+	a.decideClaim(c, false)
+}
+
+// WithdrawClaim lets the claimant take back a pending claim
+func (a *CompOffAPI) WithdrawClaim(c *gin.Context) {
+	userID, claimID, ok := a.parseClaimRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := a.compOffModel.WithdrawClaim(claimID, userID); err != nil {
+		if errors.Is(err, model.ErrCompOffNotPending) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Only your own pending comp-off claims can be withdrawn",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to withdraw comp-off claim",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Comp-off claim withdrawn successfully",
+	})
+}
+
+//---------- HELPERS ----------
+
+// decideClaim approves or rejects the claim in the path. Whether the caller may decide is left to the model,
+// which accepts the team lead and their delegates.
+func (a *CompOffAPI) decideClaim(c *gin.Context, approve bool) {
+	userID, claimID, ok := a.parseClaimRequest(c)
+	if !ok {
+		return
+	}
+
+	var req ApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	claim, err := a.compOffModel.DecideClaim(claimID, userID, approve, req.Comments)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Comp-off claim not found",
+			})
+		case errors.Is(err, model.ErrNotCompOffApprover):
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Message: "Insufficient permissions to decide this comp-off claim",
+			})
+		case errors.Is(err, model.ErrCompOffNotPending), errors.Is(err, model.ErrInvalidCompOffClaim):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to process comp-off claim",
+			})
+		}
+		return
+	}
+
+	message := "Comp-off claim rejected"
+	if approve {
+		message = "Comp-off claim approved and days credited"
+	}
+	c.JSON(http.StatusOK, CompOffClaimResponse{
+		Success: true,
+		Message: message,
+		Data:    *claim,
+	})
+}
+
+// parseClaimRequest returns the authenticated caller and the claim ID in the path
+func (a *CompOffAPI) parseClaimRequest(c *gin.Context) (uint, uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return 0, 0, false
+	}
+	return userID.(uint), uint(id), true
+}
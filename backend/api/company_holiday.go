@@ -0,0 +1,181 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CompanyHolidayAPI struct {
+	db           *gorm.DB
+	holidayModel *model.CompanyHolidayModel
+	userModel    *model.UserModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CreateCompanyHolidayRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	Date        string `json:"date" binding:"required"` // YYYY-MM-DD
+	Description string `json:"description"`
+}
+
+type CompanyHolidayListResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    []model.CompanyHoliday `json:"data"`
+}
+
+type CompanyHolidayResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    model.CompanyHoliday `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewCompanyHolidayAPI(db *gorm.DB) *CompanyHolidayAPI {
+	return &CompanyHolidayAPI{
+		db:           db,
+		holidayModel: model.NewCompanyHolidayModel(db),
+		userModel:    model.NewUserModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (h *CompanyHolidayAPI) SetupRoutes(router *gin.RouterGroup) {
+	holidayGroup := router.Group("/company-holidays")
+	holidayGroup.Use(middleware.AuthMiddleware())
+	{
+		holidayGroup.GET("", h.GetCompanyHolidays)
+	}
+
+	adminGroup := router.Group("/admin/company-holidays")
+	adminGroup.Use(middleware.AuthMiddleware())
+	{
+		adminGroup.POST("", h.CreateCompanyHoliday)
+		adminGroup.DELETE("/:id", h.DeleteCompanyHoliday)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetCompanyHolidays lists the company holidays of ?year=, the current year by default
+func (h *CompanyHolidayAPI) GetCompanyHolidays(c *gin.Context) {
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid year parameter",
+			})
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := h.holidayModel.GetHolidays(
+		time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve company holidays",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CompanyHolidayListResponse{
+		Success: true,
+		Message: "Company holidays retrieved successfully",
+		Data:    holidays,
+	})
+}
+
+// CreateCompanyHoliday adds a company holiday
+func (h *CompanyHolidayAPI) CreateCompanyHoliday(c *gin.Context) {
+	if !requirePolicyManager(c, h.userModel, "company holidays") {
+		return
+	}
+
+	var req CreateCompanyHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid date format. Expected YYYY-MM-DD",
+		})
+		return
+	}
+
+	holiday := model.CompanyHoliday{
+		Name:        req.Name,
+		Date:        date,
+		Description: req.Description,
+	}
+	if err := h.holidayModel.CreateHoliday(&holiday); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to create company holiday",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CompanyHolidayResponse{
+		Success: true,
+		Message: "Company holiday created successfully",
+		Data:    holiday,
+	})
+}
+
+// DeleteCompanyHoliday removes a company holiday
+func (h *CompanyHolidayAPI) DeleteCompanyHoliday(c *gin.Context) {
+	if !requirePolicyManager(c, h.userModel, "company holidays") {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return
+	}
+
+	if err := h.holidayModel.DeleteHoliday(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Company holiday not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to delete company holiday",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Company holiday deleted successfully",
+	})
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/amupxm/xmus-crm/backend/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultJobRunLimit is the number of runs returned by the run history when no limit is given
+const defaultJobRunLimit = 20
+
+type JobAPI struct {
+	db        *gorm.DB
+	scheduler *service.Scheduler
+	jobModel  *model.JobModel
+	userModel *model.UserModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type JobResponse struct {
+	model.ScheduledJob
+	LastRun *model.JobRun `json:"last_run,omitempty"`
+}
+
+type JobListResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    []JobResponse `json:"data"`
+}
+
+type JobRunListResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    []model.JobRun `json:"data"`
+}
+
+type JobRunResponse struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Data    model.JobRun `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewJobAPI(db *gorm.DB, scheduler *service.Scheduler) *JobAPI {
+	return &JobAPI{
+		db:        db,
+		scheduler: scheduler,
+		jobModel:  model.NewJobModel(db),
+		userModel: model.NewUserModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (j *JobAPI) SetupRoutes(router *gin.RouterGroup) {
+	jobGroup := router.Group("/admin/jobs")
+	jobGroup.Use(middleware.AuthMiddleware())
+	{
+		jobGroup.GET("", j.GetJobs)
+		jobGroup.GET("/:name/runs", j.GetJobRuns)
+		jobGroup.POST("/:name/trigger", j.TriggerJob)
+		jobGroup.POST("/:name/pause", j.PauseJob)
+		jobGroup.POST("/:name/resume", j.ResumeJob)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetJobs lists the background jobs with their schedule and latest run
+func (j *JobAPI) GetJobs(c *gin.Context) {
+	if _, ok := j.requireSystemAdmin(c); !ok {
+		return
+	}
+
+	jobs, err := j.jobModel.GetJobs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve jobs",
+		})
+		return
+	}
+
+	latestRuns, err := j.jobModel.GetLatestJobRuns()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve job runs",
+		})
+		return
+	}
+
+	responses := make([]JobResponse, len(jobs))
+	for i, job := range jobs {
+		responses[i] = JobResponse{ScheduledJob: job}
+		if run, ok := latestRuns[job.Name]; ok {
+			responses[i].LastRun = &run
+		}
+	}
+
+	c.JSON(http.StatusOK, JobListResponse{
+		Success: true,
+		Message: "Jobs retrieved successfully",
+		Data:    responses,
+	})
+}
+
+// GetJobRuns lists the most recent runs of a job
+func (j *JobAPI) GetJobRuns(c *gin.Context) {
+	if _, ok := j.requireSystemAdmin(c); !ok {
+		return
+	}
+
+	limit := defaultJobRunLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 100 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid limit parameter, expected 1-100",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	if _, err := j.jobModel.GetJob(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Job not found",
+		})
+		return
+	}
+
+	runs, err := j.jobModel.GetJobRuns(c.Param("name"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve job runs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, JobRunListResponse{
+		Success: true,
+		Message: "Job runs retrieved successfully",
+		Data:    runs,
+	})
+}
+
+// TriggerJob starts a job immediately; the run continues in the background
+func (j *JobAPI) TriggerJob(c *gin.Context) {
+	userID, ok := j.requireSystemAdmin(c)
+	if !ok {
+		return
+	}
+
+	run, err := j.scheduler.Trigger(c.Param("name"), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Job not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to trigger job",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, JobRunResponse{
+		Success: true,
+		Message: "Job triggered successfully",
+		Data:    *run,
+	})
+}
+
+// PauseJob stops the scheduled runs of a job
+func (j *JobAPI) PauseJob(c *gin.Context) {
+	j.setJobPaused(c, true)
+}
+
+// ResumeJob restarts the scheduled runs of a paused job
+func (j *JobAPI) ResumeJob(c *gin.Context) {
+	j.setJobPaused(c, false)
+}
+
+//---------- HELPERS ----------
+
+// setJobPaused pauses or resumes the job named in the path
+func (j *JobAPI) setJobPaused(c *gin.Context, paused bool) {
+	if _, ok := j.requireSystemAdmin(c); !ok {
+		return
+	}
+
+	var err error
+	if paused {
+		err = j.scheduler.Pause(c.Param("name"))
+	} else {
+		err = j.scheduler.Resume(c.Param("name"))
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Job not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to update job",
+		})
+		return
+	}
+
+	message := "Job resumed successfully"
+	if paused {
+		message = "Job paused successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
+
+// requireSystemAdmin checks that the caller may manage background jobs and returns their user ID
+func (j *JobAPI) requireSystemAdmin(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return 0, false
+	}
+
+	hasPermission, err := j.userModel.HasUserPermission(userID.(uint), "SYSTEM_ADMIN")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to manage jobs",
+		})
+		return 0, false
+	}
+	return userID.(uint), true
+}
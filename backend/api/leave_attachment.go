@@ -0,0 +1,369 @@
+package api
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/amupxm/xmus-crm/backend/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAttachmentSize is the largest supporting document accepted, in bytes
+const maxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes are the content types accepted, as detected from the file itself
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+}
+
+type LeaveAttachmentAPI struct {
+	db                *gorm.DB
+	storage           service.FileStorage
+	attachmentModel   *model.LeaveAttachmentModel
+	leaveRequestModel *model.LeaveRequestModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type LeaveAttachmentListResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    []model.LeaveAttachment `json:"data"`
+}
+
+type LeaveAttachmentResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    model.LeaveAttachment `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewLeaveAttachmentAPI(db *gorm.DB, storage service.FileStorage) *LeaveAttachmentAPI {
+	return &LeaveAttachmentAPI{
+		db:                db,
+		storage:           storage,
+		attachmentModel:   model.NewLeaveAttachmentModel(db),
+		leaveRequestModel: model.NewLeaveRequestModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *LeaveAttachmentAPI) SetupRoutes(router *gin.RouterGroup) {
+	attachmentGroup := router.Group("/leave-requests/:id/attachments")
+	attachmentGroup.Use(middleware.AuthMiddleware())
+	{
+		attachmentGroup.GET("", a.GetAttachments)
+		attachmentGroup.POST("", a.UploadAttachment)
+		attachmentGroup.GET("/:attachment_id", a.DownloadAttachment)
+		attachmentGroup.DELETE("/:attachment_id", a.DeleteAttachment)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetAttachments lists the supporting documents of a leave request
+func (a *LeaveAttachmentAPI) GetAttachments(c *gin.Context) {
+	leaveRequest, _, ok := a.requireAttachmentAccess(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := a.attachmentModel.GetAttachments(leaveRequest.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve attachments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveAttachmentListResponse{
+		Success: true,
+		Message: "Attachments retrieved successfully",
+		Data:    attachments,
+	})
+}
+
+// UploadAttachment stores a supporting document sent as the multipart field "file"
+func (a *LeaveAttachmentAPI) UploadAttachment(c *gin.Context) {
+	leaveRequest, userID, ok := a.requireAttachmentAccess(c)
+	if !ok {
+		return
+	}
+	if leaveRequest.Status == model.StatusCancelled || leaveRequest.Status == model.StatusRejected {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Cannot attach documents to a cancelled or rejected leave request",
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "A file must be uploaded in the \"file\" field",
+		})
+		return
+	}
+	if fileHeader.Size > maxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Success: false,
+			Message: fmt.Sprintf("File is too large, the limit is %d MB", maxAttachmentSize>>20),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Failed to read uploaded file",
+		})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil || len(content) > maxAttachmentSize {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Failed to read uploaded file",
+		})
+		return
+	}
+	if len(content) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Uploaded file is empty",
+		})
+		return
+	}
+
+	// Trust the file's content rather than the type the client claims
+	contentType := http.DetectContentType(content)
+	if !allowedAttachmentTypes[contentType] {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Success: false,
+			Message: "Only PDF, JPEG, PNG and WebP files are accepted",
+		})
+		return
+	}
+
+	storageKey, err := newAttachmentKey(leaveRequest.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to store attachment",
+		})
+		return
+	}
+	if err := a.storage.Save(c.Request.Context(), storageKey, bytes.NewReader(content), int64(len(content)), contentType); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to store attachment",
+		})
+		return
+	}
+
+	checksum := sha256.Sum256(content)
+	attachment := model.LeaveAttachment{
+		LeaveRequestID: leaveRequest.ID,
+		UploadedByID:   userID,
+		FileName:       attachmentFileName(fileHeader.Filename),
+		ContentType:    contentType,
+		SizeBytes:      int64(len(content)),
+		SHA256:         hex.EncodeToString(checksum[:]),
+		StorageKey:     storageKey,
+	}
+	if err := a.attachmentModel.CreateAttachment(&attachment); err != nil {
+		a.storage.Delete(c.Request.Context(), storageKey)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to store attachment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, LeaveAttachmentResponse{
+		Success: true,
+		Message: "Attachment uploaded successfully",
+		Data:    attachment,
+	})
+}
+
+// DownloadAttachment streams a supporting document to the requester or an approver
+func (a *LeaveAttachmentAPI) DownloadAttachment(c *gin.Context) {
+	leaveRequest, _, ok := a.requireAttachmentAccess(c)
+	if !ok {
+		return
+	}
+	attachment, ok := a.findAttachment(c, leaveRequest.ID)
+	if !ok {
+		return
+	}
+
+	content, err := a.storage.Open(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		status, message := http.StatusInternalServerError, "Failed to read attachment"
+		if errors.Is(err, service.ErrFileNotFound) {
+			status, message = http.StatusNotFound, "Attachment file is missing"
+		}
+		c.JSON(status, ErrorResponse{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+		"X-Content-Type-Options": "nosniff",
+		"X-Checksum-SHA256":      attachment.SHA256,
+	})
+}
+
+// DeleteAttachment removes a document its uploader added while the request is still undecided
+func (a *LeaveAttachmentAPI) DeleteAttachment(c *gin.Context) {
+	leaveRequest, userID, ok := a.requireAttachmentAccess(c)
+	if !ok {
+		return
+	}
+	attachment, ok := a.findAttachment(c, leaveRequest.ID)
+	if !ok {
+		return
+	}
+
+	if attachment.UploadedByID != userID {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Only the uploader can delete an attachment",
+		})
+		return
+	}
+	if leaveRequest.Status != model.StatusPending {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Attachments can only be deleted while the request is pending",
+		})
+		return
+	}
+
+	if err := a.attachmentModel.DeleteAttachment(leaveRequest.ID, attachment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to delete attachment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Attachment deleted successfully",
+	})
+}
+
+//---------- HELPERS ----------
+
+// requireAttachmentAccess loads the leave request in the path and checks that the caller is its requester
+// or one of its approvers
+func (a *LeaveAttachmentAPI) requireAttachmentAccess(c *gin.Context) (*model.LeaveRequest, uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return nil, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return nil, 0, false
+	}
+
+	leaveRequest, err := a.leaveRequestModel.GetLeaveRequest(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Leave request not found",
+		})
+		return nil, 0, false
+	}
+
+	if leaveRequest.UserID != userID.(uint) {
+		isApprover, err := a.leaveRequestModel.IsApprover(leaveRequest, userID.(uint))
+		if err != nil || !isApprover {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Message: "Insufficient permissions to access attachments of this leave request",
+			})
+			return nil, 0, false
+		}
+	}
+	return leaveRequest, userID.(uint), true
+}
+
+// findAttachment loads the attachment in the path
+func (a *LeaveAttachmentAPI) findAttachment(c *gin.Context, leaveRequestID uint) (*model.LeaveAttachment, bool) {
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid attachment ID",
+		})
+		return nil, false
+	}
+
+	attachment, err := a.attachmentModel.GetAttachment(leaveRequestID, uint(attachmentID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Attachment not found",
+		})
+		return nil, false
+	}
+	return attachment, true
+}
+
+// newAttachmentKey returns a random storage key below the leave request's folder
+func newAttachmentKey(leaveRequestID uint) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("leave-requests/%d/%s", leaveRequestID, hex.EncodeToString(buf)), nil
+}
+
+// attachmentFileName keeps the base name of an uploaded file, shortened to fit the column
+func attachmentFileName(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "attachment"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,6 +15,7 @@ import (
 type LeaveBalanceAdminHandler struct {
 	leaveBalanceModel *model.LeaveBalanceModel
 	leavePolicyModel  *model.LeavePolicyModel
+	leaveAccrualModel *model.LeaveAccrualModel
 	userModel         *model.UserModel
 }
 
@@ -21,16 +23,21 @@ type LeaveBalanceAdminHandler struct {
 
 // LeaveBalanceResponse represents a leave balance in API responses
 type LeaveBalanceResponse struct {
-	ID             uint      `json:"id"`
-	UserID         uint      `json:"user_id"`
-	LeaveType      string    `json:"leave_type"`
-	Year           int       `json:"year"`
-	TotalAllocated int       `json:"total_allocated"`
-	UsedDays       int       `json:"used_days"`
-	RemainingDays  int       `json:"remaining_days"`
-	CarryOverDays  int       `json:"carry_over_days"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                uint      `json:"id"`
+	UserID            uint      `json:"user_id"`
+	LeaveType         string    `json:"leave_type"`
+	Year              int       `json:"year"`
+	TotalAllocated    int       `json:"total_allocated"`
+	UsedDays          int       `json:"used_days"`
+	RemainingDays     int       `json:"remaining_days"`
+	CarryOverDays     int       `json:"carry_over_days"`
+	CarryOverUsedDays int       `json:"carry_over_used_days"`
+	InDeficit         bool      `json:"in_deficit"` // Used beyond the allocation by leave taken in advance
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Only set for leave types whose policy accrues per period
+	Accrual *model.AccrualSummary `json:"accrual,omitempty"`
 }
 
 // UserInfo represents basic user information in leave balance responses
@@ -68,10 +75,60 @@ type LeaveBalanceListResponse struct {
 	Success bool                       `json:"success"`
 	Message string                     `json:"message"`
 	Data    []UserLeaveBalanceResponse `json:"data,omitempty"`
-	Meta    struct {
-		Year  int `json:"year"`
-		Total int `json:"total"`
-	} `json:"meta,omitempty"`
+	Meta    LeaveBalanceListMeta       `json:"meta,omitempty"`
+}
+
+// LeaveBalanceListMeta adds the balance year to the list metadata
+type LeaveBalanceListMeta struct {
+	ListMeta
+	Year int `json:"year"`
+}
+
+// LeaveAccrualHistoryResponse represents a user's accrual history for a year
+type LeaveAccrualHistoryResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    []model.LeaveAccrual `json:"data"`
+	Year    int                  `json:"year"`
+}
+
+// LeaveAccrualRunResponse represents the result of a manual accrual run
+type LeaveAccrualRunResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Posted  int       `json:"posted"`
+	AsOf    time.Time `json:"as_of"`
+}
+
+// LeaveStatementResponse represents a user's leave ledger statement
+type LeaveStatementResponse struct {
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    model.LeaveStatement `json:"data"`
+}
+
+// LedgerConsistencyResponse represents the balances whose counters drifted from the ledger
+type LedgerConsistencyResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    []model.LedgerDrift `json:"data"`
+	Year    int                 `json:"year"`
+}
+
+// LeaveDeficit is a balance used beyond its allocation, settled from the next year's allocation
+type LeaveDeficit struct {
+	User          UserInfo `json:"user"`
+	LeaveType     string   `json:"leave_type"`
+	RemainingDays int      `json:"remaining_days"`
+	DeficitDays   int      `json:"deficit_days"`
+}
+
+// LeaveDeficitResponse represents the balances in deficit for a year
+type LeaveDeficitResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    []LeaveDeficit `json:"data"`
+	Year    int            `json:"year"`
 }
 
 // LeaveBalanceStatsResponse represents leave balance statistics
@@ -86,17 +143,38 @@ type LeaveBalanceStatsResponse struct {
 // convertToLeaveBalanceResponse converts a model.LeaveBalance to LeaveBalanceResponse
 func convertToLeaveBalanceResponse(balance model.LeaveBalance) LeaveBalanceResponse {
 	return LeaveBalanceResponse{
-		ID:             balance.ID,
-		UserID:         balance.UserID,
-		LeaveType:      string(balance.LeaveType),
-		Year:           balance.Year,
-		TotalAllocated: balance.TotalAllocated,
-		UsedDays:       balance.UsedDays,
-		RemainingDays:  balance.RemainingDays,
-		CarryOverDays:  balance.CarryOverDays,
-		CreatedAt:      balance.CreatedAt,
-		UpdatedAt:      balance.UpdatedAt,
+		ID:                balance.ID,
+		UserID:            balance.UserID,
+		LeaveType:         string(balance.LeaveType),
+		Year:              balance.Year,
+		TotalAllocated:    balance.TotalAllocated,
+		UsedDays:          balance.UsedDays,
+		RemainingDays:     balance.RemainingDays,
+		CarryOverDays:     balance.CarryOverDays,
+		CarryOverUsedDays: balance.CarryOverUsedDays,
+		InDeficit:         balance.RemainingDays < 0,
+		CreatedAt:         balance.CreatedAt,
+		UpdatedAt:         balance.UpdatedAt,
+	}
+}
+
+// applyAccrualSummaries attaches accrued-to-date and projected year-end figures to accrued balances
+func applyAccrualSummaries(balances []LeaveBalanceResponse, summaries map[model.LeaveType]model.AccrualSummary) {
+	for i := range balances {
+		if summary, ok := summaries[model.LeaveType(balances[i].LeaveType)]; ok {
+			balances[i].Accrual = &summary
+		}
+	}
+}
+
+// ledgerSource attributes a manual balance change to the authenticated admin
+func ledgerSource(c *gin.Context, reason string) model.LedgerSource {
+	source := model.LedgerSource{Reason: reason}
+	if userID, exists := c.Get("user_id"); exists {
+		actorID := userID.(uint)
+		source.ActorID = &actorID
 	}
+	return source
 }
 
 // convertToUserInfo converts a model.User to UserInfo
@@ -131,6 +209,7 @@ func NewLeaveBalanceAdminHandler(db *gorm.DB) *LeaveBalanceAdminHandler {
 	return &LeaveBalanceAdminHandler{
 		leaveBalanceModel: model.NewLeaveBalanceModel(db),
 		leavePolicyModel:  model.NewLeavePolicyModel(db),
+		leaveAccrualModel: model.NewLeaveAccrualModel(db),
 		userModel:         model.NewUserModel(db),
 	}
 }
@@ -141,6 +220,7 @@ type UpdateUserLeaveBalanceRequest struct {
 	LeaveType      string `json:"leave_type" binding:"required"`
 	TotalAllocated int    `json:"total_allocated" binding:"required,min=0"`
 	CarryOverDays  int    `json:"carry_over_days" binding:"min=0"`
+	Reason         string `json:"reason" binding:"required"` // Recorded on the ledger adjustment
 }
 
 // BulkUpdateLeaveBalanceRequest represents the request body for bulk updating leave balances
@@ -151,6 +231,7 @@ type BulkUpdateLeaveBalanceRequest struct {
 		TotalAllocated int    `json:"total_allocated" binding:"required,min=0"`
 		CarryOverDays  int    `json:"carry_over_days" binding:"min=0"`
 	} `json:"leave_balances" binding:"required"`
+	Reason string `json:"reason" binding:"required"` // Recorded on every ledger adjustment
 }
 
 // GetUserLeaveBalances retrieves leave balances for a specific user
@@ -216,7 +297,17 @@ func (h *LeaveBalanceAdminHandler) GetUserLeaveBalances(c *gin.Context) {
 		}
 	}
 
+	summaries, err := h.leaveAccrualModel.GetAccrualSummaries(uint(userID), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave accruals",
+		})
+		return
+	}
+
 	response := convertToUserLeaveBalanceResponse(*user, balances, year)
+	applyAccrualSummaries(response.Balances, summaries)
 	c.JSON(http.StatusOK, UserLeaveBalanceDetailResponse{
 		Success: true,
 		Message: "User leave balances retrieved successfully",
@@ -256,42 +347,22 @@ func (h *LeaveBalanceAdminHandler) UpdateUserLeaveBalance(c *gin.Context) {
 		return
 	}
 
-	// Get or create leave balance
-	balance, err := h.leaveBalanceModel.GetUserLeaveBalanceByType(req.UserID, year, model.LeaveType(req.LeaveType))
+	// Create or adjust the balance through the ledger
+	balance, err := h.leaveBalanceModel.AdjustLeaveBalance(req.UserID, year, model.LeaveType(req.LeaveType),
+		req.TotalAllocated, req.CarryOverDays, ledgerSource(c, req.Reason))
 	if err != nil {
-		// Create new balance if it doesn't exist
-		balance = &model.LeaveBalance{
-			UserID:         req.UserID,
-			LeaveType:      model.LeaveType(req.LeaveType),
-			Year:           year,
-			TotalAllocated: req.TotalAllocated,
-			UsedDays:       0,
-			CarryOverDays:  req.CarryOverDays,
-		}
-		balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-
-		err = h.leaveBalanceModel.CreateLeaveBalance(balance)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
+		if errors.Is(err, model.ErrUnknownLeaveType) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
 				Success: false,
-				Message: "Failed to create leave balance",
-			})
-			return
-		}
-	} else {
-		// Update existing balance
-		balance.TotalAllocated = req.TotalAllocated
-		balance.CarryOverDays = req.CarryOverDays
-		balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-
-		err = h.leaveBalanceModel.UpdateLeaveBalance(balance)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Success: false,
-				Message: "Failed to update leave balance",
+				Message: err.Error(),
 			})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to update leave balance",
+		})
+		return
 	}
 
 	response := convertToLeaveBalanceResponse(*balance)
@@ -338,41 +409,21 @@ func (h *LeaveBalanceAdminHandler) BulkUpdateUserLeaveBalances(c *gin.Context) {
 
 	// Update each leave balance
 	for _, balanceReq := range req.LeaveBalances {
-		balance, err := h.leaveBalanceModel.GetUserLeaveBalanceByType(req.UserID, year, model.LeaveType(balanceReq.LeaveType))
+		balance, err := h.leaveBalanceModel.AdjustLeaveBalance(req.UserID, year, model.LeaveType(balanceReq.LeaveType),
+			balanceReq.TotalAllocated, balanceReq.CarryOverDays, ledgerSource(c, req.Reason))
 		if err != nil {
-			// Create new balance
-			balance = &model.LeaveBalance{
-				UserID:         req.UserID,
-				LeaveType:      model.LeaveType(balanceReq.LeaveType),
-				Year:           year,
-				TotalAllocated: balanceReq.TotalAllocated,
-				UsedDays:       0,
-				CarryOverDays:  balanceReq.CarryOverDays,
-			}
-			balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-
-			err = h.leaveBalanceModel.CreateLeaveBalance(balance)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, ErrorResponse{
+			if errors.Is(err, model.ErrUnknownLeaveType) {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
 					Success: false,
-					Message: "Failed to create leave balance",
-				})
-				return
-			}
-		} else {
-			// Update existing balance
-			balance.TotalAllocated = balanceReq.TotalAllocated
-			balance.CarryOverDays = balanceReq.CarryOverDays
-			balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-
-			err = h.leaveBalanceModel.UpdateLeaveBalance(balance)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, ErrorResponse{
-					Success: false,
-					Message: "Failed to update leave balance",
+					Message: err.Error(),
 				})
 				return
 			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to update leave balance",
+			})
+			return
 		}
 
 		updatedBalances = append(updatedBalances, *balance)
@@ -403,8 +454,14 @@ func (h *LeaveBalanceAdminHandler) GetAllUsersLeaveBalances(c *gin.Context) {
 		return
 	}
 
-	// Get all users
-	users, err := h.userModel.GetAllUsers()
+	// Parse pagination, sorting and user filter parameters
+	query, ok := parseListQuery(c, model.UserListSpec)
+	if !ok {
+		return
+	}
+
+	// Get one page of users
+	users, listResult, err := h.userModel.ListUsers(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
@@ -413,13 +470,27 @@ func (h *LeaveBalanceAdminHandler) GetAllUsersLeaveBalances(c *gin.Context) {
 		return
 	}
 
-	var result []UserLeaveBalanceResponse
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+
+	balancesByUser, err := h.leaveBalanceModel.GetLeaveBalancesForUsers(userIDs, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave balances",
+		})
+		return
+	}
+
+	result := make([]UserLeaveBalanceResponse, 0, len(users))
+	var lastID uint
 	for _, user := range users {
-		balances, err := h.leaveBalanceModel.GetUserLeaveBalance(user.ID, year)
-		if err != nil {
+		balances, ok := balancesByUser[user.ID]
+		if !ok {
 			// Initialize balances if they don't exist
-			err = h.leaveBalanceModel.InitializeUserLeaveBalances(user.ID, year)
-			if err != nil {
+			if err := h.leaveBalanceModel.InitializeUserLeaveBalances(user.ID, year); err != nil {
 				continue // Skip this user if initialization fails
 			}
 			balances, _ = h.leaveBalanceModel.GetUserLeaveBalance(user.ID, year)
@@ -427,18 +498,16 @@ func (h *LeaveBalanceAdminHandler) GetAllUsersLeaveBalances(c *gin.Context) {
 
 		userResponse := convertToUserLeaveBalanceResponse(user, balances, year)
 		result = append(result, userResponse)
+		lastID = user.ID
 	}
 
 	c.JSON(http.StatusOK, LeaveBalanceListResponse{
 		Success: true,
 		Message: "All users leave balances retrieved successfully",
 		Data:    result,
-		Meta: struct {
-			Year  int `json:"year"`
-			Total int `json:"total"`
-		}{
-			Year:  year,
-			Total: len(result),
+		Meta: LeaveBalanceListMeta{
+			ListMeta: newListMeta(query, listResult, lastID),
+			Year:     year,
 		},
 	})
 }
@@ -533,3 +602,285 @@ func (h *LeaveBalanceAdminHandler) GetLeaveBalanceStats(c *gin.Context) {
 		},
 	})
 }
+
+// GetLeaveBalanceDeficits lists the users who took leave in advance and are in deficit for a year
+func (h *LeaveBalanceAdminHandler) GetLeaveBalanceDeficits(c *gin.Context) {
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	balances, err := h.leaveBalanceModel.GetBalancesInDeficit(year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave deficits",
+		})
+		return
+	}
+
+	deficits := make([]LeaveDeficit, len(balances))
+	for i, balance := range balances {
+		deficits[i] = LeaveDeficit{
+			User:          convertToUserInfo(balance.User),
+			LeaveType:     string(balance.LeaveType),
+			RemainingDays: balance.RemainingDays,
+			DeficitDays:   -balance.RemainingDays,
+		}
+	}
+
+	c.JSON(http.StatusOK, LeaveDeficitResponse{
+		Success: true,
+		Message: "Leave deficits retrieved successfully",
+		Data:    deficits,
+		Year:    year,
+	})
+}
+
+// GetUserLeaveAccruals retrieves the accrual history of a user for a year
+func (h *LeaveBalanceAdminHandler) GetUserLeaveAccruals(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	if _, err := h.userModel.GetUserByID(uint(userID)); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "User not found",
+		})
+		return
+	}
+
+	accruals, err := h.leaveAccrualModel.GetUserAccruals(uint(userID), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave accruals",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveAccrualHistoryResponse{
+		Success: true,
+		Message: "Leave accruals retrieved successfully",
+		Data:    accruals,
+		Year:    year,
+	})
+}
+
+// RunLeaveAccruals posts all accrual periods that have ended, as of today or the as_of date (YYYY-MM-DD).
+// Periods that were already posted are skipped, so the run can be repeated safely.
+func (h *LeaveBalanceAdminHandler) RunLeaveAccruals(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := h.userModel.HasUserPermission(userID.(uint), "MANAGE_LEAVE_POLICIES")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to run leave accruals",
+		})
+		return
+	}
+
+	asOf := time.Now()
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		asOf, err = time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid as_of date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+	}
+
+	posted, err := h.leaveAccrualModel.RunAccruals(asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to run leave accruals",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveAccrualRunResponse{
+		Success: true,
+		Message: "Leave accruals posted successfully",
+		Posted:  posted,
+		AsOf:    asOf,
+	})
+}
+
+// GetUserLeaveStatement retrieves the ledger statement of a user for a year
+func (h *LeaveBalanceAdminHandler) GetUserLeaveStatement(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	if _, err := h.userModel.GetUserByID(uint(userID)); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "User not found",
+		})
+		return
+	}
+
+	statement, err := h.leaveBalanceModel.GetLeaveStatement(uint(userID), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave statement",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveStatementResponse{
+		Success: true,
+		Message: "Leave statement retrieved successfully",
+		Data:    *statement,
+	})
+}
+
+// CheckLedgerConsistency lists balances whose counters drifted from their ledger entries
+func (h *LeaveBalanceAdminHandler) CheckLedgerConsistency(c *gin.Context) {
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	var userID uint64
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err = strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid user ID",
+			})
+			return
+		}
+	}
+
+	drifts, err := h.leaveBalanceModel.CheckLedgerConsistency(year, uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to check leave ledger",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LedgerConsistencyResponse{
+		Success: true,
+		Message: "Leave ledger checked successfully",
+		Data:    drifts,
+		Year:    year,
+	})
+}
+
+// ReconcileLeaveBalances fixes balances whose counters drifted from their ledger entries
+func (h *LeaveBalanceAdminHandler) ReconcileLeaveBalances(c *gin.Context) {
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	var userID uint64
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err = strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid user ID",
+			})
+			return
+		}
+	}
+
+	source := ledgerSource(c, "")
+	if source.ActorID == nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := h.userModel.HasUserPermission(*source.ActorID, "MANAGE_LEAVE_POLICIES")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to reconcile leave balances",
+		})
+		return
+	}
+
+	drifts, err := h.leaveBalanceModel.ReconcileLeaveBalances(year, uint(userID), source.ActorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to reconcile leave balances",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LedgerConsistencyResponse{
+		Success: true,
+		Message: "Leave balances reconciled successfully",
+		Data:    drifts,
+		Year:    year,
+	})
+}
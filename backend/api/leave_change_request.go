@@ -0,0 +1,392 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LeaveChangeRequestAPI struct {
+	db                *gorm.DB
+	changeModel       *model.LeaveChangeRequestModel
+	leaveRequestModel *model.LeaveRequestModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CreateLeaveChangeRequest struct {
+	LeaveType string `json:"leave_type"` // Defaults to the current leave type
+	StartDate string `json:"start_date"` // YYYY-MM-DD, defaults to the current start date
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD, defaults to the current end date
+	Reason    string `json:"reason" binding:"required"`
+}
+
+type LeaveChangeListResponse struct {
+	Success bool                       `json:"success"`
+	Message string                     `json:"message"`
+	Data    []model.LeaveChangeRequest `json:"data"`
+}
+
+type LeaveChangeResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    model.LeaveChangeRequest `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewLeaveChangeRequestAPI(db *gorm.DB) *LeaveChangeRequestAPI {
+	return &LeaveChangeRequestAPI{
+		db:                db,
+		changeModel:       model.NewLeaveChangeRequestModel(db),
+		leaveRequestModel: model.NewLeaveRequestModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (a *LeaveChangeRequestAPI) SetupRoutes(router *gin.RouterGroup) {
+	pendingGroup := router.Group("/leave-requests/changes")
+	pendingGroup.Use(middleware.AuthMiddleware())
+	{
+		pendingGroup.GET("/pending", a.GetPendingChanges)
+	}
+
+	changeGroup := router.Group("/leave-requests/:id/changes")
+	changeGroup.Use(middleware.AuthMiddleware())
+	{
+		changeGroup.GET("", a.GetChanges)
+		changeGroup.POST("", a.RequestChange)
+		changeGroup.POST("/:change_id/approve", a.ApproveChange)
+		changeGroup.POST("/:change_id/reject", a.RejectChange)
+		changeGroup.DELETE("/:change_id", a.WithdrawChange)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetPendingChanges lists the change requests waiting for the caller's decision
+func (a *LeaveChangeRequestAPI) GetPendingChanges(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	changes, err := a.changeModel.GetPendingChangeRequestsFor(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve pending change requests",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveChangeListResponse{
+		Success: true,
+		Message: "Pending change requests retrieved successfully",
+		Data:    changes,
+	})
+}
+
+// GetChanges lists the change requests of a leave request with their original and amended versions
+func (a *LeaveChangeRequestAPI) GetChanges(c *gin.Context) {
+	leaveRequest, _, ok := a.requireChangeAccess(c)
+	if !ok {
+		return
+	}
+
+	changes, err := a.changeModel.GetChangeRequests(leaveRequest.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve change requests",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveChangeListResponse{
+		Success: true,
+		Message: "Change requests retrieved successfully",
+		Data:    changes,
+	})
+}
+
+// RequestChange proposes new dates or a new type for approved leave; only the requester can ask for a change
+func (a *LeaveChangeRequestAPI) RequestChange(c *gin.Context) {
+	leaveRequest, userID, ok := a.requireChangeAccess(c)
+	if !ok {
+		return
+	}
+	if leaveRequest.UserID != userID {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Only the requester can change their leave",
+		})
+		return
+	}
+
+	var req CreateLeaveChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	change := model.LeaveChangeRequest{
+		RequestedByID: userID,
+		Reason:        req.Reason,
+		LeaveType:     leaveRequest.LeaveType,
+		StartDate:     leaveRequest.StartDate,
+		EndDate:       leaveRequest.EndDate,
+	}
+	if req.LeaveType != "" {
+		change.LeaveType = model.LeaveType(req.LeaveType)
+	}
+	if req.StartDate != "" {
+		startDate, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid start_date format. Expected YYYY-MM-DD",
+			})
+			return
+		}
+		change.StartDate = startDate
+	}
+	if req.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid end_date format. Expected YYYY-MM-DD",
+			})
+			return
+		}
+		change.EndDate = endDate
+	}
+
+	// Policy, overlap and balance failures are all reasons to turn the change down
+	if err := a.changeModel.RequestChange(leaveRequest, &change); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Change request validation failed",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	message := "Change request submitted for approval"
+	if change.Status == model.ChangeStatusApproved {
+		message = "Leave changed successfully"
+	}
+	c.JSON(http.StatusCreated, LeaveChangeResponse{
+		Success: true,
+		Message: message,
+		Data:    change,
+	})
+}
+
+// ApproveChange approves the current stage of a change request, applying the change after the last stage
+func (a *LeaveChangeRequestAPI) ApproveChange(c *gin.Context) {
+	a.decideChange(c, true)
+}
+
+// RejectChange rejects a change request; the leave stays as approved before
+func (a *LeaveChangeRequestAPI) RejectChange(c *gin.Context) {
+	a.decideChange(c, false)
+}
+
+// WithdrawChange lets the requester take back a pending change request
+func (a *LeaveChangeRequestAPI) WithdrawChange(c *gin.Context) {
+	leaveRequest, userID, ok := a.requireChangeAccess(c)
+	if !ok {
+		return
+	}
+	change, ok := a.findChange(c, leaveRequest.ID)
+	if !ok {
+		return
+	}
+
+	if change.RequestedByID != userID {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Only the requester can withdraw a change request",
+		})
+		return
+	}
+
+	if err := a.changeModel.WithdrawChange(change); err != nil {
+		if errors.Is(err, model.ErrChangeNotPending) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to withdraw change request",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Change request withdrawn successfully",
+	})
+}
+
+//---------- HELPERS ----------
+
+// decideChange approves or rejects the change request in the path. Whether the caller may decide is left to
+// the model, which also accepts delegates of the stage's approver.
+func (a *LeaveChangeRequestAPI) decideChange(c *gin.Context, approve bool) {
+	leaveRequest, userID, ok := a.loadLeaveRequest(c)
+	if !ok {
+		return
+	}
+	change, ok := a.findChange(c, leaveRequest.ID)
+	if !ok {
+		return
+	}
+
+	var req ApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	if err := a.changeModel.DecideChange(change, userID, approve, req.Comments); err != nil {
+		switch {
+		case errors.Is(err, model.ErrNotChangeApprover):
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Message: "Insufficient permissions to decide this change request",
+			})
+		case errors.Is(err, model.ErrChangeNotPending), errors.Is(err, model.ErrInvalidLeaveChange):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to process change request",
+			})
+		}
+		return
+	}
+
+	change, err := a.changeModel.GetChangeRequest(leaveRequest.ID, change.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve change request",
+		})
+		return
+	}
+
+	message := "Change request rejected"
+	switch {
+	case change.Status == model.ChangeStatusApproved:
+		message = "Change request approved and applied"
+	case change.Status == model.ChangeStatusPending:
+		message = "Change request approved at this stage"
+	}
+	c.JSON(http.StatusOK, LeaveChangeResponse{
+		Success: true,
+		Message: message,
+		Data:    *change,
+	})
+}
+
+// loadLeaveRequest loads the leave request in the path for an authenticated caller
+func (a *LeaveChangeRequestAPI) loadLeaveRequest(c *gin.Context) (*model.LeaveRequest, uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return nil, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return nil, 0, false
+	}
+
+	leaveRequest, err := a.leaveRequestModel.GetLeaveRequest(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Leave request not found",
+		})
+		return nil, 0, false
+	}
+	return leaveRequest, userID.(uint), true
+}
+
+// requireChangeAccess loads the leave request in the path and checks that the caller is its requester or one
+// of its approvers
+func (a *LeaveChangeRequestAPI) requireChangeAccess(c *gin.Context) (*model.LeaveRequest, uint, bool) {
+	leaveRequest, userID, ok := a.loadLeaveRequest(c)
+	if !ok {
+		return nil, 0, false
+	}
+
+	if leaveRequest.UserID != userID {
+		isApprover, err := a.leaveRequestModel.IsApprover(leaveRequest, userID)
+		if err != nil || !isApprover {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Message: "Insufficient permissions to access changes of this leave request",
+			})
+			return nil, 0, false
+		}
+	}
+	return leaveRequest, userID, true
+}
+
+// findChange loads the change request in the path
+func (a *LeaveChangeRequestAPI) findChange(c *gin.Context, leaveRequestID uint) (*model.LeaveChangeRequest, bool) {
+	changeID, err := strconv.ParseUint(c.Param("change_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid change request ID",
+		})
+		return nil, false
+	}
+
+	change, err := a.changeModel.GetChangeRequest(leaveRequestID, uint(changeID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Change request not found",
+		})
+		return nil, false
+	}
+	return change, true
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -17,6 +18,12 @@ type LeaveRequestHandler struct {
 	leaveBalanceModel  *model.LeaveBalanceModel
 	leaveCalendarModel *model.LeaveCalendarModel
 	leavePolicyModel   *model.LeavePolicyModel
+	leaveAccrualModel  *model.LeaveAccrualModel
+	delegationModel    *model.ApprovalDelegationModel
+	coverageModel      *model.TeamCoverageModel
+	blackoutModel      *model.BlackoutPeriodModel
+	leaveTypeModel     *model.LeaveTypeDefinitionModel
+	userModel          *model.UserModel
 }
 
 // NewLeaveRequestHandler creates a new leave request handler
@@ -26,6 +33,12 @@ func NewLeaveRequestHandler(db *gorm.DB) *LeaveRequestHandler {
 		leaveBalanceModel:  model.NewLeaveBalanceModel(db),
 		leaveCalendarModel: model.NewLeaveCalendarModel(db),
 		leavePolicyModel:   model.NewLeavePolicyModel(db),
+		leaveAccrualModel:  model.NewLeaveAccrualModel(db),
+		delegationModel:    model.NewApprovalDelegationModel(db),
+		coverageModel:      model.NewTeamCoverageModel(db),
+		blackoutModel:      model.NewBlackoutPeriodModel(db),
+		leaveTypeModel:     model.NewLeaveTypeDefinitionModel(db),
+		userModel:          model.NewUserModel(db),
 	}
 }
 
@@ -37,6 +50,14 @@ type CreateLeaveRequestRequest struct {
 	Reason    string `json:"reason"`
 }
 
+// CreateLeaveRequestOnBehalfRequest represents the request body for submitting leave for another employee
+type CreateLeaveRequestOnBehalfRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+	CreateLeaveRequestRequest
+	PreApprove bool   `json:"pre_approve"` // Approve at once, skipping the approval chain
+	Comments   string `json:"comments"`    // Recorded with the pre-approval
+}
+
 // UpdateLeaveRequestRequest represents the request body for updating a leave request
 type UpdateLeaveRequestRequest struct {
 	LeaveType string `json:"leave_type"`
@@ -45,11 +66,33 @@ type UpdateLeaveRequestRequest struct {
 	Reason    string `json:"reason"`
 }
 
+// ReturnEarlyRequest represents the request body for cutting approved leave short
+type ReturnEarlyRequest struct {
+	ReturnDate string `json:"return_date" binding:"required"` // YYYY-MM-DD, the first day back at work
+	Reason     string `json:"reason"`
+}
+
 // ApprovalRequest represents the request body for approval actions
 type ApprovalRequest struct {
 	Comments string `json:"comments"`
 }
 
+// BulkDecisionRequest approves or rejects several leave requests with the same comments
+type BulkDecisionRequest struct {
+	IDs      []uint `json:"ids" binding:"required,min=1,max=100,dive,gt=0"`
+	Action   string `json:"action" binding:"required,oneof=approve reject"`
+	Comments string `json:"comments"`
+}
+
+// BulkDecisionResult is the outcome of one leave request of a bulk decision
+type BulkDecisionResult struct {
+	ID      uint                     `json:"id"`
+	Success bool                     `json:"success"`
+	Status  model.LeaveRequestStatus `json:"status,omitempty"` // Status after the decision
+	Message string                   `json:"message,omitempty"`
+	Errors  []string                 `json:"errors,omitempty"`
+}
+
 // ErrorResponse represents a standard error response
 
 // CreateLeaveRequest creates a new leave request
@@ -74,32 +117,9 @@ func (h *LeaveRequestHandler) CreateLeaveRequest(c *gin.Context) {
 	}
 
 	// Parse date strings (ISO 8601 format with timezone)
-	startDate, err := time.Parse("2006-01-02T15:04:05-07:00", req.StartDate)
-	if err != nil {
-		// Try parsing without timezone as fallback
-		startDate, err = time.Parse("2006-01-02", req.StartDate)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Success: false,
-				Message: "Invalid start_date format. Expected YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS+07:00",
-				Errors:  []string{"start_date format is invalid"},
-			})
-			return
-		}
-	}
-
-	endDate, err := time.Parse("2006-01-02T15:04:05-07:00", req.EndDate)
-	if err != nil {
-		// Try parsing without timezone as fallback
-		endDate, err = time.Parse("2006-01-02", req.EndDate)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Success: false,
-				Message: "Invalid end_date format. Expected YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS+07:00",
-				Errors:  []string{"end_date format is invalid"},
-			})
-			return
-		}
+	startDate, endDate, ok := parseLeaveDates(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
 	}
 
 	// Convert string to LeaveType
@@ -115,78 +135,161 @@ func (h *LeaveRequestHandler) CreateLeaveRequest(c *gin.Context) {
 		Status:    model.StatusPending,
 	}
 
-	// Validate the request
-	if err := h.leaveRequestModel.ValidateLeaveRequest(leaveRequest); err != nil {
+	// Validate dates, policy, team coverage and balance
+	coverage, ok := h.checkNewLeaveRequest(c, leaveRequest)
+	if !ok {
+		return
+	}
+
+	// Create the leave request
+	if err := h.leaveRequestModel.CreateLeaveRequest(leaveRequest); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to create leave request",
+		})
+		return
+	}
+
+	// Create calendar entries
+	if err := h.leaveCalendarModel.CreateCalendarEntriesForLeaveRequest(leaveRequest); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to create calendar entries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":           true,
+		"message":           "Leave request created successfully",
+		"data":              leaveRequest,
+		"coverage_warnings": coverage.Conflicts,
+	})
+}
+
+// CreateLeaveRequestOnBehalf lets HR and team leads submit leave for an employee, for example sick leave
+// reported by phone. Such leave may start in the past and can be pre-approved by HR or management.
+func (h *LeaveRequestHandler) CreateLeaveRequestOnBehalf(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := h.userModel.HasUserPermission(userID.(uint), "SUBMIT_LEAVE_ON_BEHALF")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to submit leave on behalf of employees",
+		})
+		return
+	}
+
+	var req CreateLeaveRequestOnBehalfRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Success: false,
-			Message: "Validation failed",
+			Message: "Invalid request data",
 			Errors:  []string{err.Error()},
 		})
 		return
 	}
 
-	// Validate against leave policy
-	if err := h.leavePolicyModel.ValidateLeaveRequestAgainstPolicy(leaveRequest); err != nil {
+	if req.UserID == userID.(uint) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Success: false,
-			Message: "Leave policy validation failed",
-			Errors:  []string{err.Error()},
+			Message: "Use the regular leave request for your own leave",
+		})
+		return
+	}
+	if _, err := h.userModel.GetUserByID(req.UserID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "User not found",
 		})
 		return
 	}
 
-	// Check leave balance
-	balance, err := h.leaveBalanceModel.GetUserLeaveBalanceByType(userID.(uint), time.Now().Year(), leaveType)
-	if err != nil {
-		// Initialize balance if it doesn't exist
-		if err := h.leaveBalanceModel.InitializeUserLeaveBalances(userID.(uint), time.Now().Year()); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Success: false,
-				Message: "Failed to initialize leave balance",
-			})
-			return
+	canSubmit, err := h.leaveRequestModel.CanSubmitOnBehalf(userID.(uint), req.UserID)
+	if err != nil || !canSubmit {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to submit leave for this employee",
+		})
+		return
+	}
+
+	// Skipping the approval chain is left to those who approve across teams
+	if req.PreApprove {
+		preApprover := false
+		for _, permission := range []string{"APPROVE_LEAVE_HR", "APPROVE_LEAVE_MANAGEMENT"} {
+			if hasPermission, err := h.userModel.HasUserPermission(userID.(uint), permission); err == nil && hasPermission {
+				preApprover = true
+				break
+			}
 		}
-		balance, err = h.leaveBalanceModel.GetUserLeaveBalanceByType(userID.(uint), time.Now().Year(), leaveType)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
+		if !preApprover {
+			c.JSON(http.StatusForbidden, ErrorResponse{
 				Success: false,
-				Message: "Failed to get leave balance",
+				Message: "Insufficient permissions to pre-approve leave",
 			})
 			return
 		}
 	}
 
-	if balance.RemainingDays < leaveRequest.DaysRequested {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Success: false,
-			Message: "Insufficient leave balance",
-			Errors:  []string{fmt.Sprintf("You have %d days remaining, but requested %d days", balance.RemainingDays, leaveRequest.DaysRequested)},
-		})
+	startDate, endDate, ok := parseLeaveDates(c, req.StartDate, req.EndDate)
+	if !ok {
 		return
 	}
 
-	// Create the leave request
-	if err := h.leaveRequestModel.CreateLeaveRequest(leaveRequest); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Success: false,
-			Message: "Failed to create leave request",
-		})
+	submittedByID := userID.(uint)
+	leaveRequest := &model.LeaveRequest{
+		UserID:        req.UserID,
+		LeaveType:     model.LeaveType(req.LeaveType),
+		StartDate:     startDate,
+		EndDate:       endDate,
+		Reason:        req.Reason,
+		Status:        model.StatusPending,
+		SubmittedByID: &submittedByID,
+	}
+
+	// Retroactive leave is booked against the year it was taken in
+	coverage, ok := h.checkNewLeaveRequest(c, leaveRequest)
+	if !ok {
 		return
 	}
 
-	// Create calendar entries
-	if err := h.leaveCalendarModel.CreateCalendarEntriesForLeaveRequest(leaveRequest); err != nil {
+	if err := h.leaveRequestModel.SubmitOnBehalf(leaveRequest, req.PreApprove, req.Comments); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
-			Message: "Failed to create calendar entries",
+			Message: "Failed to create leave request",
 		})
 		return
 	}
 
+	// Pre-approved leave hands the employee's own approvals to their standing delegate, as approval does
+	if req.PreApprove {
+		if _, err := h.delegationModel.ActivateAutoDelegation(leaveRequest); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to activate approval delegation",
+			})
+			return
+		}
+	}
+
+	message := "Leave request submitted on behalf of the employee"
+	if req.PreApprove {
+		message = "Leave recorded and approved on behalf of the employee"
+	}
 	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "Leave request created successfully",
-		"data":    leaveRequest,
+		"success":           true,
+		"message":           message,
+		"data":              leaveRequest,
+		"coverage_warnings": coverage.Conflicts,
 	})
 }
 
@@ -201,18 +304,20 @@ func (h *LeaveRequestHandler) GetLeaveRequests(c *gin.Context) {
 		return
 	}
 
-	// Get year parameter (default to current year)
-	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
-	year, err := strconv.Atoi(yearStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Success: false,
-			Message: "Invalid year parameter",
-		})
+	// Parse pagination, sorting and filter parameters
+	query, ok := parseListQuery(c, model.LeaveRequestListSpec)
+	if !ok {
 		return
 	}
 
-	leaveRequests, err := h.leaveRequestModel.GetLeaveRequestsByYear(userID.(uint), year)
+	// Default to the current year unless a date range is given
+	_, hasStartAfter := query.Filters["start_after"]
+	_, hasStartBefore := query.Filters["start_before"]
+	if _, hasYear := query.Filters["year"]; !hasYear && !hasStartAfter && !hasStartBefore {
+		query.Filters["year"] = strconv.Itoa(time.Now().Year())
+	}
+
+	leaveRequests, result, err := h.leaveRequestModel.ListUserLeaveRequests(userID.(uint), query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
@@ -221,10 +326,16 @@ func (h *LeaveRequestHandler) GetLeaveRequests(c *gin.Context) {
 		return
 	}
 
+	var lastID uint
+	if len(leaveRequests) > 0 {
+		lastID = leaveRequests[len(leaveRequests)-1].ID
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Leave requests retrieved successfully",
 		"data":    leaveRequests,
+		"meta":    newListMeta(query, result, lastID),
 	})
 }
 
@@ -371,13 +482,9 @@ func (h *LeaveRequestHandler) UpdateLeaveRequest(c *gin.Context) {
 	// Recalculate days requested
 	leaveRequest.DaysRequested = int(leaveRequest.EndDate.Sub(leaveRequest.StartDate).Hours()/24) + 1
 
-	// Validate the updated request
-	if err := h.leaveRequestModel.ValidateLeaveRequest(leaveRequest); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Success: false,
-			Message: "Invalid request data",
-			Errors:  []string{err.Error()},
-		})
+	// The edited request goes through the same checks as a new one, which also recomputes the days taken in advance
+	coverage, ok := h.checkNewLeaveRequest(c, leaveRequest)
+	if !ok {
 		return
 	}
 
@@ -400,9 +507,10 @@ func (h *LeaveRequestHandler) UpdateLeaveRequest(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Leave request updated successfully",
-		"data":    leaveRequest,
+		"success":           true,
+		"message":           "Leave request updated successfully",
+		"data":              leaveRequest,
+		"coverage_warnings": coverage.Conflicts,
 	})
 }
 
@@ -451,6 +559,88 @@ func (h *LeaveRequestHandler) CancelLeaveRequest(c *gin.Context) {
 	})
 }
 
+// ReturnEarly cuts the requester's approved leave short at the return date; the remaining days are cancelled
+// and refunded, days already taken are not
+func (h *LeaveRequestHandler) ReturnEarly(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return
+	}
+
+	var req ReturnEarlyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	returnDate, err := time.Parse("2006-01-02", req.ReturnDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid return_date format. Expected YYYY-MM-DD",
+		})
+		return
+	}
+
+	leaveRequest, err := h.leaveRequestModel.GetLeaveRequest(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Leave request not found",
+		})
+		return
+	}
+	if leaveRequest.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to return early from this leave request",
+		})
+		return
+	}
+
+	leaveRequest, cancelledDays, err := h.leaveRequestModel.ReturnEarly(uint(id), userID.(uint), returnDate, req.Reason)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidEarlyReturn) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Cannot return early",
+				Errors:  []string{err.Error()},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to cut leave short",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        fmt.Sprintf("Leave cut short, %d days cancelled and refunded", cancelledDays),
+		"data":           leaveRequest,
+		"cancelled_days": cancelledDays,
+	})
+}
+
 // GetLeaveBalance retrieves leave balance for the authenticated user
 func (h *LeaveRequestHandler) GetLeaveBalance(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -497,6 +687,16 @@ func (h *LeaveRequestHandler) GetLeaveBalance(c *gin.Context) {
 		balanceResponses[i] = convertToLeaveBalanceResponse(balance)
 	}
 
+	summaries, err := h.leaveAccrualModel.GetAccrualSummaries(userID.(uint), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave accruals",
+		})
+		return
+	}
+	applyAccrualSummaries(balanceResponses, summaries)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Leave balance retrieved successfully",
@@ -505,8 +705,8 @@ func (h *LeaveRequestHandler) GetLeaveBalance(c *gin.Context) {
 	})
 }
 
-// GetLeaveStats retrieves leave statistics for the authenticated user
-func (h *LeaveRequestHandler) GetLeaveStats(c *gin.Context) {
+// GetLeaveAccruals retrieves the accrual history of the authenticated user
+func (h *LeaveRequestHandler) GetLeaveAccruals(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -516,24 +716,35 @@ func (h *LeaveRequestHandler) GetLeaveStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.leaveRequestModel.GetLeaveRequestStats(userID.(uint))
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	accruals, err := h.leaveAccrualModel.GetUserAccruals(userID.(uint), year)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
-			Message: "Failed to retrieve leave statistics",
+			Message: "Failed to retrieve leave accruals",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Leave statistics retrieved successfully",
-		"data":    stats,
+	c.JSON(http.StatusOK, LeaveAccrualHistoryResponse{
+		Success: true,
+		Message: "Leave accruals retrieved successfully",
+		Data:    accruals,
+		Year:    year,
 	})
 }
 
-// GetLeaveCalendar retrieves leave calendar for a specific year
-func (h *LeaveRequestHandler) GetLeaveCalendar(c *gin.Context) {
+// GetLeaveStatement retrieves the leave ledger statement of the authenticated user
+func (h *LeaveRequestHandler) GetLeaveStatement(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -543,7 +754,7 @@ func (h *LeaveRequestHandler) GetLeaveCalendar(c *gin.Context) {
 		return
 	}
 
-	yearStr := c.Param("year")
+	yearStr := c.DefaultQuery("year", strconv.Itoa(time.Now().Year()))
 	year, err := strconv.Atoi(yearStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -553,24 +764,24 @@ func (h *LeaveRequestHandler) GetLeaveCalendar(c *gin.Context) {
 		return
 	}
 
-	entries, err := h.leaveCalendarModel.GetCalendarEntriesForYear(userID.(uint), year)
+	statement, err := h.leaveBalanceModel.GetLeaveStatement(userID.(uint), year)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
-			Message: "Failed to retrieve leave calendar",
+			Message: "Failed to retrieve leave statement",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Leave calendar retrieved successfully",
-		"data":    entries,
+	c.JSON(http.StatusOK, LeaveStatementResponse{
+		Success: true,
+		Message: "Leave statement retrieved successfully",
+		Data:    *statement,
 	})
 }
 
-// GetPendingApprovals retrieves pending approvals for the authenticated user
-func (h *LeaveRequestHandler) GetPendingApprovals(c *gin.Context) {
+// GetLeaveStats retrieves leave statistics for the authenticated user
+func (h *LeaveRequestHandler) GetLeaveStats(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
@@ -580,18 +791,186 @@ func (h *LeaveRequestHandler) GetPendingApprovals(c *gin.Context) {
 		return
 	}
 
-	approvalType := c.Query("type") // team-lead, hr, management
-
-	var requests []model.LeaveRequest
-	var err error
-
+	stats, err := h.leaveRequestModel.GetLeaveRequestStats(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Leave statistics retrieved successfully",
+		"data":    stats,
+	})
+}
+
+// GetLeaveCalendar retrieves leave calendar for a specific year
+func (h *LeaveRequestHandler) GetLeaveCalendar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	yearStr := c.Param("year")
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid year parameter",
+		})
+		return
+	}
+
+	entries, err := h.leaveCalendarModel.GetCalendarEntriesForYear(userID.(uint), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave calendar",
+		})
+		return
+	}
+
+	blackouts, err := h.blackoutModel.GetUserBlackoutPeriods(userID.(uint),
+		time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve blackout periods",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"message":   "Leave calendar retrieved successfully",
+		"data":      entries,
+		"blackouts": blackouts,
+	})
+}
+
+// GetAvailableDates lists the days between start_date and end_date on which the user has no leave yet
+func (h *LeaveRequestHandler) GetAvailableDates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid start_date format. Expected YYYY-MM-DD",
+		})
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid end_date format. Expected YYYY-MM-DD",
+		})
+		return
+	}
+	if endDate.Before(startDate) || endDate.Sub(startDate) >= maxAvailabilityDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "end_date must be on or after start_date and the range at most 92 days",
+		})
+		return
+	}
+
+	dates, err := h.leaveCalendarModel.GetAvailableDates(userID.(uint), startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve available dates",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Available dates retrieved successfully",
+		"data":    dates,
+	})
+}
+
+// GetLeaveEligibility tells which leave types the user can request for leave starting on ?date=, today by default,
+// and the reasons for the others
+func (h *LeaveRequestHandler) GetLeaveEligibility(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	now := time.Now()
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid date format. Expected YYYY-MM-DD",
+			})
+			return
+		}
+		date = parsed
+	}
+
+	eligibility, err := h.leavePolicyModel.GetLeaveEligibility(userID.(uint), date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to check leave eligibility",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Leave eligibility retrieved successfully",
+		"data":    eligibility,
+	})
+}
+
+// GetPendingApprovals retrieves pending approvals for the authenticated user
+func (h *LeaveRequestHandler) GetPendingApprovals(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	approvalType := c.Query("type") // team-lead, hr, management
+
+	var requests []model.LeaveRequest
+	var err error
+
 	switch approvalType {
 	case "team-lead":
 		requests, err = h.leaveRequestModel.GetPendingTeamLeadApprovals(userID.(uint))
 	case "hr":
-		requests, err = h.leaveRequestModel.GetPendingHRApprovals()
+		requests, err = h.leaveRequestModel.GetPendingHRApprovals(userID.(uint))
 	case "management":
-		requests, err = h.leaveRequestModel.GetPendingManagementApprovals()
+		requests, err = h.leaveRequestModel.GetPendingManagementApprovals(userID.(uint))
 	default:
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Success: false,
@@ -646,47 +1025,12 @@ func (h *LeaveRequestHandler) ApproveLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	// Process the approval
-	if err := h.leaveRequestModel.ProcessLeaveRequestWorkflow(uint(id), userID.(uint), "approve", req.Comments); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Success: false,
-			Message: "Invalid request data",
-			Errors:  []string{err.Error()},
-		})
+	leaveRequest, status, errResp := h.decideLeaveRequest(uint(id), userID.(uint), "approve", req.Comments)
+	if errResp != nil {
+		c.JSON(status, *errResp)
 		return
 	}
 
-	// Get updated request to check if it's fully approved
-	leaveRequest, err := h.leaveRequestModel.GetLeaveRequest(uint(id))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Success: false,
-			Message: "Failed to retrieve updated request",
-		})
-		return
-	}
-
-	// If fully approved, update leave balance and calendar
-	if leaveRequest.Status == model.StatusApproved || leaveRequest.Status == model.StatusManagementApproved {
-		// Update leave balance
-		if err := h.leaveBalanceModel.IncrementUsedDays(leaveRequest.UserID, leaveRequest.StartDate.Year(), leaveRequest.LeaveType, leaveRequest.DaysRequested); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Success: false,
-				Message: "Failed to update leave balance",
-			})
-			return
-		}
-
-		// Update calendar entries status
-		if err := h.leaveCalendarModel.UpdateCalendarEntryStatus(uint(id), leaveRequest.Status); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Success: false,
-				Message: "Failed to update calendar entries",
-			})
-			return
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Leave request approved successfully",
@@ -725,28 +1069,77 @@ func (h *LeaveRequestHandler) RejectLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	// Process the rejection
-	if err := h.leaveRequestModel.ProcessLeaveRequestWorkflow(uint(id), userID.(uint), "reject", req.Comments); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+	if _, status, errResp := h.decideLeaveRequest(uint(id), userID.(uint), "reject", req.Comments); errResp != nil {
+		c.JSON(status, *errResp)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Leave request rejected successfully",
+	})
+}
+
+// BulkDecideLeaveRequests approves or rejects a list of leave requests. Each request is decided on its own,
+// under the same rules and with the same records as a single decision, so some may fail while others succeed.
+func (h *LeaveRequestHandler) BulkDecideLeaveRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Success: false,
-			Message: "Invalid request data",
-			Errors:  []string{err.Error()},
+			Message: "User not authenticated",
 		})
 		return
 	}
 
-	// Update calendar entries status
-	if err := h.leaveCalendarModel.UpdateCalendarEntryStatus(uint(id), model.StatusRejected); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+	var req BulkDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Success: false,
-			Message: "Failed to update calendar entries",
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
 		})
 		return
 	}
 
+	results := make([]BulkDecisionResult, 0, len(req.IDs))
+	seen := make(map[uint]bool)
+	succeeded := 0
+	for _, id := range req.IDs {
+		// Deciding a request twice would move it through two stages
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		leaveRequest, _, errResp := h.decideLeaveRequest(id, userID.(uint), req.Action, req.Comments)
+		if errResp != nil {
+			results = append(results, BulkDecisionResult{
+				ID:      id,
+				Message: errResp.Message,
+				Errors:  errResp.Errors,
+			})
+			continue
+		}
+
+		result := BulkDecisionResult{ID: id, Success: true, Status: model.StatusRejected}
+		if leaveRequest != nil {
+			result.Status = leaveRequest.Status
+		}
+		results = append(results, result)
+		succeeded++
+	}
+
+	verb := "approved"
+	if req.Action == "reject" {
+		verb = "rejected"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Leave request rejected successfully",
+		"success":   true,
+		"message":   fmt.Sprintf("%d of %d leave requests %s", succeeded, len(results), verb),
+		"data":      results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
 	})
 }
 
@@ -824,6 +1217,60 @@ func (h *LeaveRequestHandler) GetLeaveRequestTimeline(c *gin.Context) {
 	})
 }
 
+// GetLeaveRequestCoverage shows how a leave request affects the staffing of the requester's teams
+func (h *LeaveRequestHandler) GetLeaveRequestCoverage(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return
+	}
+
+	leaveRequest, err := h.leaveRequestModel.GetLeaveRequest(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Leave request not found",
+		})
+		return
+	}
+
+	if !h.canReviewLeaveRequest(leaveRequest, userID.(uint)) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Access denied",
+		})
+		return
+	}
+
+	report, err := h.coverageModel.CheckCoverage(leaveRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to check team coverage",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Team coverage retrieved successfully",
+		"data":    report,
+	})
+}
+
 // GetLeaveRequestSummary retrieves leave request summary for reporting
 func (h *LeaveRequestHandler) GetLeaveRequestSummary(c *gin.Context) {
 	_, exists := c.Get("user_id")
@@ -860,3 +1307,216 @@ func (h *LeaveRequestHandler) GetLeaveRequestSummary(c *gin.Context) {
 		"data":    summary,
 	})
 }
+
+// checkNewLeaveRequest validates a leave request before it is created or edited: dates and overlap, policy,
+// team coverage and the balance of the year the leave starts in
+func (h *LeaveRequestHandler) checkNewLeaveRequest(c *gin.Context, leaveRequest *model.LeaveRequest) (*model.CoverageReport, bool) {
+	// Validate the request
+	if err := h.leaveRequestModel.ValidateLeaveRequest(leaveRequest); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Errors:  []string{err.Error()},
+		})
+		return nil, false
+	}
+
+	// Validate against leave policy
+	if err := h.leavePolicyModel.ValidateLeaveRequestAgainstPolicy(leaveRequest); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Leave policy validation failed",
+			Errors:  []string{err.Error()},
+		})
+		return nil, false
+	}
+
+	// Check team staffing rules; mandatory rules block the request
+	coverage, ok := h.checkCoverage(c, leaveRequest)
+	if !ok {
+		return nil, false
+	}
+
+	// Check leave balance of the year the leave starts in, which is the balance its days are taken from
+	balanceYear := leaveRequest.StartDate.Year()
+	balance, err := h.leaveBalanceModel.GetUserLeaveBalanceByType(leaveRequest.UserID, balanceYear, leaveRequest.LeaveType)
+	if err != nil {
+		// Initialize balance if it doesn't exist
+		if err := h.leaveBalanceModel.InitializeUserLeaveBalances(leaveRequest.UserID, balanceYear); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to initialize leave balance",
+			})
+			return nil, false
+		}
+		balance, err = h.leaveBalanceModel.GetUserLeaveBalanceByType(leaveRequest.UserID, balanceYear, leaveRequest.LeaveType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to get leave balance",
+			})
+			return nil, false
+		}
+	}
+
+	// Leave types such as unpaid leave record the days taken without needing a balance
+	definition, err := h.leaveTypeModel.GetLeaveType(leaveRequest.LeaveType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to get leave type",
+		})
+		return nil, false
+	}
+
+	if !definition.DeductsBalance {
+		// An edited request may have moved to a type without a balance
+		leaveRequest.SetAdvance(nil, 0)
+		return coverage, true
+	}
+
+	// The policy may let part of the request be taken in advance of next year's allocation
+	policy, err := h.leavePolicyModel.GetLeavePolicyByTypeAndYear(leaveRequest.LeaveType, balanceYear)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to get leave policy",
+		})
+		return nil, false
+	}
+	advanceDays, ok := model.AdvanceDays(policy, balance.RemainingDays, leaveRequest.DaysRequested)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Insufficient leave balance",
+			Errors:  []string{model.InsufficientBalanceMessage(policy, balance.RemainingDays, leaveRequest.DaysRequested)},
+		})
+		return nil, false
+	}
+	leaveRequest.SetAdvance(policy, advanceDays)
+
+	return coverage, true
+}
+
+// parseLeaveDates parses the start and end dates of a request body, writing the error response when invalid
+func parseLeaveDates(c *gin.Context, start, end string) (time.Time, time.Time, bool) {
+	startDate, err := time.Parse("2006-01-02T15:04:05-07:00", start)
+	if err != nil {
+		// Try parsing without timezone as fallback
+		startDate, err = time.Parse("2006-01-02", start)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid start_date format. Expected YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS+07:00",
+				Errors:  []string{"start_date format is invalid"},
+			})
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	endDate, err := time.Parse("2006-01-02T15:04:05-07:00", end)
+	if err != nil {
+		// Try parsing without timezone as fallback
+		endDate, err = time.Parse("2006-01-02", end)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid end_date format. Expected YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS+07:00",
+				Errors:  []string{"end_date format is invalid"},
+			})
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	return startDate, endDate, true
+}
+
+// checkCoverage checks a request against the staffing rules of the requester's teams. It responds with a
+// conflict and returns false when a mandatory rule would be broken.
+func (h *LeaveRequestHandler) checkCoverage(c *gin.Context, leaveRequest *model.LeaveRequest) (*model.CoverageReport, bool) {
+	report, err := h.coverageModel.CheckCoverage(leaveRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to check team coverage",
+		})
+		return nil, false
+	}
+
+	if blocking := report.MandatoryConflicts(); len(blocking) > 0 {
+		messages := make([]string, len(blocking))
+		for i, conflict := range blocking {
+			messages[i] = conflict.Message
+		}
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Message: "Team coverage rules do not allow leave on these dates",
+			Errors:  messages,
+		})
+		return nil, false
+	}
+	return report, true
+}
+
+// decideLeaveRequest approves or rejects the current stage of a request as the given user. The workflow updates
+// the balance and calendar with the decision; fully approved leave also hands the requester's approvals to their
+// delegate. Approval returns the updated request; failures return the HTTP status and error response to send.
+func (h *LeaveRequestHandler) decideLeaveRequest(id, userID uint, action, comments string) (*model.LeaveRequest, int, *ErrorResponse) {
+	if err := h.leaveRequestModel.ProcessLeaveRequestWorkflow(id, userID, action, comments); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, model.ErrLeaveRequestNotPending) {
+			// Someone else decided the request first
+			status = http.StatusConflict
+		}
+		return nil, status, &ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		}
+	}
+
+	if action == "reject" {
+		return nil, http.StatusOK, nil
+	}
+
+	// Get updated request to check if it's fully approved
+	leaveRequest, err := h.leaveRequestModel.GetLeaveRequest(id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, &ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve updated request",
+		}
+	}
+
+	// Hand the requester's own approvals to their standing delegate while they are away
+	if leaveRequest.Status.IsBalanceDeducted() {
+		if _, err := h.delegationModel.ActivateAutoDelegation(leaveRequest); err != nil {
+			return nil, http.StatusInternalServerError, &ErrorResponse{
+				Success: false,
+				Message: "Failed to activate approval delegation",
+			}
+		}
+	}
+
+	return leaveRequest, http.StatusOK, nil
+}
+
+// canReviewLeaveRequest reports whether a user is the requester, the request's team lead or fallback
+// approver, someone who decided one of its stages, or someone who approves or views leave across teams
+func (h *LeaveRequestHandler) canReviewLeaveRequest(leaveRequest *model.LeaveRequest, userID uint) bool {
+	if leaveRequest.UserID == userID ||
+		(leaveRequest.TeamLeadID != nil && *leaveRequest.TeamLeadID == userID) ||
+		(leaveRequest.EscalatedToID != nil && *leaveRequest.EscalatedToID == userID) {
+		return true
+	}
+	// A fallback approver keeps access to what they decided
+	if decided, err := h.leaveRequestModel.HasDecided(leaveRequest.ID, userID); err == nil && decided {
+		return true
+	}
+	for _, permission := range []string{"APPROVE_LEAVE_HR", "APPROVE_LEAVE_MANAGEMENT", "VIEW_LEAVE_REQUESTS"} {
+		if hasPermission, err := h.userModel.HasUserPermission(userID, permission); err == nil && hasPermission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultLeaveTypeColor is used when a leave type is created without a color
+const defaultLeaveTypeColor = "#607D8B"
+
+type LeaveTypeAPI struct {
+	db             *gorm.DB
+	leaveTypeModel *model.LeaveTypeDefinitionModel
+	userModel      *model.UserModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type LeaveTypeRequest struct {
+	DisplayName    string `json:"display_name" binding:"required,min=2,max=100"`
+	Description    string `json:"description"`
+	Color          string `json:"color"`                     // Hex color such as #4CAF50
+	IsPaid         *bool  `json:"is_paid,omitempty"`         // Defaults to true
+	DeductsBalance *bool  `json:"deducts_balance,omitempty"` // Defaults to true
+	IsActive       *bool  `json:"is_active,omitempty"`       // Defaults to true
+	SortOrder      int    `json:"sort_order"`
+}
+
+type CreateLeaveTypeRequest struct {
+	Code string `json:"code" binding:"required"` // E.g. BEREAVEMENT
+	LeaveTypeRequest
+	Policy *LeaveTypePolicyRequest `json:"policy"` // Policy for the current year, without it the type cannot be requested yet
+}
+
+type LeaveTypePolicyRequest struct {
+	DefaultAllocation  int    `json:"default_allocation" binding:"min=0"`
+	MaxAllocation      int    `json:"max_allocation" binding:"min=0"`
+	MinNoticeDays      int    `json:"min_notice_days" binding:"min=0"`
+	MaxConsecutiveDays int    `json:"max_consecutive_days" binding:"required,min=1"`
+	Description        string `json:"description"`
+}
+
+type LeaveTypeListResponse struct {
+	Success bool                        `json:"success"`
+	Message string                      `json:"message"`
+	Data    []model.LeaveTypeDefinition `json:"data"`
+}
+
+type LeaveTypeResponse struct {
+	Success bool                      `json:"success"`
+	Message string                    `json:"message"`
+	Data    model.LeaveTypeDefinition `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewLeaveTypeAPI(db *gorm.DB) *LeaveTypeAPI {
+	return &LeaveTypeAPI{
+		db:             db,
+		leaveTypeModel: model.NewLeaveTypeDefinitionModel(db),
+		userModel:      model.NewUserModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (l *LeaveTypeAPI) SetupRoutes(router *gin.RouterGroup) {
+	leaveTypeGroup := router.Group("/leave-types")
+	leaveTypeGroup.Use(middleware.AuthMiddleware())
+	{
+		leaveTypeGroup.GET("", l.GetActiveLeaveTypes)
+	}
+
+	adminGroup := router.Group("/admin/leave-types")
+	adminGroup.Use(middleware.AuthMiddleware())
+	{
+		adminGroup.GET("", l.GetLeaveTypes)
+		adminGroup.POST("", l.CreateLeaveType)
+		adminGroup.PUT("/:code", l.UpdateLeaveType)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetActiveLeaveTypes lists the leave types employees can request
+func (l *LeaveTypeAPI) GetActiveLeaveTypes(c *gin.Context) {
+	if _, exists := c.Get("user_id"); !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	definitions, err := l.leaveTypeModel.GetLeaveTypes(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave types",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveTypeListResponse{
+		Success: true,
+		Message: "Leave types retrieved successfully",
+		Data:    definitions,
+	})
+}
+
+// GetLeaveTypes lists all leave types, including inactive ones
+func (l *LeaveTypeAPI) GetLeaveTypes(c *gin.Context) {
+	if !requirePolicyManager(c, l.userModel, "leave types") {
+		return
+	}
+
+	definitions, err := l.leaveTypeModel.GetLeaveTypes(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave types",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveTypeListResponse{
+		Success: true,
+		Message: "Leave types retrieved successfully",
+		Data:    definitions,
+	})
+}
+
+// CreateLeaveType adds a leave type and, when given, its policy for the current year
+func (l *LeaveTypeAPI) CreateLeaveType(c *gin.Context) {
+	if !requirePolicyManager(c, l.userModel, "leave types") {
+		return
+	}
+
+	var req CreateLeaveTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	definition := model.LeaveTypeDefinition{Code: model.LeaveType(strings.ToUpper(req.Code))}
+	applyLeaveTypeRequest(&definition, req.LeaveTypeRequest)
+
+	var policy *model.LeavePolicy
+	if req.Policy != nil {
+		maxAllocation := req.Policy.MaxAllocation
+		if maxAllocation < req.Policy.DefaultAllocation {
+			maxAllocation = req.Policy.DefaultAllocation
+		}
+		policy = &model.LeavePolicy{
+			Year:               time.Now().Year(),
+			DefaultAllocation:  req.Policy.DefaultAllocation,
+			MaxAllocation:      maxAllocation,
+			MinNoticeDays:      req.Policy.MinNoticeDays,
+			MaxConsecutiveDays: req.Policy.MaxConsecutiveDays,
+			RequiresApproval:   true,
+			UndecidedAction:    model.UndecidedNone,
+			ProRataRounding:    model.ProRataNearest,
+			IsActive:           true,
+			Description:        req.Policy.Description,
+		}
+	}
+
+	if err := l.leaveTypeModel.CreateLeaveType(&definition, policy); err != nil {
+		respondLeaveTypeError(c, err, "Failed to create leave type")
+		return
+	}
+
+	c.JSON(http.StatusCreated, LeaveTypeResponse{
+		Success: true,
+		Message: "Leave type created successfully",
+		Data:    definition,
+	})
+}
+
+// UpdateLeaveType changes the name, color and flags of a leave type; deactivating it stops new requests
+func (l *LeaveTypeAPI) UpdateLeaveType(c *gin.Context) {
+	if !requirePolicyManager(c, l.userModel, "leave types") {
+		return
+	}
+
+	definition, err := l.leaveTypeModel.GetLeaveType(model.LeaveType(strings.ToUpper(c.Param("code"))))
+	if err != nil {
+		if errors.Is(err, model.ErrUnknownLeaveType) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Leave type not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave type",
+		})
+		return
+	}
+
+	var req LeaveTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	applyLeaveTypeRequest(definition, req)
+	if err := l.leaveTypeModel.UpdateLeaveType(definition); err != nil {
+		respondLeaveTypeError(c, err, "Failed to update leave type")
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveTypeResponse{
+		Success: true,
+		Message: "Leave type updated successfully",
+		Data:    *definition,
+	})
+}
+
+//---------- HELPERS ----------
+
+// applyLeaveTypeRequest copies a request body onto a leave type
+func applyLeaveTypeRequest(definition *model.LeaveTypeDefinition, req LeaveTypeRequest) {
+	definition.DisplayName = req.DisplayName
+	definition.Description = req.Description
+	definition.Color = req.Color
+	if definition.Color == "" {
+		definition.Color = defaultLeaveTypeColor
+	}
+	definition.IsPaid = req.IsPaid == nil || *req.IsPaid
+	definition.DeductsBalance = req.DeductsBalance == nil || *req.DeductsBalance
+	definition.IsActive = req.IsActive == nil || *req.IsActive
+	definition.SortOrder = req.SortOrder
+}
+
+// respondLeaveTypeError maps leave type validation errors to bad requests
+func respondLeaveTypeError(c *gin.Context, err error, message string) {
+	if errors.Is(err, model.ErrInvalidLeaveTypeDefinition) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: message,
+	})
+}
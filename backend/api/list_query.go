@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+)
+
+// ListMeta is the pagination metadata returned by every list endpoint
+type ListMeta struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// parseListQuery reads page/limit or cursor, sort, q and the spec's filters from the query string.
+// It writes a 400 response and returns false when the query is invalid.
+func parseListQuery(c *gin.Context, spec model.ListSpec) (model.ListQuery, bool) {
+	query := model.ListQuery{
+		Page:    1,
+		Limit:   model.DefaultListLimit,
+		Filters: make(map[string]string),
+		Search:  strings.TrimSpace(c.Query("q")),
+	}
+
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			query.Page = parsed
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= model.MaxListLimit {
+			query.Limit = parsed
+		}
+	}
+
+	var errors []string
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 32)
+		if err != nil {
+			errors = append(errors, "cursor is invalid")
+		} else {
+			id := uint(parsed)
+			query.Cursor = &id
+		}
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			desc := strings.HasPrefix(field, "-")
+			query.Sort = append(query.Sort, model.SortField{
+				Field: strings.TrimPrefix(field, "-"),
+				Desc:  desc,
+			})
+		}
+	}
+
+	for name := range spec.Filters {
+		if value, ok := c.GetQuery(name); ok {
+			query.Filters[name] = value
+		}
+	}
+
+	errors = append(errors, spec.Validate(query)...)
+	if len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid query parameters",
+			Errors:  errors,
+		})
+		return query, false
+	}
+
+	return query, true
+}
+
+// newListMeta builds the list metadata for a page; lastID is the ID of the last item on the page
+func newListMeta(query model.ListQuery, result *model.ListResult, lastID uint) ListMeta {
+	meta := ListMeta{
+		Total:   result.Total,
+		Page:    query.Page,
+		Limit:   query.Limit,
+		HasNext: result.HasNext,
+		HasPrev: result.HasPrev,
+	}
+	// Cursor pages have no page number; start with cursor=0 and follow next_cursor
+	if query.Cursor != nil {
+		meta.Page = 0
+		if result.HasNext && lastID != 0 {
+			meta.NextCursor = strconv.FormatUint(uint64(lastID), 10)
+		}
+	}
+	return meta
+}
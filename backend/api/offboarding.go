@@ -0,0 +1,199 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type OffboardUserRequest struct {
+	TerminationDate string `json:"termination_date" validate:"required"` // YYYY-MM-DD, last working day
+	SuccessorID     *uint  `json:"successor_id,omitempty"`               // Takes over pending approvals and team leads
+	Reason          string `json:"reason,omitempty"`
+	DryRun          bool   `json:"dry_run"`
+}
+
+type OffboardingResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    model.OffboardingPlan `json:"data"`
+}
+
+type LeaveSettlementResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    []model.LeaveSettlement `json:"data"`
+}
+
+//---------- HANDLERS ----------
+
+// OffboardUser offboards a user at a termination date; with dry_run the changes are only previewed
+func (u *UserAPI) OffboardUser(c *gin.Context) {
+	// Check permission
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := u.userModel.HasUserPermission(userID.(uint), "OFFBOARD_USERS")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to offboard users",
+		})
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	if uint(id) == userID.(uint) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Cannot offboard your own account",
+		})
+		return
+	}
+
+	var req OffboardUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	if err := u.validate.Struct(req); err != nil {
+		var errors []string
+		for _, err := range err.(validator.ValidationErrors) {
+			errors = append(errors, err.Error())
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Errors:  errors,
+		})
+		return
+	}
+
+	terminationDate, err := time.Parse("2006-01-02", req.TerminationDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid termination date format. Use YYYY-MM-DD",
+		})
+		return
+	}
+
+	offboardingModel := model.NewOffboardingModel(u.db)
+	plan, err := offboardingModel.PlanOffboarding(uint(id), terminationDate, req.SuccessorID, req.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Cannot offboard user",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, OffboardingResponse{
+			Success: true,
+			Message: "Offboarding preview, no changes were made",
+			Data:    *plan,
+		})
+		return
+	}
+
+	// The plan is made again inside the offboarding transaction, against locked leave requests
+	plan, err = offboardingModel.Offboard(uint(id), terminationDate, req.SuccessorID, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to offboard user",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, OffboardingResponse{
+		Success: true,
+		Message: "User offboarded successfully",
+		Data:    *plan,
+	})
+}
+
+// GetLeaveSettlement returns the final leave settlement recorded when a user was offboarded
+func (u *UserAPI) GetLeaveSettlement(c *gin.Context) {
+	// Check permission
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := u.userModel.HasUserPermission(userID.(uint), "OFFBOARD_USERS")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to view leave settlements",
+		})
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+		return
+	}
+
+	settlements, err := model.NewOffboardingModel(u.db).GetLeaveSettlements(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave settlement",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LeaveSettlementResponse{
+		Success: true,
+		Message: "Leave settlement retrieved successfully",
+		Data:    settlements,
+	})
+}
@@ -0,0 +1,460 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/middleware"
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// payrollExportColumns are the columns of the CSV payroll export
+var payrollExportColumns = []string{
+	"period", "for_period", "user_id", "email", "full_name", "line_type", "leave_type",
+	"days", "daily_rate", "amount", "currency", "is_adjustment",
+}
+
+type PayrollAPI struct {
+	db              *gorm.DB
+	userModel       *model.UserModel
+	encashmentModel *model.LeaveEncashmentModel
+	payrollModel    *model.PayrollModel
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CreateEncashmentRequest struct {
+	LeaveType string `json:"leave_type" binding:"required"`
+	Year      int    `json:"year"` // Defaults to the current year
+	Days      int    `json:"days" binding:"required,min=1"`
+	Reason    string `json:"reason"`
+}
+
+type EncashmentListResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    []model.LeaveEncashment `json:"data"`
+}
+
+type EncashmentResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    model.LeaveEncashment `json:"data"`
+}
+
+type PayrollPeriodListResponse struct {
+	Success bool                  `json:"success"`
+	Message string                `json:"message"`
+	Data    []model.PayrollPeriod `json:"data"`
+}
+
+type PayrollExportResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    model.PayrollExport `json:"data"`
+}
+
+//---------- CONSTRUCTOR ----------
+
+func NewPayrollAPI(db *gorm.DB) *PayrollAPI {
+	return &PayrollAPI{
+		db:              db,
+		userModel:       model.NewUserModel(db),
+		encashmentModel: model.NewLeaveEncashmentModel(db),
+		payrollModel:    model.NewPayrollModel(db),
+	}
+}
+
+//---------- ROUTES ----------
+
+func (p *PayrollAPI) SetupRoutes(router *gin.RouterGroup) {
+	encashmentGroup := router.Group("/leave-encashments")
+	encashmentGroup.Use(middleware.AuthMiddleware())
+	{
+		encashmentGroup.GET("", p.GetMyEncashments)
+		encashmentGroup.POST("", p.SubmitEncashment)
+		encashmentGroup.GET("/pending", p.GetPendingEncashments)
+		encashmentGroup.POST("/:id/approve", p.ApproveEncashment)
+		encashmentGroup.POST("/:id/reject", p.RejectEncashment)
+		encashmentGroup.DELETE("/:id", p.WithdrawEncashment)
+	}
+
+	payrollGroup := router.Group("/payroll/periods")
+	payrollGroup.Use(middleware.AuthMiddleware())
+	{
+		payrollGroup.GET("", p.GetLockedPeriods)
+		payrollGroup.GET("/:period/export", p.ExportPayroll)
+		payrollGroup.POST("/:period/lock", p.LockPayrollPeriod)
+	}
+}
+
+//---------- HANDLERS ----------
+
+// GetMyEncashments lists the caller's encashment requests
+func (p *PayrollAPI) GetMyEncashments(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	encashments, err := p.encashmentModel.GetUserEncashments(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve leave encashments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, EncashmentListResponse{
+		Success: true,
+		Message: "Leave encashments retrieved successfully",
+		Data:    encashments,
+	})
+}
+
+// SubmitEncashment asks for unused days of a leave type to be paid out, within the policy limits
+func (p *PayrollAPI) SubmitEncashment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	var req CreateEncashmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+
+	encashment := model.LeaveEncashment{
+		UserID:    userID.(uint),
+		LeaveType: model.LeaveType(req.LeaveType),
+		Year:      req.Year,
+		Days:      req.Days,
+		Reason:    req.Reason,
+	}
+	if err := p.encashmentModel.SubmitEncashment(&encashment); err != nil {
+		if errors.Is(err, model.ErrInvalidEncashment) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Leave encashment validation failed",
+				Errors:  []string{err.Error()},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to submit leave encashment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, EncashmentResponse{
+		Success: true,
+		Message: "Leave encashment submitted for approval",
+		Data:    encashment,
+	})
+}
+
+// GetPendingEncashments lists the encashment requests waiting for a payroll decision
+func (p *PayrollAPI) GetPendingEncashments(c *gin.Context) {
+	if _, ok := p.requirePayrollManager(c, "Insufficient permissions to view pending leave encashments"); !ok {
+		return
+	}
+
+	encashments, err := p.encashmentModel.GetPendingEncashments()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve pending leave encashments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, EncashmentListResponse{
+		Success: true,
+		Message: "Pending leave encashments retrieved successfully",
+		Data:    encashments,
+	})
+}
+
+// ApproveEncashment approves a request, taking the days off the balance and fixing the amount to pay
+func (p *PayrollAPI) ApproveEncashment(c *gin.Context) {
+	p.decideEncashment(c, true)
+}
+
+// RejectEncashment rejects a request; the balance is left as it is
+func (p *PayrollAPI) RejectEncashment(c *gin.Context) {
+	p.decideEncashment(c, false)
+}
+
+// WithdrawEncashment lets the requester take back a pending encashment request
+func (p *PayrollAPI) WithdrawEncashment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+	id, ok := parseEncashmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := p.encashmentModel.WithdrawEncashment(id, userID.(uint)); err != nil {
+		if errors.Is(err, model.ErrEncashmentNotPending) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Only your own pending leave encashments can be withdrawn",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to withdraw leave encashment",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Leave encashment withdrawn successfully",
+	})
+}
+
+// GetLockedPeriods lists the payroll periods that have been locked
+func (p *PayrollAPI) GetLockedPeriods(c *gin.Context) {
+	if _, ok := p.requirePayrollManager(c, "Insufficient permissions to view payroll periods"); !ok {
+		return
+	}
+
+	periods, err := p.payrollModel.GetLockedPeriods()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve payroll periods",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PayrollPeriodListResponse{
+		Success: true,
+		Message: "Payroll periods retrieved successfully",
+		Data:    periods,
+	})
+}
+
+// ExportPayroll exports the unpaid-leave deductions and encashment payouts of a month as JSON or CSV
+func (p *PayrollAPI) ExportPayroll(c *gin.Context) {
+	if _, ok := p.requirePayrollManager(c, "Insufficient permissions to export payroll"); !ok {
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid export format",
+			Errors:  []string{"format must be json or csv"},
+		})
+		return
+	}
+
+	export, err := p.payrollModel.GetPayrollExport(c.Param("period"))
+	if err != nil {
+		respondPayrollError(c, err, "Failed to export payroll")
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, PayrollExportResponse{
+			Success: true,
+			Message: "Payroll exported successfully",
+			Data:    *export,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "payroll-"+export.Period+".csv"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(payrollExportColumns); err != nil {
+		return
+	}
+	for _, line := range export.Lines {
+		if err := writer.Write([]string{
+			line.Period,
+			line.ForPeriod,
+			strconv.FormatUint(uint64(line.UserID), 10),
+			line.Email,
+			line.FullName,
+			string(line.LineType),
+			string(line.LeaveType),
+			strconv.Itoa(line.Days),
+			strconv.FormatFloat(line.DailyRate, 'f', 2, 64),
+			strconv.FormatFloat(line.Amount, 'f', 2, 64),
+			line.Currency,
+			strconv.FormatBool(line.IsAdjustment),
+		}); err != nil {
+			return
+		}
+	}
+	writer.Flush()
+}
+
+// LockPayrollPeriod freezes the export of a month; later changes to it are paid as adjustments in the next
+// open month
+func (p *PayrollAPI) LockPayrollPeriod(c *gin.Context) {
+	userID, ok := p.requirePayrollManager(c, "Insufficient permissions to lock payroll periods")
+	if !ok {
+		return
+	}
+
+	export, err := p.payrollModel.LockPayrollPeriod(c.Param("period"), userID, time.Now())
+	if err != nil {
+		respondPayrollError(c, err, "Failed to lock payroll period")
+		return
+	}
+
+	c.JSON(http.StatusOK, PayrollExportResponse{
+		Success: true,
+		Message: "Payroll period locked successfully",
+		Data:    *export,
+	})
+}
+
+//---------- HELPERS ----------
+
+// decideEncashment approves or rejects the encashment request in the path as a payroll manager
+func (p *PayrollAPI) decideEncashment(c *gin.Context, approve bool) {
+	userID, ok := p.requirePayrollManager(c, "Insufficient permissions to decide leave encashments")
+	if !ok {
+		return
+	}
+	id, ok := parseEncashmentID(c)
+	if !ok {
+		return
+	}
+
+	var req ApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	encashment, err := p.encashmentModel.DecideEncashment(id, userID, approve, req.Comments, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Leave encashment not found",
+			})
+		case errors.Is(err, model.ErrEncashmentNotPending), errors.Is(err, model.ErrInvalidEncashment):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to process leave encashment",
+			})
+		}
+		return
+	}
+
+	message := "Leave encashment rejected"
+	if approve {
+		message = "Leave encashment approved"
+	}
+	c.JSON(http.StatusOK, EncashmentResponse{
+		Success: true,
+		Message: message,
+		Data:    *encashment,
+	})
+}
+
+// requirePayrollManager checks that the caller has the MANAGE_PAYROLL permission and returns their ID
+func (p *PayrollAPI) requirePayrollManager(c *gin.Context, message string) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return 0, false
+	}
+
+	hasPermission, err := p.userModel.HasUserPermission(userID.(uint), "MANAGE_PAYROLL")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: message,
+		})
+		return 0, false
+	}
+	return userID.(uint), true
+}
+
+// parseEncashmentID returns the encashment ID in the path
+func parseEncashmentID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid ID parameter",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondPayrollError maps payroll period errors to responses
+func respondPayrollError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, model.ErrInvalidPayrollPeriod):
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	case errors.Is(err, model.ErrPayrollPeriodLocked):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: message,
+		})
+	}
+}
@@ -43,11 +43,7 @@ type PermissionListResponse struct {
 	Success bool                 `json:"success"`
 	Message string               `json:"message"`
 	Data    []PermissionResponse `json:"data,omitempty"`
-	Meta    struct {
-		Total int `json:"total"`
-		Page  int `json:"page"`
-		Limit int `json:"limit"`
-	} `json:"meta,omitempty"`
+	Meta    ListMeta             `json:"meta,omitempty"`
 }
 
 type PermissionDetailResponse struct {
@@ -104,25 +100,25 @@ func (p *PermissionAPI) GetPermissions(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 10
-	if p := c.Query("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	// Parse pagination, sorting and filter parameters
+	query, ok := parseListQuery(c, model.PermissionListSpec)
+	if !ok {
+		return
 	}
 
 	// Get permissions
-	permissions := model.GetAllPermissions()
+	permissions, result, err := p.permissionModel.ListPermissions(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve permissions",
+		})
+		return
+	}
 
 	// Convert to response format
 	permissionResponses := make([]PermissionResponse, 0, len(permissions))
+	var lastID uint
 	for _, perm := range permissions {
 		permissionResponses = append(permissionResponses, PermissionResponse{
 			ID:          perm.Id,
@@ -131,32 +127,14 @@ func (p *PermissionAPI) GetPermissions(c *gin.Context) {
 			CreatedAt:   perm.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:   perm.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
-	}
-
-	// Apply pagination
-	start := (page - 1) * limit
-	end := start + limit
-	if start >= len(permissionResponses) {
-		permissionResponses = []PermissionResponse{}
-	} else if end > len(permissionResponses) {
-		permissionResponses = permissionResponses[start:]
-	} else {
-		permissionResponses = permissionResponses[start:end]
+		lastID = perm.Id
 	}
 
 	c.JSON(http.StatusOK, PermissionListResponse{
 		Success: true,
 		Message: "Permissions retrieved successfully",
 		Data:    permissionResponses,
-		Meta: struct {
-			Total int `json:"total"`
-			Page  int `json:"page"`
-			Limit int `json:"limit"`
-		}{
-			Total: len(permissions),
-			Page:  page,
-			Limit: limit,
-		},
+		Meta:    newListMeta(query, result, lastID),
 	})
 }
 
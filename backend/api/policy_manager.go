@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+)
+
+// requirePolicyManager checks that the caller may change leave policies and the settings that go with them,
+// such as SLAs, blackout periods, holidays and leave types. It writes a 401 or 403 response naming what the
+// caller tried to manage and returns false when they may not.
+func requirePolicyManager(c *gin.Context, userModel *model.UserModel, what string) bool {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return false
+	}
+
+	hasPermission, err := userModel.HasUserPermission(userID.(uint), "MANAGE_LEAVE_POLICIES")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to manage " + what,
+		})
+		return false
+	}
+	return true
+}
@@ -49,11 +49,7 @@ type RoleListResponse struct {
 	Success bool           `json:"success"`
 	Message string         `json:"message"`
 	Data    []RoleResponse `json:"data,omitempty"`
-	Meta    struct {
-		Total int `json:"total"`
-		Page  int `json:"page"`
-		Limit int `json:"limit"`
-	} `json:"meta,omitempty"`
+	Meta    ListMeta       `json:"meta,omitempty"`
 }
 
 type RoleDetailResponse struct {
@@ -110,22 +106,14 @@ func (r *RoleAPI) GetRoles(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 10
-	if p := c.Query("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	// Parse pagination, sorting and filter parameters
+	query, ok := parseListQuery(c, model.RoleListSpec)
+	if !ok {
+		return
 	}
 
 	// Get roles from database
-	roles, err := r.roleModel.GetAllRoles()
+	roles, result, err := r.roleModel.ListRoles(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
@@ -136,6 +124,7 @@ func (r *RoleAPI) GetRoles(c *gin.Context) {
 
 	// Convert to response format
 	roleResponses := make([]RoleResponse, 0, len(roles))
+	var lastID uint
 	for _, role := range roles {
 		roleResponses = append(roleResponses, RoleResponse{
 			ID:          role.ID,
@@ -146,32 +135,14 @@ func (r *RoleAPI) GetRoles(c *gin.Context) {
 			CreatedAt:   role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:   role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
-	}
-
-	// Apply pagination
-	start := (page - 1) * limit
-	end := start + limit
-	if start >= len(roleResponses) {
-		roleResponses = []RoleResponse{}
-	} else if end > len(roleResponses) {
-		roleResponses = roleResponses[start:]
-	} else {
-		roleResponses = roleResponses[start:end]
+		lastID = role.ID
 	}
 
 	c.JSON(http.StatusOK, RoleListResponse{
 		Success: true,
 		Message: "Roles retrieved successfully",
 		Data:    roleResponses,
-		Meta: struct {
-			Total int `json:"total"`
-			Page  int `json:"page"`
-			Limit int `json:"limit"`
-		}{
-			Total: len(roles),
-			Page:  page,
-			Limit: limit,
-		},
+		Meta:    newListMeta(query, result, lastID),
 	})
 }
 
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+)
+
+// maxAvailabilityDays limits the date range of one availability matrix
+const maxAvailabilityDays = 92
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type TeamAvailabilityResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    model.TeamAvailability `json:"data"`
+}
+
+//---------- HANDLERS ----------
+
+// GetTeamAvailability returns who of a team is away on each day between ?start_date= and ?end_date=,
+// the current month by default
+func (t *TeamAPI) GetTeamAvailability(c *gin.Context) {
+	teamID, ok := t.requireTeamCalendarViewer(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 1, -1)
+	if value := c.Query("start_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid start_date format. Expected YYYY-MM-DD",
+			})
+			return
+		}
+		startDate = parsed
+		endDate = startDate.AddDate(0, 1, -1)
+	}
+	if value := c.Query("end_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid end_date format. Expected YYYY-MM-DD",
+			})
+			return
+		}
+		endDate = parsed
+	}
+	if endDate.Before(startDate) || endDate.Sub(startDate) >= maxAvailabilityDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "end_date must be on or after start_date and the range at most 92 days",
+		})
+		return
+	}
+
+	availability, err := model.NewLeaveCalendarModel(t.db).GetTeamAvailability(teamID, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve team availability",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, TeamAvailabilityResponse{
+		Success: true,
+		Message: "Team availability retrieved successfully",
+		Data:    *availability,
+	})
+}
+
+// GetTeamCalendarStats returns the approved leave days of a team in ?year=, the current year by default
+func (t *TeamAPI) GetTeamCalendarStats(c *gin.Context) {
+	teamID, ok := t.requireTeamCalendarViewer(c)
+	if !ok {
+		return
+	}
+
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid year parameter",
+			})
+			return
+		}
+		year = parsed
+	}
+
+	stats, err := model.NewLeaveCalendarModel(t.db).GetTeamCalendarStats(teamID, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve team calendar statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Team calendar statistics retrieved successfully",
+		"data":    stats,
+	})
+}
+
+//---------- HELPERS ----------
+
+// requireTeamCalendarViewer checks that the caller belongs to or leads the team in the path, or may view
+// all leave requests, and returns the team ID
+func (t *TeamAPI) requireTeamCalendarViewer(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return 0, false
+	}
+
+	teamID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid team ID",
+		})
+		return 0, false
+	}
+
+	team, err := t.teamModel.GetTeam(uint(teamID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Team not found",
+		})
+		return 0, false
+	}
+
+	if team.TeamLeadID == userID.(uint) {
+		return team.ID, true
+	}
+	userModel := model.NewUserModel(t.db)
+	if teamIDs, err := userModel.GetUserTeamIDs(userID.(uint)); err == nil && containsUint(teamIDs, team.ID) {
+		return team.ID, true
+	}
+	hasPermission, err := userModel.HasUserPermission(userID.(uint), "VIEW_LEAVE_REQUESTS")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to view this team's calendar",
+		})
+		return 0, false
+	}
+	return team.ID, true
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type CoverageRuleRequest struct {
+	Name        string `json:"name" validate:"required,min=3,max=100"`
+	RuleType    string `json:"rule_type" validate:"required,oneof=MIN_PRESENT KEY_MEMBERS"`
+	MinPresent  int    `json:"min_present" validate:"min=0"`
+	MemberIDs   []uint `json:"member_ids"`
+	MaxAbsent   int    `json:"max_absent" validate:"min=0"`
+	IsMandatory bool   `json:"is_mandatory"`
+	IsActive    *bool  `json:"is_active,omitempty"` // Defaults to true
+}
+
+type CoverageRuleListResponse struct {
+	Success bool                     `json:"success"`
+	Message string                   `json:"message"`
+	Data    []model.TeamCoverageRule `json:"data"`
+}
+
+type CoverageRuleResponse struct {
+	Success bool                   `json:"success"`
+	Message string                 `json:"message"`
+	Data    model.TeamCoverageRule `json:"data"`
+}
+
+//---------- HANDLERS ----------
+
+// GetCoverageRules lists the staffing rules of a team
+func (t *TeamAPI) GetCoverageRules(c *gin.Context) {
+	teamID, ok := t.requireCoverageManager(c)
+	if !ok {
+		return
+	}
+
+	rules, err := model.NewTeamCoverageModel(t.db).GetTeamRules(teamID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve coverage rules",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CoverageRuleListResponse{
+		Success: true,
+		Message: "Coverage rules retrieved successfully",
+		Data:    rules,
+	})
+}
+
+// CreateCoverageRule adds a staffing rule to a team
+func (t *TeamAPI) CreateCoverageRule(c *gin.Context) {
+	teamID, ok := t.requireCoverageManager(c)
+	if !ok {
+		return
+	}
+
+	var req CoverageRuleRequest
+	if !t.bindCoverageRule(c, &req) {
+		return
+	}
+
+	rule := model.TeamCoverageRule{TeamID: teamID}
+	applyCoverageRuleRequest(&rule, req)
+	if err := model.NewTeamCoverageModel(t.db).CreateRule(&rule); err != nil {
+		respondCoverageRuleError(c, err, "Failed to create coverage rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, CoverageRuleResponse{
+		Success: true,
+		Message: "Coverage rule created successfully",
+		Data:    rule,
+	})
+}
+
+// UpdateCoverageRule replaces a staffing rule of a team
+func (t *TeamAPI) UpdateCoverageRule(c *gin.Context) {
+	teamID, ok := t.requireCoverageManager(c)
+	if !ok {
+		return
+	}
+
+	ruleID, err := strconv.ParseUint(c.Param("rule_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+		return
+	}
+
+	var req CoverageRuleRequest
+	if !t.bindCoverageRule(c, &req) {
+		return
+	}
+
+	coverageModel := model.NewTeamCoverageModel(t.db)
+	rule, err := coverageModel.GetRule(teamID, uint(ruleID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Coverage rule not found",
+		})
+		return
+	}
+
+	applyCoverageRuleRequest(rule, req)
+	if err := coverageModel.UpdateRule(rule); err != nil {
+		respondCoverageRuleError(c, err, "Failed to update coverage rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, CoverageRuleResponse{
+		Success: true,
+		Message: "Coverage rule updated successfully",
+		Data:    *rule,
+	})
+}
+
+// DeleteCoverageRule removes a staffing rule of a team
+func (t *TeamAPI) DeleteCoverageRule(c *gin.Context) {
+	teamID, ok := t.requireCoverageManager(c)
+	if !ok {
+		return
+	}
+
+	ruleID, err := strconv.ParseUint(c.Param("rule_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+		return
+	}
+
+	if err := model.NewTeamCoverageModel(t.db).DeleteRule(teamID, uint(ruleID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Success: false,
+				Message: "Coverage rule not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to delete coverage rule",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Coverage rule deleted successfully",
+	})
+}
+
+//---------- HELPERS ----------
+
+// requireCoverageManager checks that the caller manages teams or leads the team in the path and returns the team ID
+func (t *TeamAPI) requireCoverageManager(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return 0, false
+	}
+
+	teamID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid team ID",
+		})
+		return 0, false
+	}
+
+	team, err := t.teamModel.GetTeam(uint(teamID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Success: false,
+			Message: "Team not found",
+		})
+		return 0, false
+	}
+
+	if team.TeamLeadID != userID.(uint) {
+		hasPermission, err := model.NewUserModel(t.db).HasUserPermission(userID.(uint), "MANAGE_TEAMS")
+		if err != nil || !hasPermission {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Success: false,
+				Message: "Insufficient permissions to manage coverage rules",
+			})
+			return 0, false
+		}
+	}
+	return team.ID, true
+}
+
+// bindCoverageRule parses and validates a coverage rule request body
+func (t *TeamAPI) bindCoverageRule(c *gin.Context, req *CoverageRuleRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Errors:  []string{err.Error()},
+		})
+		return false
+	}
+	if err := t.validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Validation failed",
+			Errors:  []string{err.Error()},
+		})
+		return false
+	}
+	return true
+}
+
+// applyCoverageRuleRequest copies a request body onto a rule
+func applyCoverageRuleRequest(rule *model.TeamCoverageRule, req CoverageRuleRequest) {
+	rule.Name = req.Name
+	rule.RuleType = model.CoverageRuleType(req.RuleType)
+	rule.MinPresent = req.MinPresent
+	rule.MemberIDs = model.UintArray(req.MemberIDs)
+	rule.MaxAbsent = req.MaxAbsent
+	rule.IsMandatory = req.IsMandatory
+	rule.IsActive = req.IsActive == nil || *req.IsActive
+}
+
+// respondCoverageRuleError maps rule validation errors to bad requests
+func respondCoverageRuleError(c *gin.Context, err error, message string) {
+	if errors.Is(err, model.ErrInvalidCoverageRule) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Success: false,
+		Message: message,
+	})
+}
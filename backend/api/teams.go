@@ -49,11 +49,7 @@ type TeamListResponse struct {
 	Success bool           `json:"success"`
 	Message string         `json:"message"`
 	Data    []TeamResponse `json:"data,omitempty"`
-	Meta    struct {
-		Total int `json:"total"`
-		Page  int `json:"page"`
-		Limit int `json:"limit"`
-	} `json:"meta,omitempty"`
+	Meta    ListMeta       `json:"meta,omitempty"`
 }
 
 type TeamDetailResponse struct {
@@ -86,6 +82,12 @@ func (t *TeamAPI) SetupRoutes(router *gin.RouterGroup) {
 		teamGroup.POST("/:id/members", t.AddTeamMember)
 		teamGroup.DELETE("/:id/members/:user_id", t.RemoveTeamMember)
 		teamGroup.GET("/:id/members", t.GetTeamMembers)
+		teamGroup.GET("/:id/coverage-rules", t.GetCoverageRules)
+		teamGroup.POST("/:id/coverage-rules", t.CreateCoverageRule)
+		teamGroup.PUT("/:id/coverage-rules/:rule_id", t.UpdateCoverageRule)
+		teamGroup.DELETE("/:id/coverage-rules/:rule_id", t.DeleteCoverageRule)
+		teamGroup.GET("/:id/availability", t.GetTeamAvailability)
+		teamGroup.GET("/:id/calendar-stats", t.GetTeamCalendarStats)
 	}
 }
 
@@ -113,22 +115,14 @@ func (t *TeamAPI) GetTeams(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 10
-	if p := c.Query("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	// Parse pagination, sorting and filter parameters
+	query, ok := parseListQuery(c, model.TeamListSpec)
+	if !ok {
+		return
 	}
 
 	// Get teams from database
-	teams, err := t.teamModel.GetAllTeams()
+	teams, result, err := t.teamModel.ListTeams(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
@@ -139,6 +133,7 @@ func (t *TeamAPI) GetTeams(c *gin.Context) {
 
 	// Convert to response format
 	teamResponses := make([]TeamResponse, 0, len(teams))
+	var lastID uint
 	for _, team := range teams {
 		teamResponses = append(teamResponses, TeamResponse{
 			ID:          team.ID,
@@ -149,32 +144,14 @@ func (t *TeamAPI) GetTeams(c *gin.Context) {
 			CreatedAt:   team.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:   team.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		})
-	}
-
-	// Apply pagination
-	start := (page - 1) * limit
-	end := start + limit
-	if start >= len(teamResponses) {
-		teamResponses = []TeamResponse{}
-	} else if end > len(teamResponses) {
-		teamResponses = teamResponses[start:]
-	} else {
-		teamResponses = teamResponses[start:end]
+		lastID = team.ID
 	}
 
 	c.JSON(http.StatusOK, TeamListResponse{
 		Success: true,
 		Message: "Teams retrieved successfully",
 		Data:    teamResponses,
-		Meta: struct {
-			Total int `json:"total"`
-			Page  int `json:"page"`
-			Limit int `json:"limit"`
-		}{
-			Total: len(teams),
-			Page:  page,
-			Limit: limit,
-		},
+		Meta:    newListMeta(query, result, lastID),
 	})
 }
 
@@ -33,6 +33,11 @@ type CreateUserRequest struct {
 	PrimaryTeamID  uint    `json:"primary_team_id" validate:"required"`
 	RoleIDs        []uint  `json:"role_ids,omitempty"`
 	TeamIDs        []uint  `json:"team_ids,omitempty"`
+
+	EmploymentStartDate string `json:"employment_start_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	EmploymentType      string `json:"employment_type,omitempty" validate:"omitempty,oneof=FULL_TIME PART_TIME CONTRACTOR INTERN"`
+	ProbationEndDate    string `json:"probation_end_date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Gender              string `json:"gender,omitempty" validate:"omitempty,oneof=FEMALE MALE OTHER"`
 }
 
 type UpdateUserRequest struct {
@@ -46,23 +51,38 @@ type UpdateUserRequest struct {
 	PrimaryTeamID  *uint    `json:"primary_team_id,omitempty"`
 	RoleIDs        []uint   `json:"role_ids,omitempty"`
 	TeamIDs        []uint   `json:"team_ids,omitempty"`
+
+	// Employment dates as YYYY-MM-DD; an empty string clears the date
+	EmploymentStartDate *string `json:"employment_start_date,omitempty"`
+	EmploymentEndDate   *string `json:"employment_end_date,omitempty"`
+	ProbationEndDate    *string `json:"probation_end_date,omitempty"`
+
+	// Attributes checked by leave eligibility rules; an empty gender clears it
+	EmploymentType *string `json:"employment_type,omitempty" validate:"omitempty,oneof=FULL_TIME PART_TIME CONTRACTOR INTERN"`
+	Gender         *string `json:"gender,omitempty" validate:"omitempty,oneof=FEMALE MALE OTHER"`
 }
 
 type UserDetailResponse struct {
-	ID             uint       `json:"id"`
-	Email          string     `json:"email"`
-	FirstName      string     `json:"first_name"`
-	LastName       string     `json:"last_name"`
-	IsActive       bool       `json:"is_active"`
-	Salary         float64    `json:"salary"`
-	SalaryCurrency string     `json:"salary_currency"`
-	PrimaryRoleID  uint       `json:"primary_role_id"`
-	PrimaryTeamID  uint       `json:"primary_team_id"`
-	Roles          []RoleInfo `json:"roles,omitempty"`
-	Teams          []TeamInfo `json:"teams,omitempty"`
-	LastLogin      *time.Time `json:"last_login,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID                  uint       `json:"id"`
+	Email               string     `json:"email"`
+	FirstName           string     `json:"first_name"`
+	LastName            string     `json:"last_name"`
+	IsActive            bool       `json:"is_active"`
+	Salary              float64    `json:"salary"`
+	SalaryCurrency      string     `json:"salary_currency"`
+	PrimaryRoleID       uint       `json:"primary_role_id"`
+	PrimaryTeamID       uint       `json:"primary_team_id"`
+	Roles               []RoleInfo `json:"roles,omitempty"`
+	Teams               []TeamInfo `json:"teams,omitempty"`
+	LastLogin           *time.Time `json:"last_login,omitempty"`
+	EmploymentStartDate *time.Time `json:"employment_start_date,omitempty"`
+	EmploymentEndDate   *time.Time `json:"employment_end_date,omitempty"`
+	EmploymentType      string     `json:"employment_type"`
+	ProbationEndDate    *time.Time `json:"probation_end_date,omitempty"`
+	Gender              string     `json:"gender,omitempty"`
+	TerminationDate     *time.Time `json:"termination_date,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type RoleInfo struct {
@@ -81,11 +101,7 @@ type UserListResponse struct {
 	Success bool                 `json:"success"`
 	Message string               `json:"message"`
 	Data    []UserDetailResponse `json:"data,omitempty"`
-	Meta    struct {
-		Total int `json:"total"`
-		Page  int `json:"page"`
-		Limit int `json:"limit"`
-	} `json:"meta,omitempty"`
+	Meta    ListMeta             `json:"meta,omitempty"`
 }
 
 type UserDetailResponseWrapper struct {
@@ -113,9 +129,13 @@ func (u *UserAPI) SetupRoutes(r *gin.RouterGroup) {
 		userGroup.GET("/get_me", u.GetMe)
 		userGroup.POST("", u.CreateUser)
 		userGroup.GET("", u.GetUsers)
+		userGroup.POST("/import", u.ImportUsers)
+		userGroup.GET("/export", u.ExportUsers)
 		userGroup.GET("/:id", u.GetUser)
 		userGroup.PUT("/:id", u.UpdateUser)
 		userGroup.DELETE("/:id", u.DeleteUser)
+		userGroup.POST("/:id/offboard", u.OffboardUser)
+		userGroup.GET("/:id/settlement", u.GetLeaveSettlement)
 	}
 }
 
@@ -180,20 +200,26 @@ func (u *UserAPI) GetMe(c *gin.Context) {
 	}
 
 	response := UserDetailResponse{
-		ID:             user.ID,
-		Email:          user.Email,
-		FirstName:      user.FirstName,
-		LastName:       user.LastName,
-		IsActive:       user.IsActiveUser,
-		Salary:         user.Salary,
-		SalaryCurrency: user.SalaryCurrency,
-		PrimaryRoleID:  user.PrimaryRoleID,
-		PrimaryTeamID:  user.PrimaryTeamID,
-		Roles:          roleInfos,
-		Teams:          teamInfos,
-		LastLogin:      user.LastLoginTime,
-		CreatedAt:      user.CreatedAt,
-		UpdatedAt:      user.UpdatedAt,
+		ID:                  user.ID,
+		Email:               user.Email,
+		FirstName:           user.FirstName,
+		LastName:            user.LastName,
+		IsActive:            user.IsActiveUser,
+		Salary:              user.Salary,
+		SalaryCurrency:      user.SalaryCurrency,
+		PrimaryRoleID:       user.PrimaryRoleID,
+		PrimaryTeamID:       user.PrimaryTeamID,
+		Roles:               roleInfos,
+		Teams:               teamInfos,
+		LastLogin:           user.LastLoginTime,
+		TerminationDate:     user.TerminationDate,
+		EmploymentStartDate: user.EmploymentStartDate,
+		EmploymentEndDate:   user.EmploymentEndDate,
+		EmploymentType:      string(user.EmploymentType),
+		ProbationEndDate:    user.ProbationEndDate,
+		Gender:              string(user.Gender),
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, UserDetailResponseWrapper{
@@ -270,6 +296,18 @@ func (u *UserAPI) CreateUser(c *gin.Context) {
 		PrimaryRoleID:  req.PrimaryRoleID,
 		PrimaryTeamID:  req.PrimaryTeamID,
 	}
+	if req.EmploymentStartDate != "" {
+		startDate, _ := time.Parse("2006-01-02", req.EmploymentStartDate) // Format checked by validation
+		user.EmploymentStartDate = &startDate
+	}
+	if req.EmploymentType != "" {
+		user.EmploymentType = model.EmploymentType(req.EmploymentType)
+	}
+	if req.ProbationEndDate != "" {
+		probationEndDate, _ := time.Parse("2006-01-02", req.ProbationEndDate) // Format checked by validation
+		user.ProbationEndDate = &probationEndDate
+	}
+	user.Gender = model.Gender(req.Gender)
 
 	if err := u.userModel.CreateNewUser(user); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -311,17 +349,22 @@ func (u *UserAPI) CreateUser(c *gin.Context) {
 
 	// Convert to response format
 	response := UserDetailResponse{
-		ID:             updatedUser.ID,
-		Email:          updatedUser.Email,
-		FirstName:      updatedUser.FirstName,
-		LastName:       updatedUser.LastName,
-		IsActive:       updatedUser.IsActiveUser,
-		Salary:         updatedUser.Salary,
-		SalaryCurrency: updatedUser.SalaryCurrency,
-		PrimaryRoleID:  updatedUser.PrimaryRoleID,
-		PrimaryTeamID:  updatedUser.PrimaryTeamID,
-		CreatedAt:      updatedUser.CreatedAt,
-		UpdatedAt:      updatedUser.UpdatedAt,
+		ID:                  updatedUser.ID,
+		Email:               updatedUser.Email,
+		FirstName:           updatedUser.FirstName,
+		LastName:            updatedUser.LastName,
+		IsActive:            updatedUser.IsActiveUser,
+		Salary:              updatedUser.Salary,
+		SalaryCurrency:      updatedUser.SalaryCurrency,
+		PrimaryRoleID:       updatedUser.PrimaryRoleID,
+		PrimaryTeamID:       updatedUser.PrimaryTeamID,
+		EmploymentStartDate: updatedUser.EmploymentStartDate,
+		EmploymentEndDate:   updatedUser.EmploymentEndDate,
+		EmploymentType:      string(updatedUser.EmploymentType),
+		ProbationEndDate:    updatedUser.ProbationEndDate,
+		Gender:              string(updatedUser.Gender),
+		CreatedAt:           updatedUser.CreatedAt,
+		UpdatedAt:           updatedUser.UpdatedAt,
 	}
 
 	c.JSON(http.StatusCreated, UserDetailResponseWrapper{
@@ -352,22 +395,14 @@ func (u *UserAPI) GetUsers(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	page := 1
-	limit := 10
-	if p := c.Query("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
-		}
-	}
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
-			limit = parsed
-		}
+	// Parse pagination, sorting and filter parameters
+	query, ok := parseListQuery(c, model.UserListSpec)
+	if !ok {
+		return
 	}
 
 	// Get users
-	users, err := u.userModel.GetAllUsers()
+	users, result, err := u.userModel.ListUsers(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Success: false,
@@ -378,47 +413,36 @@ func (u *UserAPI) GetUsers(c *gin.Context) {
 
 	// Convert to response format
 	userResponses := make([]UserDetailResponse, len(users))
+	var lastID uint
 	for i, user := range users {
 		userResponses[i] = UserDetailResponse{
-			ID:             user.ID,
-			Email:          user.Email,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			IsActive:       user.IsActiveUser,
-			Salary:         user.Salary,
-			SalaryCurrency: user.SalaryCurrency,
-			PrimaryRoleID:  user.PrimaryRoleID,
-			PrimaryTeamID:  user.PrimaryTeamID,
-			LastLogin:      user.LastLoginTime,
-			CreatedAt:      user.CreatedAt,
-			UpdatedAt:      user.UpdatedAt,
+			ID:                  user.ID,
+			Email:               user.Email,
+			FirstName:           user.FirstName,
+			LastName:            user.LastName,
+			IsActive:            user.IsActiveUser,
+			Salary:              user.Salary,
+			SalaryCurrency:      user.SalaryCurrency,
+			PrimaryRoleID:       user.PrimaryRoleID,
+			PrimaryTeamID:       user.PrimaryTeamID,
+			LastLogin:           user.LastLoginTime,
+			TerminationDate:     user.TerminationDate,
+			EmploymentStartDate: user.EmploymentStartDate,
+			EmploymentEndDate:   user.EmploymentEndDate,
+			EmploymentType:      string(user.EmploymentType),
+			ProbationEndDate:    user.ProbationEndDate,
+			Gender:              string(user.Gender),
+			CreatedAt:           user.CreatedAt,
+			UpdatedAt:           user.UpdatedAt,
 		}
-	}
-
-	// Apply pagination
-	start := (page - 1) * limit
-	end := start + limit
-	if start >= len(userResponses) {
-		userResponses = []UserDetailResponse{}
-	} else if end > len(userResponses) {
-		userResponses = userResponses[start:]
-	} else {
-		userResponses = userResponses[start:end]
+		lastID = user.ID
 	}
 
 	c.JSON(http.StatusOK, UserListResponse{
 		Success: true,
 		Message: "Users retrieved successfully",
 		Data:    userResponses,
-		Meta: struct {
-			Total int `json:"total"`
-			Page  int `json:"page"`
-			Limit int `json:"limit"`
-		}{
-			Total: len(users),
-			Page:  page,
-			Limit: limit,
-		},
+		Meta:    newListMeta(query, result, lastID),
 	})
 }
 
@@ -503,20 +527,26 @@ func (u *UserAPI) GetUser(c *gin.Context) {
 	}
 
 	response := UserDetailResponse{
-		ID:             user.ID,
-		Email:          user.Email,
-		FirstName:      user.FirstName,
-		LastName:       user.LastName,
-		IsActive:       user.IsActiveUser,
-		Salary:         user.Salary,
-		SalaryCurrency: user.SalaryCurrency,
-		PrimaryRoleID:  user.PrimaryRoleID,
-		PrimaryTeamID:  user.PrimaryTeamID,
-		Roles:          roleInfos,
-		Teams:          teamInfos,
-		LastLogin:      user.LastLoginTime,
-		CreatedAt:      user.CreatedAt,
-		UpdatedAt:      user.UpdatedAt,
+		ID:                  user.ID,
+		Email:               user.Email,
+		FirstName:           user.FirstName,
+		LastName:            user.LastName,
+		IsActive:            user.IsActiveUser,
+		Salary:              user.Salary,
+		SalaryCurrency:      user.SalaryCurrency,
+		PrimaryRoleID:       user.PrimaryRoleID,
+		PrimaryTeamID:       user.PrimaryTeamID,
+		Roles:               roleInfos,
+		Teams:               teamInfos,
+		LastLogin:           user.LastLoginTime,
+		TerminationDate:     user.TerminationDate,
+		EmploymentStartDate: user.EmploymentStartDate,
+		EmploymentEndDate:   user.EmploymentEndDate,
+		EmploymentType:      string(user.EmploymentType),
+		ProbationEndDate:    user.ProbationEndDate,
+		Gender:              string(user.Gender),
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, UserDetailResponseWrapper{
@@ -617,6 +647,55 @@ func (u *UserAPI) UpdateUser(c *gin.Context) {
 	if req.PrimaryTeamID != nil {
 		user.PrimaryTeamID = *req.PrimaryTeamID
 	}
+	employmentDatesChanged := false
+	if req.EmploymentStartDate != nil {
+		startDate, err := parseOptionalDate(*req.EmploymentStartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid employment start date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+		user.EmploymentStartDate = startDate
+		employmentDatesChanged = true
+	}
+	if req.EmploymentEndDate != nil {
+		endDate, err := parseOptionalDate(*req.EmploymentEndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid employment end date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+		user.EmploymentEndDate = endDate
+		employmentDatesChanged = true
+	}
+	if req.ProbationEndDate != nil {
+		probationEndDate, err := parseOptionalDate(*req.ProbationEndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid probation end date format. Use YYYY-MM-DD",
+			})
+			return
+		}
+		user.ProbationEndDate = probationEndDate
+	}
+	if req.EmploymentType != nil && *req.EmploymentType != "" {
+		user.EmploymentType = model.EmploymentType(*req.EmploymentType)
+	}
+	if req.Gender != nil {
+		user.Gender = model.Gender(*req.Gender)
+	}
+	if user.EmploymentStartDate != nil && user.EmploymentEndDate != nil && user.EmploymentEndDate.Before(*user.EmploymentStartDate) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Employment end date must be after the start date",
+		})
+		return
+	}
 
 	// Update user
 	if err := u.userModel.UpdateUser(user); err != nil {
@@ -627,6 +706,17 @@ func (u *UserAPI) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Pro-rated allocations depend on the employment dates
+	if employmentDatesChanged {
+		if err := model.NewLeaveBalanceModel(u.db).RecalculateUserLeaveAllocations(user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "User updated but failed to recalculate leave balances",
+			})
+			return
+		}
+	}
+
 	// Update roles if provided
 	if len(req.RoleIDs) > 0 {
 		// Clear existing roles
@@ -667,17 +757,22 @@ func (u *UserAPI) UpdateUser(c *gin.Context) {
 
 	// Convert to response format
 	response := UserDetailResponse{
-		ID:             updatedUser.ID,
-		Email:          updatedUser.Email,
-		FirstName:      updatedUser.FirstName,
-		LastName:       updatedUser.LastName,
-		IsActive:       updatedUser.IsActiveUser,
-		Salary:         updatedUser.Salary,
-		SalaryCurrency: updatedUser.SalaryCurrency,
-		PrimaryRoleID:  updatedUser.PrimaryRoleID,
-		PrimaryTeamID:  updatedUser.PrimaryTeamID,
-		CreatedAt:      updatedUser.CreatedAt,
-		UpdatedAt:      updatedUser.UpdatedAt,
+		ID:                  updatedUser.ID,
+		Email:               updatedUser.Email,
+		FirstName:           updatedUser.FirstName,
+		LastName:            updatedUser.LastName,
+		IsActive:            updatedUser.IsActiveUser,
+		Salary:              updatedUser.Salary,
+		SalaryCurrency:      updatedUser.SalaryCurrency,
+		PrimaryRoleID:       updatedUser.PrimaryRoleID,
+		PrimaryTeamID:       updatedUser.PrimaryTeamID,
+		EmploymentStartDate: updatedUser.EmploymentStartDate,
+		EmploymentEndDate:   updatedUser.EmploymentEndDate,
+		EmploymentType:      string(updatedUser.EmploymentType),
+		ProbationEndDate:    updatedUser.ProbationEndDate,
+		Gender:              string(updatedUser.Gender),
+		CreatedAt:           updatedUser.CreatedAt,
+		UpdatedAt:           updatedUser.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, UserDetailResponseWrapper{
@@ -752,3 +847,15 @@ func (u *UserAPI) DeleteUser(c *gin.Context) {
 		"message": "User deleted successfully",
 	})
 }
+
+// parseOptionalDate parses a YYYY-MM-DD date; an empty string yields nil
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
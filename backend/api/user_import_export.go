@@ -0,0 +1,694 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	maxImportFileSize = 5 << 20 // 5 MB
+	maxImportRows     = 500
+)
+
+// userImportColumns are the recognised columns of an import file; the header row may list them in any order
+var userImportColumns = []string{
+	"email", "password", "first_name", "last_name", "salary", "salary_currency",
+	"primary_role", "primary_team", "roles", "teams", "employment_start_date",
+	"employment_type", "probation_end_date", "gender",
+}
+
+// userExportColumns are the columns written by the export, compatible with the import columns
+var userExportColumns = []string{
+	"id", "email", "first_name", "last_name", "is_active", "salary", "salary_currency",
+	"primary_role", "primary_team", "roles", "teams", "employment_start_date", "employment_end_date",
+	"employment_type", "probation_end_date", "gender", "last_login", "created_at",
+}
+
+//---------- REQUEST RESPONSE TYPES ----------
+
+type UserImportRowResult struct {
+	Row    int      `json:"row"`
+	Email  string   `json:"email"`
+	Valid  bool     `json:"valid"`
+	UserID uint     `json:"user_id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type UserImportResult struct {
+	DryRun      bool                  `json:"dry_run"`
+	TotalRows   int                   `json:"total_rows"`
+	ValidRows   int                   `json:"valid_rows"`
+	InvalidRows int                   `json:"invalid_rows"`
+	Imported    int                   `json:"imported"`
+	Rows        []UserImportRowResult `json:"rows"`
+}
+
+type UserImportResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    UserImportResult `json:"data"`
+}
+
+//---------- HANDLERS ----------
+
+// ImportUsers creates users in bulk from an uploaded CSV or XLSX file.
+// With dry_run=true every row is validated and reported but nothing is written.
+func (u *UserAPI) ImportUsers(c *gin.Context) {
+	// Check permission
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := u.userModel.HasUserPermission(userID.(uint), "CREATE_USERS")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to create users",
+		})
+		return
+	}
+
+	dryRun := false
+	if value := c.DefaultQuery("dry_run", c.PostForm("dry_run")); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Success: false,
+				Message: "Invalid dry_run value",
+				Errors:  []string{"dry_run must be true or false"},
+			})
+			return
+		}
+		dryRun = parsed
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Import file is required",
+			Errors:  []string{"upload the file in the multipart field \"file\""},
+		})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Import file is too large",
+			Errors:  []string{fmt.Sprintf("maximum file size is %d MB", maxImportFileSize>>20)},
+		})
+		return
+	}
+
+	records, err := readImportFile(fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Failed to read import file",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+	if len(records) < 2 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Import file has no data rows",
+		})
+		return
+	}
+	if len(records)-1 > maxImportRows {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Import file has too many rows",
+			Errors:  []string{fmt.Sprintf("maximum is %d rows per import", maxImportRows)},
+		})
+		return
+	}
+
+	columns, errors := parseImportHeader(records[0])
+	if len(errors) > 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid header row",
+			Errors:  errors,
+		})
+		return
+	}
+
+	// Roles and teams are referenced by name in the file
+	roles, err := model.NewRoleModel(u.db).GetAllRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to load roles",
+		})
+		return
+	}
+	teams, err := model.NewTeamModel(u.db).GetAllTeams()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to load teams",
+		})
+		return
+	}
+	rolesByName := make(map[string]model.Role, len(roles))
+	for _, role := range roles {
+		rolesByName[strings.ToUpper(role.Name)] = role
+	}
+	teamsByName := make(map[string]model.Team, len(teams))
+	for _, team := range teams {
+		teamsByName[strings.ToUpper(team.Name)] = team
+	}
+
+	// Emails already in use, looked up in one query
+	var emails []string
+	for _, record := range records[1:] {
+		if email := importCell(record, columns, "email"); email != "" {
+			emails = append(emails, strings.ToLower(email))
+		}
+	}
+	existingEmails, err := u.userModel.GetExistingEmails(emails)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to check existing users",
+		})
+		return
+	}
+
+	result := UserImportResult{DryRun: dryRun}
+	var imports []model.UserImport
+	var importRows []int // index into result.Rows for each entry of imports
+	seenEmails := make(map[string]int)
+
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		rowNumber := i + 2 // 1-based, after the header row
+		row := UserImportRowResult{
+			Row:   rowNumber,
+			Email: importCell(record, columns, "email"),
+		}
+
+		req, rowErrors := u.buildImportRequest(record, columns, rolesByName, teamsByName)
+
+		email := strings.ToLower(req.Email)
+		if email != "" {
+			if existingEmails[email] {
+				rowErrors = append(rowErrors, "a user with this email already exists")
+			} else if firstRow, ok := seenEmails[email]; ok {
+				rowErrors = append(rowErrors, fmt.Sprintf("duplicate email, already used in row %d", firstRow))
+			} else {
+				seenEmails[email] = rowNumber
+			}
+		}
+
+		result.TotalRows++
+		if len(rowErrors) > 0 {
+			row.Errors = rowErrors
+			result.InvalidRows++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		row.Valid = true
+		result.ValidRows++
+		result.Rows = append(result.Rows, row)
+
+		if dryRun {
+			continue
+		}
+
+		hashedPassword, err := model.HashPassword(req.Password)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Success: false,
+				Message: "Failed to hash password",
+			})
+			return
+		}
+
+		userImport := model.UserImport{
+			User: model.User{
+				Email:          req.Email,
+				Password:       hashedPassword,
+				FirstName:      req.FirstName,
+				LastName:       req.LastName,
+				IsActiveUser:   true,
+				Salary:         req.Salary,
+				SalaryCurrency: req.SalaryCurrency,
+				PrimaryRoleID:  req.PrimaryRoleID,
+				PrimaryTeamID:  req.PrimaryTeamID,
+			},
+			RoleIDs: req.RoleIDs,
+			TeamIDs: req.TeamIDs,
+		}
+		// Validated above, so the date parses
+		userImport.User.EmploymentStartDate, _ = parseOptionalDate(req.EmploymentStartDate)
+		userImport.User.ProbationEndDate, _ = parseOptionalDate(req.ProbationEndDate)
+		userImport.User.EmploymentType = model.EmploymentType(req.EmploymentType)
+		userImport.User.Gender = model.Gender(req.Gender)
+		imports = append(imports, userImport)
+		importRows = append(importRows, len(result.Rows)-1)
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, UserImportResponse{
+			Success: true,
+			Message: "Import file validated, no users were created",
+			Data:    result,
+		})
+		return
+	}
+
+	if len(imports) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, UserImportResponse{
+			Success: false,
+			Message: "No valid rows to import",
+			Data:    result,
+		})
+		return
+	}
+
+	// All valid rows are created together so a failure leaves no partial import behind
+	if err := u.userModel.ImportUsers(imports, time.Now().Year()); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to import users",
+			Errors:  []string{err.Error()},
+		})
+		return
+	}
+
+	for i, imported := range imports {
+		result.Rows[importRows[i]].UserID = imported.User.ID
+	}
+	result.Imported = len(imports)
+
+	c.JSON(http.StatusCreated, UserImportResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d users imported successfully", result.Imported),
+		Data:    result,
+	})
+}
+
+// ExportUsers downloads the users matching the list filters as CSV or XLSX
+func (u *UserAPI) ExportUsers(c *gin.Context) {
+	// Check permission
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Success: false,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	hasPermission, err := u.userModel.HasUserPermission(userID.(uint), "EXPORT_DATA")
+	if err != nil || !hasPermission {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Success: false,
+			Message: "Insufficient permissions to export users",
+		})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Success: false,
+			Message: "Invalid export format",
+			Errors:  []string{"format must be csv or xlsx"},
+		})
+		return
+	}
+
+	// Same filters, search and sorting as the user list
+	query, ok := parseListQuery(c, model.UserListSpec)
+	if !ok {
+		return
+	}
+
+	users, err := u.userModel.ExportUsers(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to retrieve users",
+		})
+		return
+	}
+
+	roles, err := model.NewRoleModel(u.db).GetAllRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to load roles",
+		})
+		return
+	}
+	teams, err := model.NewTeamModel(u.db).GetAllTeams()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Success: false,
+			Message: "Failed to load teams",
+		})
+		return
+	}
+	roleNames := make(map[uint]string, len(roles))
+	for _, role := range roles {
+		roleNames[role.ID] = role.Name
+	}
+	teamNames := make(map[uint]string, len(teams))
+	for _, team := range teams {
+		teamNames[team.ID] = team.Name
+	}
+
+	rows := make([][]string, 0, len(users)+1)
+	rows = append(rows, userExportColumns)
+	for _, user := range users {
+		rows = append(rows, exportUserRow(user, roleNames, teamNames))
+	}
+
+	filename := fmt.Sprintf("users-%s.%s", time.Now().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		if err := writeXLSX(c.Writer, "Users", rows); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.WriteAll(rows); err != nil {
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+//---------- HELPERS ----------
+
+// readImportFile returns all rows of the uploaded file, choosing the parser by file extension
+func readImportFile(fileHeader *multipart.FileHeader) ([][]string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		// Spreadsheet programs often prepend a byte order mark
+		if len(records) > 0 && len(records[0]) > 0 {
+			records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+		}
+		return records, nil
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX: %w", err)
+		}
+		defer workbook.Close()
+
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("XLSX file has no sheets")
+		}
+		// Only the first sheet is imported
+		return workbook.GetRows(sheets[0])
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, expected .csv or .xlsx", filepath.Ext(fileHeader.Filename))
+	}
+}
+
+// parseImportHeader maps column names to their index and reports unknown or missing columns
+func parseImportHeader(header []string) (map[string]int, []string) {
+	known := make(map[string]bool, len(userImportColumns))
+	for _, column := range userImportColumns {
+		known[column] = true
+	}
+
+	// Read-only export columns are ignored, so an edited export can be imported once a password column is added;
+	// the export never contains passwords
+	ignored := make(map[string]bool, len(userExportColumns))
+	for _, column := range userExportColumns {
+		ignored[column] = !known[column]
+	}
+
+	columns := make(map[string]int)
+	var errors []string
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || ignored[name] {
+			continue
+		}
+		if !known[name] {
+			errors = append(errors, fmt.Sprintf("unknown column %q", name))
+			continue
+		}
+		if _, ok := columns[name]; ok {
+			errors = append(errors, fmt.Sprintf("duplicate column %q", name))
+			continue
+		}
+		columns[name] = i
+	}
+
+	for _, required := range []string{"email", "password", "first_name", "last_name", "salary", "salary_currency", "primary_role", "primary_team"} {
+		if _, ok := columns[required]; !ok {
+			errors = append(errors, fmt.Sprintf("missing column %q", required))
+		}
+	}
+	return columns, errors
+}
+
+// buildImportRequest turns a row into a create request, resolving role and team names,
+// and validates it the same way as CreateUser
+func (u *UserAPI) buildImportRequest(record []string, columns map[string]int, rolesByName map[string]model.Role, teamsByName map[string]model.Team) (CreateUserRequest, []string) {
+	var errors []string
+	req := CreateUserRequest{
+		Email:          importCell(record, columns, "email"),
+		Password:       importCell(record, columns, "password"),
+		FirstName:      importCell(record, columns, "first_name"),
+		LastName:       importCell(record, columns, "last_name"),
+		SalaryCurrency: strings.ToUpper(importCell(record, columns, "salary_currency")),
+
+		EmploymentStartDate: importCell(record, columns, "employment_start_date"),
+		EmploymentType:      strings.ToUpper(importCell(record, columns, "employment_type")),
+		ProbationEndDate:    importCell(record, columns, "probation_end_date"),
+		Gender:              strings.ToUpper(importCell(record, columns, "gender")),
+	}
+
+	if salary := importCell(record, columns, "salary"); salary != "" {
+		parsed, err := strconv.ParseFloat(salary, 64)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("salary %q is not a number", salary))
+		} else {
+			req.Salary = parsed
+		}
+	}
+
+	resolveRole := func(name string) (uint, bool) {
+		role, ok := rolesByName[strings.ToUpper(name)]
+		if !ok {
+			errors = append(errors, fmt.Sprintf("unknown role %q", name))
+			return 0, false
+		}
+		if !role.IsActive {
+			errors = append(errors, fmt.Sprintf("role %q is inactive", name))
+			return 0, false
+		}
+		return role.ID, true
+	}
+	resolveTeam := func(name string) (uint, bool) {
+		team, ok := teamsByName[strings.ToUpper(name)]
+		if !ok {
+			errors = append(errors, fmt.Sprintf("unknown team %q", name))
+			return 0, false
+		}
+		if !team.IsActive {
+			errors = append(errors, fmt.Sprintf("team %q is inactive", name))
+			return 0, false
+		}
+		return team.ID, true
+	}
+
+	if name := importCell(record, columns, "primary_role"); name != "" {
+		if id, ok := resolveRole(name); ok {
+			req.PrimaryRoleID = id
+			req.RoleIDs = append(req.RoleIDs, id)
+		}
+	}
+	if name := importCell(record, columns, "primary_team"); name != "" {
+		if id, ok := resolveTeam(name); ok {
+			req.PrimaryTeamID = id
+			req.TeamIDs = append(req.TeamIDs, id)
+		}
+	}
+	for _, name := range splitImportList(importCell(record, columns, "roles")) {
+		if id, ok := resolveRole(name); ok && !containsUint(req.RoleIDs, id) {
+			req.RoleIDs = append(req.RoleIDs, id)
+		}
+	}
+	for _, name := range splitImportList(importCell(record, columns, "teams")) {
+		if id, ok := resolveTeam(name); ok && !containsUint(req.TeamIDs, id) {
+			req.TeamIDs = append(req.TeamIDs, id)
+		}
+	}
+
+	if err := u.validate.Struct(req); err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			// Unresolved names are already reported above
+			if (err.Field() == "PrimaryRoleID" && importCell(record, columns, "primary_role") != "") ||
+				(err.Field() == "PrimaryTeamID" && importCell(record, columns, "primary_team") != "") {
+				continue
+			}
+			errors = append(errors, err.Error())
+		}
+	}
+
+	return req, errors
+}
+
+// exportUserRow formats a user in the order of userExportColumns
+func exportUserRow(user model.User, roleNames, teamNames map[uint]string) []string {
+	roles := make([]string, len(user.Roles))
+	for i, role := range user.Roles {
+		roles[i] = role.Name
+	}
+	teams := make([]string, len(user.Teams))
+	for i, team := range user.Teams {
+		teams[i] = team.Name
+	}
+
+	lastLogin := ""
+	if user.LastLoginTime != nil {
+		lastLogin = user.LastLoginTime.Format(time.RFC3339)
+	}
+	formatDate := func(date *time.Time) string {
+		if date == nil {
+			return ""
+		}
+		return date.Format("2006-01-02")
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(user.ID), 10),
+		spreadsheetText(user.Email),
+		spreadsheetText(user.FirstName),
+		spreadsheetText(user.LastName),
+		strconv.FormatBool(user.IsActiveUser),
+		strconv.FormatFloat(user.Salary, 'f', 2, 64),
+		spreadsheetText(user.SalaryCurrency),
+		spreadsheetText(roleNames[user.PrimaryRoleID]),
+		spreadsheetText(teamNames[user.PrimaryTeamID]),
+		spreadsheetText(strings.Join(roles, ";")),
+		spreadsheetText(strings.Join(teams, ";")),
+		formatDate(user.EmploymentStartDate),
+		formatDate(user.EmploymentEndDate),
+		string(user.EmploymentType),
+		formatDate(user.ProbationEndDate),
+		string(user.Gender),
+		lastLogin,
+		user.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// spreadsheetText prefixes user supplied text that a spreadsheet program would run as a formula with a quote,
+// so an exported value such as "=HYPERLINK(...)" is shown as text instead of being evaluated
+func spreadsheetText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// writeXLSX writes the rows to a single sheet workbook
+func writeXLSX(w io.Writer, sheet string, rows [][]string) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	if err := workbook.SetSheetName(workbook.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := workbook.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	return workbook.Write(w)
+}
+
+// importCell returns the trimmed value of a named column, or "" when the row is too short. The quote
+// spreadsheetText adds on export is removed again, so an exported file re-imports unchanged; passwords are
+// never exported and are read as they are
+func importCell(record []string, columns map[string]int, name string) string {
+	index, ok := columns[name]
+	if !ok || index >= len(record) {
+		return ""
+	}
+	value := strings.TrimSpace(record[index])
+	if name != "password" && len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@", rune(value[1])) {
+		value = value[1:]
+	}
+	return value
+}
+
+// splitImportList splits a list cell such as "HR;TEAM_LEAD" on semicolons or commas
+func splitImportList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -34,6 +34,35 @@ func main() {
 		db.Exec("DROP TABLE IF EXISTS leave_balances CASCADE")
 		db.Exec("DROP TABLE IF EXISTS leave_policies CASCADE")
 		db.Exec("DROP TABLE IF EXISTS leave_requests CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_notifications CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_settlements CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_accruals CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_ledger_entries CASCADE")
+		db.Exec("DROP TABLE IF EXISTS scheduled_jobs CASCADE")
+		db.Exec("DROP TABLE IF EXISTS job_runs CASCADE")
+		db.Exec("DROP TABLE IF EXISTS approval_slas CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_approval_events CASCADE")
+		db.Exec("DROP TABLE IF EXISTS approval_delegations CASCADE")
+		db.Exec("DROP TABLE IF EXISTS auto_delegations CASCADE")
+		db.Exec("DROP TABLE IF EXISTS team_coverage_rules CASCADE")
+		db.Exec("DROP TABLE IF EXISTS blackout_periods CASCADE")
+		db.Exec("DROP TABLE IF EXISTS company_holidays CASCADE")
+		db.Exec("DROP TABLE IF EXISTS calendar_feeds CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_attachments CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_type_definitions CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_change_requests CASCADE")
+		db.Exec("DROP TABLE IF EXISTS comp_off_claims CASCADE")
+		db.Exec("DROP TABLE IF EXISTS leave_encashments CASCADE")
+		db.Exec("DROP TABLE IF EXISTS payroll_periods CASCADE")
+		db.Exec("DROP TABLE IF EXISTS payroll_lines CASCADE")
+	}
+
+	// Leave types are seeded before the tables referencing them are migrated
+	if err := db.AutoMigrate(&model.LeaveTypeDefinition{}); err != nil {
+		log.Error().Err(err).Msg("failed to migrate leave types")
+	}
+	if err := model.NewLeaveTypeDefinitionModel(db).SeedDefaultLeaveTypes(); err != nil {
+		log.Error().Err(err).Msg("failed to seed leave types")
 	}
 
 	// Auto-migrate the database schema
@@ -48,9 +77,32 @@ func main() {
 		&model.LeaveBalance{},
 		&model.LeaveCalendarEntry{},
 		&model.LeavePolicy{},
+		&model.LeaveNotification{},
+		&model.LeaveSettlement{},
+		&model.LeaveAccrual{},
+		&model.LeaveLedgerEntry{},
+		&model.ScheduledJob{},
+		&model.JobRun{},
+		&model.ApprovalSLA{},
+		&model.LeaveApprovalEvent{},
+		&model.ApprovalDelegation{},
+		&model.AutoDelegation{},
+		&model.TeamCoverageRule{},
+		&model.BlackoutPeriod{},
+		&model.CompanyHoliday{},
+		&model.CalendarFeed{},
+		&model.LeaveAttachment{},
+		&model.LeaveChangeRequest{},
+		&model.CompOffClaim{},
+		&model.LeaveEncashment{},
+		&model.PayrollPeriod{},
+		&model.PayrollLine{},
 	); err != nil {
 		log.Error().Err(err).Msg("failed to migrate database")
 	}
+	if err := model.NewLeaveRequestModel(db).ApproveHRFinalRequests(); err != nil {
+		log.Error().Err(err).Msg("failed to approve leave requests final at HR approval")
+	}
 
 	// Run migration if flag is set
 	if migration {
@@ -71,6 +123,13 @@ func main() {
 		}
 	}
 
+	// Background jobs; every replica runs the scheduler, each run happens on one of them
+	scheduler := service.NewScheduler(db, log)
+	if err := service.RegisterLeaveJobs(scheduler, db); err != nil {
+		log.Error().Err(err).Msg("failed to register background jobs")
+	}
+	scheduler.Start()
+
 	router := service.InitGinRouter(log)
 	router.GET("/health", func(c *service.GinContext) {
 		c.JSON(200, map[string]string{"status": "ok"})
@@ -97,6 +156,59 @@ func main() {
 	teamsAPI := api.NewTeamAPI(db)
 	teamsAPI.SetupRoutes(apiGroup)
 
+	// Initialize Jobs API
+	jobAPI := api.NewJobAPI(db, scheduler)
+	jobAPI.SetupRoutes(apiGroup)
+
+	// Initialize Approval SLA API
+	approvalSLAAPI := api.NewApprovalSLAAPI(db)
+	approvalSLAAPI.SetupRoutes(apiGroup)
+
+	// Initialize Approval Delegation API
+	approvalDelegationAPI := api.NewApprovalDelegationAPI(db)
+	approvalDelegationAPI.SetupRoutes(apiGroup)
+
+	// Initialize Blackout Period API
+	blackoutPeriodAPI := api.NewBlackoutPeriodAPI(db)
+	blackoutPeriodAPI.SetupRoutes(apiGroup)
+
+	// Initialize Leave Type API
+	leaveTypeAPI := api.NewLeaveTypeAPI(db)
+	leaveTypeAPI.SetupRoutes(apiGroup)
+
+	// Initialize Company Holiday API
+	companyHolidayAPI := api.NewCompanyHolidayAPI(db)
+	companyHolidayAPI.SetupRoutes(apiGroup)
+
+	// Initialize Calendar Feed API
+	calendarFeedAPI := api.NewCalendarFeedAPI(db)
+	calendarFeedAPI.SetupRoutes(apiGroup)
+
+	// Initialize Leave Change Request API
+	leaveChangeRequestAPI := api.NewLeaveChangeRequestAPI(db)
+	leaveChangeRequestAPI.SetupRoutes(apiGroup)
+
+	// Initialize Comp-off API
+	compOffAPI := api.NewCompOffAPI(db)
+	compOffAPI.SetupRoutes(apiGroup)
+
+	// Initialize Payroll API
+	payrollAPI := api.NewPayrollAPI(db)
+	payrollAPI.SetupRoutes(apiGroup)
+
+	// Initialize Approval Inbox API
+	approvalInboxAPI := api.NewApprovalInboxAPI(db)
+	approvalInboxAPI.SetupRoutes(apiGroup)
+
+	// Initialize Leave Attachment API
+	fileStorage, err := service.NewFileStorageFromEnv()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to initialize file storage, leave attachments are disabled")
+	} else {
+		leaveAttachmentAPI := api.NewLeaveAttachmentAPI(db, fileStorage)
+		leaveAttachmentAPI.SetupRoutes(apiGroup)
+	}
+
 	// Initialize Leave Request API
 	leaveRequestHandler := api.NewLeaveRequestHandler(db)
 	leaveRequestGroup := apiGroup.Group("/leave-requests")
@@ -104,24 +216,32 @@ func main() {
 	{
 		// Leave request management
 		leaveRequestGroup.POST("", leaveRequestHandler.CreateLeaveRequest)
+		leaveRequestGroup.POST("/on-behalf", leaveRequestHandler.CreateLeaveRequestOnBehalf)
 		leaveRequestGroup.GET("", leaveRequestHandler.GetLeaveRequests)
 		leaveRequestGroup.GET("/:id", leaveRequestHandler.GetLeaveRequest)
 		leaveRequestGroup.PUT("/:id", leaveRequestHandler.UpdateLeaveRequest)
 		leaveRequestGroup.DELETE("/:id", leaveRequestHandler.CancelLeaveRequest)
+		leaveRequestGroup.POST("/:id/return-early", leaveRequestHandler.ReturnEarly)
 
 		// Leave balance and statistics
 		leaveRequestGroup.GET("/balance", leaveRequestHandler.GetLeaveBalance)
+		leaveRequestGroup.GET("/accruals", leaveRequestHandler.GetLeaveAccruals)
+		leaveRequestGroup.GET("/statement", leaveRequestHandler.GetLeaveStatement)
 		leaveRequestGroup.GET("/stats", leaveRequestHandler.GetLeaveStats)
 		leaveRequestGroup.GET("/calendar/:year", leaveRequestHandler.GetLeaveCalendar)
+		leaveRequestGroup.GET("/available-dates", leaveRequestHandler.GetAvailableDates)
+		leaveRequestGroup.GET("/eligibility", leaveRequestHandler.GetLeaveEligibility)
 
 		// Approval workflow
 		leaveRequestGroup.GET("/pending", leaveRequestHandler.GetPendingApprovals)
 		leaveRequestGroup.POST("/:id/approve", leaveRequestHandler.ApproveLeaveRequest)
 		leaveRequestGroup.POST("/:id/reject", leaveRequestHandler.RejectLeaveRequest)
+		leaveRequestGroup.POST("/bulk-decision", leaveRequestHandler.BulkDecideLeaveRequests)
 
 		// Workflow status and timeline
 		leaveRequestGroup.GET("/:id/workflow", leaveRequestHandler.GetLeaveRequestWorkflowStatus)
 		leaveRequestGroup.GET("/:id/timeline", leaveRequestHandler.GetLeaveRequestTimeline)
+		leaveRequestGroup.GET("/:id/coverage", leaveRequestHandler.GetLeaveRequestCoverage)
 
 		// Reporting
 		leaveRequestGroup.GET("/summary", leaveRequestHandler.GetLeaveRequestSummary)
@@ -145,11 +265,27 @@ func main() {
 		// Bulk update leave balances for a user
 		adminLeaveBalanceGroup.PUT("/user/:user_id/bulk", leaveBalanceAdminHandler.BulkUpdateUserLeaveBalances)
 
+		// Accrual history of a user
+		adminLeaveBalanceGroup.GET("/user/:user_id/accruals", leaveBalanceAdminHandler.GetUserLeaveAccruals)
+
+		// Ledger statement of a user
+		adminLeaveBalanceGroup.GET("/user/:user_id/statement", leaveBalanceAdminHandler.GetUserLeaveStatement)
+
+		// Balances whose counters drifted from the ledger
+		adminLeaveBalanceGroup.GET("/consistency", leaveBalanceAdminHandler.CheckLedgerConsistency)
+		adminLeaveBalanceGroup.POST("/consistency/reconcile", leaveBalanceAdminHandler.ReconcileLeaveBalances)
+
+		// Post accrual periods that have ended
+		adminLeaveBalanceGroup.POST("/accruals/run", leaveBalanceAdminHandler.RunLeaveAccruals)
+
 		// Reset user's leave balances for new year
 		adminLeaveBalanceGroup.POST("/user/:user_id/reset", leaveBalanceAdminHandler.ResetUserLeaveBalances)
 
 		// Get leave balance statistics
 		adminLeaveBalanceGroup.GET("/stats", leaveBalanceAdminHandler.GetLeaveBalanceStats)
+
+		// Users who took leave in advance and are in deficit
+		adminLeaveBalanceGroup.GET("/deficits", leaveBalanceAdminHandler.GetLeaveBalanceDeficits)
 	}
 
 	// Protected routes example
@@ -0,0 +1,244 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSelfDelegation      = errors.New("approvers cannot delegate to themselves")
+	ErrInvalidDelegateDate = errors.New("delegation end date must not be before its start date")
+	ErrInactiveDelegate    = errors.New("delegate must be an active user")
+)
+
+// LeaveTypeList represents a slice of leave types for JSON storage
+type LeaveTypeList []LeaveType
+
+// Value implements the driver.Valuer interface
+func (l LeaveTypeList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface
+func (l *LeaveTypeList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return gorm.ErrInvalidData
+	}
+
+	return json.Unmarshal(bytes, l)
+}
+
+// Contains reports whether the list allows a leave type; an empty list allows every type
+func (l LeaveTypeList) Contains(leaveType LeaveType) bool {
+	if len(l) == 0 {
+		return true
+	}
+	for _, t := range l {
+		if t == leaveType {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalDelegation lets another user decide requests on the delegator's behalf between two dates
+type ApprovalDelegation struct {
+	ID                   uint          `gorm:"primaryKey" json:"id"`
+	DelegatorID          uint          `gorm:"not null;index" json:"delegator_id"`
+	DelegateID           uint          `gorm:"not null;index" json:"delegate_id"`
+	StartDate            time.Time     `gorm:"not null" json:"start_date"`
+	EndDate              time.Time     `gorm:"not null" json:"end_date"`                // Inclusive
+	LeaveTypes           LeaveTypeList `gorm:"type:jsonb" json:"leave_types,omitempty"` // Empty for every leave type
+	Reason               string        `gorm:"type:text" json:"reason,omitempty"`
+	SourceLeaveRequestID *uint         `gorm:"index" json:"source_leave_request_id,omitempty"` // Delegator's own leave that activated it
+	IsActive             bool          `gorm:"default:true" json:"is_active"`                  // False once revoked
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at"`
+
+	// Relationships
+	Delegator User `gorm:"foreignKey:DelegatorID" json:"delegator,omitempty"`
+	Delegate  User `gorm:"foreignKey:DelegateID" json:"delegate,omitempty"`
+}
+
+// AutoDelegation is an approver's standing delegate, activated whenever their own leave is approved
+type AutoDelegation struct {
+	UserID     uint          `gorm:"primaryKey" json:"user_id"`
+	DelegateID uint          `gorm:"not null" json:"delegate_id"`
+	LeaveTypes LeaveTypeList `gorm:"type:jsonb" json:"leave_types,omitempty"`
+	IsEnabled  bool          `gorm:"default:true" json:"is_enabled"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// ApprovalDelegationModel handles approval delegation database operations
+type ApprovalDelegationModel struct {
+	db *gorm.DB
+}
+
+func NewApprovalDelegationModel(db *gorm.DB) *ApprovalDelegationModel {
+	return &ApprovalDelegationModel{
+		db: db,
+	}
+}
+
+// CreateDelegation validates and stores a delegation
+func (a *ApprovalDelegationModel) CreateDelegation(delegation *ApprovalDelegation) error {
+	if delegation.DelegateID == delegation.DelegatorID {
+		return ErrSelfDelegation
+	}
+	if delegation.EndDate.Before(delegation.StartDate) {
+		return ErrInvalidDelegateDate
+	}
+	if err := a.requireActiveDelegate(delegation.DelegateID); err != nil {
+		return err
+	}
+	delegation.IsActive = true
+	return a.db.Create(delegation).Error
+}
+
+// GetDelegation retrieves a delegation by ID
+func (a *ApprovalDelegationModel) GetDelegation(id uint) (*ApprovalDelegation, error) {
+	var delegation ApprovalDelegation
+	if err := a.db.Preload("Delegator").Preload("Delegate").First(&delegation, id).Error; err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// GetDelegationsByDelegator lists the delegations a user has given, newest first
+func (a *ApprovalDelegationModel) GetDelegationsByDelegator(delegatorID uint) ([]ApprovalDelegation, error) {
+	var delegations []ApprovalDelegation
+	if err := a.db.Where("delegator_id = ?", delegatorID).
+		Preload("Delegate").
+		Order("start_date DESC").
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// GetDelegationsToDelegate lists the delegations a user has received, newest first
+func (a *ApprovalDelegationModel) GetDelegationsToDelegate(delegateID uint) ([]ApprovalDelegation, error) {
+	var delegations []ApprovalDelegation
+	if err := a.db.Where("delegate_id = ?", delegateID).
+		Preload("Delegator").
+		Order("start_date DESC").
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// GetActiveDelegationsTo returns the delegations a user can act on today
+func (a *ApprovalDelegationModel) GetActiveDelegationsTo(delegateID uint, on time.Time) ([]ApprovalDelegation, error) {
+	day := truncateToDate(on)
+	var delegations []ApprovalDelegation
+	if err := a.db.Where("delegate_id = ? AND is_active = ? AND start_date < ? AND end_date >= ?",
+		delegateID, true, day.AddDate(0, 0, 1), day).
+		Order("start_date ASC").
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// RevokeDelegation deactivates a delegation given by the delegator
+func (a *ApprovalDelegationModel) RevokeDelegation(id uint, delegatorID uint) error {
+	result := a.db.Model(&ApprovalDelegation{}).
+		Where("id = ? AND delegator_id = ?", id, delegatorID).
+		Update("is_active", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetAutoDelegation returns a user's standing delegate setting
+func (a *ApprovalDelegationModel) GetAutoDelegation(userID uint) (*AutoDelegation, error) {
+	var setting AutoDelegation
+	if err := a.db.First(&setting, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// SaveAutoDelegation creates or replaces a user's standing delegate setting
+func (a *ApprovalDelegationModel) SaveAutoDelegation(setting *AutoDelegation) error {
+	if setting.DelegateID == setting.UserID {
+		return ErrSelfDelegation
+	}
+	if err := a.requireActiveDelegate(setting.DelegateID); err != nil {
+		return err
+	}
+	return a.db.Save(setting).Error
+}
+
+// ActivateAutoDelegation creates a delegation covering an approved leave request when its requester
+// has an enabled standing delegate. It returns nil when there is nothing to activate.
+func (a *ApprovalDelegationModel) ActivateAutoDelegation(request *LeaveRequest) (*ApprovalDelegation, error) {
+	setting, err := a.GetAutoDelegation(request.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !setting.IsEnabled {
+		return nil, nil
+	}
+
+	var count int64
+	if err := a.db.Model(&ApprovalDelegation{}).
+		Where("source_leave_request_id = ?", request.ID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, nil
+	}
+
+	delegation := &ApprovalDelegation{
+		DelegatorID:          request.UserID,
+		DelegateID:           setting.DelegateID,
+		StartDate:            request.StartDate,
+		EndDate:              request.EndDate,
+		LeaveTypes:           setting.LeaveTypes,
+		Reason:               "Activated by approved leave",
+		SourceLeaveRequestID: &request.ID,
+	}
+	if err := a.CreateDelegation(delegation); err != nil {
+		if errors.Is(err, ErrInactiveDelegate) {
+			return nil, nil // The standing delegate has left; the approver has to pick another one
+		}
+		return nil, err
+	}
+	return delegation, nil
+}
+
+// requireActiveDelegate checks that a delegate exists and can log in
+func (a *ApprovalDelegationModel) requireActiveDelegate(delegateID uint) error {
+	var delegate User
+	if err := a.db.Select("id", "is_active_user").First(&delegate, delegateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInactiveDelegate
+		}
+		return err
+	}
+	if !delegate.IsActiveUser {
+		return ErrInactiveDelegate
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalItemKind is the kind of request an approver decides
+type ApprovalItemKind string
+
+const (
+	ApprovalItemLeaveRequest ApprovalItemKind = "LEAVE_REQUEST"
+	ApprovalItemLeaveChange  ApprovalItemKind = "LEAVE_CHANGE"
+	ApprovalItemCompOffClaim ApprovalItemKind = "COMP_OFF_CLAIM"
+	ApprovalItemEncashment   ApprovalItemKind = "ENCASHMENT"
+)
+
+const (
+	// inboxUrgentDays is how close to its start waiting leave becomes urgent
+	inboxUrgentDays = 3
+	// recentDecisionDays is how far back the inbox lists the caller's own decisions
+	recentDecisionDays = 30
+	// recentDecisionLimit caps the decisions listed
+	recentDecisionLimit = 50
+)
+
+// ApprovalInboxItem is a request the caller can decide now
+type ApprovalInboxItem struct {
+	Kind           ApprovalItemKind `json:"kind"`
+	ID             uint             `json:"id"`                         // ID of the leave request, change request, claim or encashment
+	LeaveRequestID uint             `json:"leave_request_id,omitempty"` // Leave a change request belongs to
+	User           *User            `json:"user,omitempty"`
+	LeaveType      LeaveType        `json:"leave_type"`
+	StartDate      *time.Time       `json:"start_date,omitempty"`
+	EndDate        *time.Time       `json:"end_date,omitempty"`
+	Days           int              `json:"days"`
+	Stage          ApprovalStage    `json:"stage,omitempty"`           // Empty for encashments, which payroll approvers decide
+	OnBehalfOfID   *uint            `json:"on_behalf_of_id,omitempty"` // Approver the caller stands in for through a delegation
+	Escalated      bool             `json:"escalated"`
+	WaitingSince   time.Time        `json:"waiting_since"`
+	AgeHours       int              `json:"age_hours"` // Hours the item has waited on its current stage
+	SLAHours       int              `json:"sla_hours"` // Hours after which the stage is escalated or reminded, 0 for none
+	Overdue        bool             `json:"overdue"`
+	Urgent         bool             `json:"urgent"` // Overdue, escalated or starting within a few days
+}
+
+// ApprovalDecision is a decision the caller took recently
+type ApprovalDecision struct {
+	Kind         ApprovalItemKind `json:"kind"`
+	ID           uint             `json:"id"` // ID of the leave request for leave and change decisions, else of the claim or encashment
+	User         *User            `json:"user,omitempty"`
+	LeaveType    LeaveType        `json:"leave_type"`
+	Stage        ApprovalStage    `json:"stage,omitempty"`
+	Approved     bool             `json:"approved"`
+	OnBehalfOfID *uint            `json:"on_behalf_of_id,omitempty"`
+	Comments     string           `json:"comments,omitempty"`
+	DecidedAt    time.Time        `json:"decided_at"`
+}
+
+// ApprovalInbox is everything waiting for an approver's decision and what they decided lately
+type ApprovalInbox struct {
+	Items           []ApprovalInboxItem `json:"items"`
+	RecentlyDecided []ApprovalDecision  `json:"recently_decided"`
+}
+
+// ApprovalInboxModel gathers the requests of every kind an approver can act on
+type ApprovalInboxModel struct {
+	db *gorm.DB
+}
+
+func NewApprovalInboxModel(db *gorm.DB) *ApprovalInboxModel {
+	return &ApprovalInboxModel{
+		db: db,
+	}
+}
+
+// GetInbox returns the requests a user can decide now, in their own right, as a delegate or because a request was
+// escalated to them, with urgent items first and then by start date. Their decisions of the last
+// recentDecisionDays days come along, newest first.
+func (m *ApprovalInboxModel) GetInbox(userID uint, now time.Time) (*ApprovalInbox, error) {
+	slas, err := NewApprovalSLAModel(m.db).GetApprovalSLAs()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []ApprovalInboxItem{}
+	for _, collect := range []func(uint, map[ApprovalStage]ApprovalSLA) ([]ApprovalInboxItem, error){
+		m.leaveRequestItems, m.leaveChangeItems, m.compOffItems, m.encashmentItems,
+	} {
+		found, err := collect(userID, slas)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+
+	today := truncateToDate(now)
+	for i := range items {
+		item := &items[i]
+		item.AgeHours = int(now.Sub(item.WaitingSince).Hours())
+		item.Overdue = item.SLAHours > 0 && item.AgeHours >= item.SLAHours
+		startsSoon := item.StartDate != nil && item.StartDate.Before(today.AddDate(0, 0, inboxUrgentDays+1))
+		item.Urgent = item.Overdue || item.Escalated || startsSoon
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		if items[a].Urgent != items[b].Urgent {
+			return items[a].Urgent
+		}
+		startA, startB := items[a].StartDate, items[b].StartDate
+		if (startA == nil) != (startB == nil) {
+			return startA != nil
+		}
+		if startA != nil && !startA.Equal(*startB) {
+			return startA.Before(*startB)
+		}
+		return items[a].WaitingSince.Before(items[b].WaitingSince)
+	})
+
+	decisions, err := m.recentDecisions(userID, now.AddDate(0, 0, -recentDecisionDays))
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalInbox{Items: items, RecentlyDecided: decisions}, nil
+}
+
+// leaveRequestItems lists the leave requests waiting on a stage the user can decide
+func (m *ApprovalInboxModel) leaveRequestItems(userID uint, slas map[ApprovalStage]ApprovalSLA) ([]ApprovalInboxItem, error) {
+	leaveRequestModel := NewLeaveRequestModel(m.db)
+	var requests []LeaveRequest
+	if err := m.db.Where("status IN ?", []LeaveRequestStatus{StatusPending, StatusTeamLeadApproved, StatusHRApproved}).
+		Preload("User").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	items := []ApprovalInboxItem{}
+	for i := range requests {
+		request := &requests[i]
+		stage := request.PendingStage()
+		if stage == "" {
+			continue
+		}
+		actorID, err := leaveRequestModel.deciderFor(request, userID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID == 0 {
+			continue
+		}
+
+		items = append(items, ApprovalInboxItem{
+			Kind:         ApprovalItemLeaveRequest,
+			ID:           request.ID,
+			User:         &request.User,
+			LeaveType:    request.LeaveType,
+			StartDate:    &request.StartDate,
+			EndDate:      &request.EndDate,
+			Days:         request.DaysRequested,
+			Stage:        stage,
+			OnBehalfOfID: onBehalfOf(actorID, userID),
+			Escalated:    request.EscalatedAt != nil && !request.EscalatedAt.Before(request.StageStartedAt()),
+			WaitingSince: request.StageStartedAt(),
+			SLAHours:     slaHours(slas[stage]),
+		})
+	}
+	return items, nil
+}
+
+// leaveChangeItems lists the pending changes of approved leave on a stage the user can decide
+func (m *ApprovalInboxModel) leaveChangeItems(userID uint, slas map[ApprovalStage]ApprovalSLA) ([]ApprovalInboxItem, error) {
+	changeModel := NewLeaveChangeRequestModel(m.db)
+	var changes []LeaveChangeRequest
+	if err := m.db.Where("status = ?", ChangeStatusPending).Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	leaveRequestModel := NewLeaveRequestModel(m.db)
+	items := []ApprovalInboxItem{}
+	for i := range changes {
+		change := &changes[i]
+		request, err := leaveRequestModel.GetLeaveRequest(change.LeaveRequestID)
+		if err != nil {
+			continue
+		}
+		actorID, err := changeModel.deciderFor(change, request, userID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID == 0 {
+			continue
+		}
+
+		items = append(items, ApprovalInboxItem{
+			Kind:           ApprovalItemLeaveChange,
+			ID:             change.ID,
+			LeaveRequestID: change.LeaveRequestID,
+			User:           &request.User,
+			LeaveType:      change.LeaveType,
+			StartDate:      &change.StartDate,
+			EndDate:        &change.EndDate,
+			Days:           change.DaysRequested,
+			Stage:          change.CurrentStage,
+			OnBehalfOfID:   onBehalfOf(actorID, userID),
+			WaitingSince:   change.CreatedAt,
+			SLAHours:       slaHours(slas[change.CurrentStage]),
+		})
+	}
+	return items, nil
+}
+
+// compOffItems lists the pending comp-off claims the user can decide as team lead or delegate
+func (m *ApprovalInboxModel) compOffItems(userID uint, slas map[ApprovalStage]ApprovalSLA) ([]ApprovalInboxItem, error) {
+	compOffModel := NewCompOffClaimModel(m.db)
+	var claims []CompOffClaim
+	if err := m.db.Where("status = ? AND user_id <> ?", CompOffStatusPending, userID).
+		Preload("User").
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+
+	items := []ApprovalInboxItem{}
+	for i := range claims {
+		claim := &claims[i]
+		actorID, err := compOffModel.deciderFor(claim, userID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID == 0 {
+			continue
+		}
+
+		items = append(items, ApprovalInboxItem{
+			Kind:         ApprovalItemCompOffClaim,
+			ID:           claim.ID,
+			User:         &claim.User,
+			LeaveType:    LeaveTypeCompOff,
+			Days:         claim.Days,
+			Stage:        StageTeamLead,
+			OnBehalfOfID: onBehalfOf(actorID, userID),
+			WaitingSince: claim.CreatedAt,
+			SLAHours:     slaHours(slas[StageTeamLead]),
+		})
+	}
+	return items, nil
+}
+
+// encashmentItems lists the pending encashments of others when the user approves payroll
+func (m *ApprovalInboxModel) encashmentItems(userID uint, _ map[ApprovalStage]ApprovalSLA) ([]ApprovalInboxItem, error) {
+	canApprove, err := NewUserModel(m.db).HasUserPermission(userID, "MANAGE_PAYROLL")
+	if err != nil || !canApprove {
+		return []ApprovalInboxItem{}, err
+	}
+	encashments, err := NewLeaveEncashmentModel(m.db).GetPendingEncashments()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []ApprovalInboxItem{}
+	for i := range encashments {
+		encashment := &encashments[i]
+		if encashment.UserID == userID {
+			continue
+		}
+		items = append(items, ApprovalInboxItem{
+			Kind:         ApprovalItemEncashment,
+			ID:           encashment.ID,
+			User:         &encashment.User,
+			LeaveType:    encashment.LeaveType,
+			Days:         encashment.Days,
+			WaitingSince: encashment.CreatedAt,
+		})
+	}
+	return items, nil
+}
+
+// recentDecisions lists the leave, change, comp-off and encashment decisions a user took since a time
+func (m *ApprovalInboxModel) recentDecisions(userID uint, since time.Time) ([]ApprovalDecision, error) {
+	// Changes applied without approval are recorded as decided by the requester, so the user's own leave is left out
+	var events []LeaveApprovalEvent
+	if err := m.db.Model(&LeaveApprovalEvent{}).
+		Joins("JOIN leave_requests ON leave_requests.id = leave_approval_events.leave_request_id").
+		Where("leave_approval_events.to_approver_id = ? AND leave_approval_events.created_at >= ?", userID, since).
+		Where("leave_approval_events.event_type IN ?", []ApprovalEventType{
+			ApprovalEventApproved, ApprovalEventRejected, ApprovalEventChangeApproved, ApprovalEventChangeRejected,
+		}).
+		Where("leave_requests.user_id <> ?", userID).
+		Order("leave_approval_events.created_at DESC").
+		Limit(recentDecisionLimit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	requestIDs := make([]uint, 0, len(events))
+	for _, event := range events {
+		requestIDs = append(requestIDs, event.LeaveRequestID)
+	}
+	var requests []LeaveRequest
+	if err := m.db.Where("id IN ?", requestIDs).Preload("User").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	requestsByID := make(map[uint]*LeaveRequest, len(requests))
+	for i := range requests {
+		requestsByID[requests[i].ID] = &requests[i]
+	}
+
+	decisions := []ApprovalDecision{}
+	for _, event := range events {
+		decision := ApprovalDecision{
+			Kind:      ApprovalItemLeaveRequest,
+			ID:        event.LeaveRequestID,
+			Stage:     event.Stage,
+			Approved:  event.EventType == ApprovalEventApproved || event.EventType == ApprovalEventChangeApproved,
+			Comments:  event.Note,
+			DecidedAt: event.CreatedAt,
+		}
+		if event.EventType.IsChangeEvent() {
+			decision.Kind = ApprovalItemLeaveChange
+		}
+		if event.FromApproverID != nil {
+			decision.OnBehalfOfID = onBehalfOf(*event.FromApproverID, userID)
+		}
+		if request, ok := requestsByID[event.LeaveRequestID]; ok {
+			decision.User = &request.User
+			decision.LeaveType = request.LeaveType
+		}
+		decisions = append(decisions, decision)
+	}
+
+	var claims []CompOffClaim
+	if err := m.db.Where("decided_by_id = ? AND decided_at >= ? AND status IN ?", userID, since,
+		[]CompOffClaimStatus{CompOffStatusApproved, CompOffStatusRejected}).
+		Preload("User").
+		Order("decided_at DESC").
+		Limit(recentDecisionLimit).
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	for i := range claims {
+		decisions = append(decisions, ApprovalDecision{
+			Kind:      ApprovalItemCompOffClaim,
+			ID:        claims[i].ID,
+			User:      &claims[i].User,
+			LeaveType: LeaveTypeCompOff,
+			Stage:     StageTeamLead,
+			Approved:  claims[i].Status == CompOffStatusApproved,
+			Comments:  claims[i].Comments,
+			DecidedAt: *claims[i].DecidedAt,
+		})
+	}
+
+	var encashments []LeaveEncashment
+	if err := m.db.Where("decided_by_id = ? AND decided_at >= ? AND status IN ?", userID, since,
+		[]EncashmentStatus{EncashmentStatusApproved, EncashmentStatusRejected}).
+		Preload("User").
+		Order("decided_at DESC").
+		Limit(recentDecisionLimit).
+		Find(&encashments).Error; err != nil {
+		return nil, err
+	}
+	for i := range encashments {
+		decisions = append(decisions, ApprovalDecision{
+			Kind:      ApprovalItemEncashment,
+			ID:        encashments[i].ID,
+			User:      &encashments[i].User,
+			LeaveType: encashments[i].LeaveType,
+			Approved:  encashments[i].Status == EncashmentStatusApproved,
+			Comments:  encashments[i].Comments,
+			DecidedAt: *encashments[i].DecidedAt,
+		})
+	}
+
+	sort.SliceStable(decisions, func(a, b int) bool {
+		return decisions[a].DecidedAt.After(decisions[b].DecidedAt)
+	})
+	if len(decisions) > recentDecisionLimit {
+		decisions = decisions[:recentDecisionLimit]
+	}
+	return decisions, nil
+}
+
+// onBehalfOf returns the approver a user decides for, or nil when they decide in their own right
+func onBehalfOf(actorID, userID uint) *uint {
+	if actorID == userID {
+		return nil
+	}
+	return &actorID
+}
+
+// slaHours returns the hours a stage may wait before it is escalated, or reminded when it is never escalated
+func slaHours(sla ApprovalSLA) int {
+	if sla.EscalateAfterHours > 0 {
+		return sla.EscalateAfterHours
+	}
+	return sla.ReminderAfterHours
+}
@@ -0,0 +1,535 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApprovalStage is a step of the leave approval workflow
+type ApprovalStage string
+
+const (
+	StageTeamLead   ApprovalStage = "TEAM_LEAD"
+	StageHR         ApprovalStage = "HR"
+	StageManagement ApprovalStage = "MANAGEMENT"
+)
+
+// ApprovalStages lists the stages in workflow order
+var ApprovalStages = []ApprovalStage{StageTeamLead, StageHR, StageManagement}
+
+// IsValid reports whether the stage is one of the workflow stages
+func (s ApprovalStage) IsValid() bool {
+	for _, stage := range ApprovalStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// managementApprovalDays is the request length above which management has to approve after HR
+const managementApprovalDays = 4
+
+// PendingStage returns the approval stage a request waits on, or "" when it is decided
+func (r *LeaveRequest) PendingStage() ApprovalStage {
+	switch r.Status {
+	case StatusPending:
+		return StageTeamLead
+	case StatusTeamLeadApproved:
+		return StageHR
+	case StatusHRApproved:
+		return StageManagement
+	}
+	return ""
+}
+
+// workflowStage returns the approval stage decided from a workflow status
+func workflowStage(status LeaveRequestStatus) ApprovalStage {
+	switch status {
+	case StatusTeamLeadApproved:
+		return StageHR
+	case StatusHRApproved:
+		return StageManagement
+	default:
+		return StageTeamLead
+	}
+}
+
+// StageStartedAt returns when the request reached its current approval stage
+func (r *LeaveRequest) StageStartedAt() time.Time {
+	switch r.Status {
+	case StatusTeamLeadApproved:
+		if r.TeamLeadApprovedAt != nil {
+			return *r.TeamLeadApprovedAt
+		}
+	case StatusHRApproved:
+		if r.HRApprovedAt != nil {
+			return *r.HRApprovedAt
+		}
+	}
+	return r.CreatedAt
+}
+
+// UndecidedAction is what happens to a request that is still undecided when the leave starts
+type UndecidedAction string
+
+const (
+	UndecidedNone        UndecidedAction = "NONE" // Leave it for the approvers
+	UndecidedAutoApprove UndecidedAction = "AUTO_APPROVE"
+	UndecidedAutoReject  UndecidedAction = "AUTO_REJECT"
+)
+
+// ApprovalSLA configures reminders and escalation for one approval stage
+type ApprovalSLA struct {
+	ID                    uint          `gorm:"primaryKey" json:"id"`
+	Stage                 ApprovalStage `gorm:"uniqueIndex;not null" json:"stage"`
+	ReminderAfterHours    int           `gorm:"not null;default:24" json:"reminder_after_hours"`    // First reminder to the approver, 0 for no reminders
+	ReminderIntervalHours int           `gorm:"not null;default:24" json:"reminder_interval_hours"` // Hours between repeated reminders
+	EscalateAfterHours    int           `gorm:"not null;default:0" json:"escalate_after_hours"`     // Hand over to the fallback approver, 0 to never escalate
+	CreatedAt             time.Time     `json:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
+}
+
+// defaultApprovalSLAs apply to stages that have not been configured
+var defaultApprovalSLAs = map[ApprovalStage]ApprovalSLA{
+	StageTeamLead:   {Stage: StageTeamLead, ReminderAfterHours: 24, ReminderIntervalHours: 24, EscalateAfterHours: 48},
+	StageHR:         {Stage: StageHR, ReminderAfterHours: 24, ReminderIntervalHours: 24, EscalateAfterHours: 72},
+	StageManagement: {Stage: StageManagement, ReminderAfterHours: 48, ReminderIntervalHours: 24, EscalateAfterHours: 0},
+}
+
+// ApprovalEventType represents an automatic action taken on a waiting request
+type ApprovalEventType string
+
+const (
+	ApprovalEventReminder     ApprovalEventType = "REMINDER"
+	ApprovalEventEscalated    ApprovalEventType = "ESCALATED"
+	ApprovalEventAutoApproved ApprovalEventType = "AUTO_APPROVED"
+	ApprovalEventAutoRejected ApprovalEventType = "AUTO_REJECTED"
+	ApprovalEventOnBehalf     ApprovalEventType = "ON_BEHALF" // Decided by ToApproverID as delegate of FromApproverID, before decisions had their own events
+	ApprovalEventApproved     ApprovalEventType = "APPROVED"  // Stage approved by ToApproverID, in their own right or for FromApproverID
+	ApprovalEventRejected     ApprovalEventType = "REJECTED"  // Stage rejected by ToApproverID, in their own right or for FromApproverID
+)
+
+// IsDecision reports whether the event records an approver's decision on a stage of the request
+func (t ApprovalEventType) IsDecision() bool {
+	return t == ApprovalEventApproved || t == ApprovalEventRejected
+}
+
+// IsUserAction reports whether ToApproverID is the user who acted rather than an approver who was notified
+func (t ApprovalEventType) IsUserAction() bool {
+	switch t {
+	case ApprovalEventOnBehalf, ApprovalEventReturnedEarly, ApprovalEventSubmittedOnBehalf, ApprovalEventPreApproved:
+		return true
+	}
+	if t.IsDecision() {
+		return true
+	}
+	return t.IsChangeEvent()
+}
+
+// LeaveApprovalEvent records a reminder, escalation or automatic decision for the request timeline
+type LeaveApprovalEvent struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	LeaveRequestID uint              `gorm:"not null;index" json:"leave_request_id"`
+	Stage          ApprovalStage     `gorm:"not null" json:"stage"`
+	EventType      ApprovalEventType `gorm:"not null" json:"event_type"`
+	FromApproverID *uint             `json:"from_approver_id,omitempty"`
+	ToApproverID   *uint             `json:"to_approver_id,omitempty"`
+	ToGroup        ApprovalStage     `json:"to_group,omitempty"` // Everyone who approves this stage
+	Note           string            `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// ApprovalSLAResult counts what one SLA run did
+type ApprovalSLAResult struct {
+	Reminders    int `json:"reminders"`
+	Escalations  int `json:"escalations"`
+	AutoApproved int `json:"auto_approved"`
+	AutoRejected int `json:"auto_rejected"`
+}
+
+// ApprovalSLAModel handles approval SLAs, reminders and escalation
+type ApprovalSLAModel struct {
+	db *gorm.DB
+}
+
+func NewApprovalSLAModel(db *gorm.DB) *ApprovalSLAModel {
+	return &ApprovalSLAModel{
+		db: db,
+	}
+}
+
+// GetApprovalSLAs returns the SLA of every stage, falling back to the defaults for unconfigured stages
+func (a *ApprovalSLAModel) GetApprovalSLAs() (map[ApprovalStage]ApprovalSLA, error) {
+	var stored []ApprovalSLA
+	if err := a.db.Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	slas := make(map[ApprovalStage]ApprovalSLA, len(defaultApprovalSLAs))
+	for stage, sla := range defaultApprovalSLAs {
+		slas[stage] = sla
+	}
+	for _, sla := range stored {
+		slas[sla.Stage] = sla
+	}
+	return slas, nil
+}
+
+// SaveApprovalSLA creates or replaces the SLA of a stage
+func (a *ApprovalSLAModel) SaveApprovalSLA(sla *ApprovalSLA) error {
+	if !sla.Stage.IsValid() {
+		return fmt.Errorf("unknown approval stage %q", sla.Stage)
+	}
+	return a.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "stage"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reminder_after_hours", "reminder_interval_hours", "escalate_after_hours", "updated_at"}),
+	}).Create(sla).Error
+}
+
+// GetApprovalEvents returns the automatic actions taken on a request in order
+func (a *ApprovalSLAModel) GetApprovalEvents(leaveRequestID uint) ([]LeaveApprovalEvent, error) {
+	var events []LeaveApprovalEvent
+	if err := a.db.Where("leave_request_id = ?", leaveRequestID).
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ProcessApprovalSLAs reminds approvers of waiting requests, escalates requests past their stage deadline
+// or about to start, and auto-handles requests still undecided on their start date
+func (a *ApprovalSLAModel) ProcessApprovalSLAs(now time.Time) (*ApprovalSLAResult, error) {
+	slas, err := a.GetApprovalSLAs()
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []LeaveRequest
+	if err := a.db.Where("status IN ?", []LeaveRequestStatus{StatusPending, StatusTeamLeadApproved, StatusHRApproved}).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ApprovalSLAResult{}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := range requests {
+		request := &requests[i]
+		stage := request.PendingStage()
+		if stage == "" {
+			continue
+		}
+
+		if !request.StartDate.After(today) {
+			if err := a.autoHandle(request, stage, result); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		sla := slas[stage]
+		waited := now.Sub(request.StageStartedAt())
+		startsSoon := !request.StartDate.After(today.AddDate(0, 0, 1))
+		alreadyEscalated := request.EscalatedAt != nil && !request.EscalatedAt.Before(request.StageStartedAt())
+		noTeamLead := stage == StageTeamLead && request.TeamLeadID == nil
+		overdue := sla.EscalateAfterHours > 0 && waited >= time.Duration(sla.EscalateAfterHours)*time.Hour
+		if !alreadyEscalated && (startsSoon || noTeamLead || overdue) {
+			escalated, err := a.escalate(request, stage, now, startsSoon)
+			if err != nil {
+				return result, err
+			}
+			if escalated {
+				result.Escalations++
+				continue
+			}
+		}
+
+		if sla.ReminderAfterHours > 0 && waited >= time.Duration(sla.ReminderAfterHours)*time.Hour {
+			reminded, err := a.remind(request, stage, sla, now)
+			if err != nil {
+				return result, err
+			}
+			if reminded {
+				result.Reminders++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// currentApprovers returns the users who can decide the current stage of a request
+func (a *ApprovalSLAModel) currentApprovers(request *LeaveRequest, stage ApprovalStage) ([]uint, error) {
+	userModel := NewUserModel(a.db)
+	switch {
+	case request.EscalatedToID != nil:
+		return []uint{*request.EscalatedToID}, nil
+	case request.EscalatedToGroup != "":
+		return userModel.GetUserIDsWithPermission(stageApprovalPermission(request.EscalatedToGroup))
+	case stage == StageTeamLead && request.TeamLeadID != nil:
+		return []uint{*request.TeamLeadID}, nil
+	default:
+		return userModel.GetUserIDsWithPermission(stageApprovalPermission(stage))
+	}
+}
+
+// remind sends a reminder to the current approvers unless one was sent within the reminder interval
+func (a *ApprovalSLAModel) remind(request *LeaveRequest, stage ApprovalStage, sla ApprovalSLA, now time.Time) (bool, error) {
+	interval := time.Duration(sla.ReminderIntervalHours) * time.Hour
+	var count int64
+	if err := a.db.Model(&LeaveApprovalEvent{}).
+		Where("leave_request_id = ? AND stage = ? AND event_type = ? AND created_at > ?",
+			request.ID, stage, ApprovalEventReminder, latestTime(request.StageStartedAt(), now.Add(-interval))).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	approvers, err := a.currentApprovers(request, stage)
+	if err != nil {
+		return false, err
+	}
+	if len(approvers) == 0 {
+		return false, nil
+	}
+
+	message := fmt.Sprintf("Leave request #%d (%s, starting %s) has been waiting for your approval since %s.",
+		request.ID, request.LeaveType, request.StartDate.Format("2006-01-02"), request.StageStartedAt().Format("2006-01-02 15:04"))
+	notificationModel := NewLeaveNotificationModel(a.db)
+	for _, approverID := range approvers {
+		if err := notificationModel.CreateReminderNotification(approverID, request.ID, message); err != nil {
+			return false, err
+		}
+	}
+
+	event := &LeaveApprovalEvent{
+		LeaveRequestID: request.ID,
+		Stage:          stage,
+		EventType:      ApprovalEventReminder,
+		Note:           fmt.Sprintf("Reminder sent to %d approver(s)", len(approvers)),
+	}
+	if len(approvers) == 1 {
+		event.ToApproverID = &approvers[0]
+	}
+	return true, a.db.Create(event).Error
+}
+
+// escalate hands the current stage to the fallback approver: the team lead's own lead, or the HR group
+// for the team lead stage, and the management group for the HR stage. The management stage has no fallback.
+func (a *ApprovalSLAModel) escalate(request *LeaveRequest, stage ApprovalStage, now time.Time, startsSoon bool) (bool, error) {
+	event := &LeaveApprovalEvent{
+		LeaveRequestID: request.ID,
+		Stage:          stage,
+		EventType:      ApprovalEventEscalated,
+	}
+
+	switch stage {
+	case StageTeamLead:
+		event.FromApproverID = request.TeamLeadID
+		if request.TeamLeadID != nil {
+			fallbackID, err := a.teamLeadOf(*request.TeamLeadID)
+			if err != nil {
+				return false, err
+			}
+			if fallbackID != 0 && fallbackID != *request.TeamLeadID && fallbackID != request.UserID {
+				event.ToApproverID = &fallbackID
+			}
+		}
+		if event.ToApproverID == nil {
+			event.ToGroup = StageHR
+		}
+	case StageHR:
+		event.ToGroup = StageManagement
+	default:
+		return false, nil
+	}
+
+	event.Note = "Approval deadline passed"
+	if startsSoon {
+		event.Note = "Leave starts within a day and is still undecided"
+	}
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&LeaveRequest{}).Where("id = ? AND status = ?", request.ID, request.Status).
+			Updates(map[string]interface{}{
+				"escalated_to_id":    event.ToApproverID,
+				"escalated_to_group": event.ToGroup,
+				"escalated_at":       now,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	request.EscalatedToID = event.ToApproverID
+	request.EscalatedToGroup = event.ToGroup
+	request.EscalatedAt = &now
+
+	approvers, err := a.currentApprovers(request, stage)
+	if err != nil {
+		return true, err
+	}
+	notificationModel := NewLeaveNotificationModel(a.db)
+	for _, approverID := range approvers {
+		if err := notificationModel.CreateApprovalRequiredNotification(request, approverID,
+			fmt.Sprintf("Leave request #%d was escalated to you: %s.", request.ID, event.Note)); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// autoHandle applies the policy's undecided action to a request whose leave has started
+func (a *ApprovalSLAModel) autoHandle(request *LeaveRequest, stage ApprovalStage, result *ApprovalSLAResult) error {
+	policy, err := NewLeavePolicyModel(a.db).GetLeavePolicyByTypeAndYear(request.LeaveType, request.StartDate.Year())
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	var status LeaveRequestStatus
+	var eventType ApprovalEventType
+	switch policy.UndecidedAction {
+	case UndecidedAutoApprove:
+		if policy.RequiresDocumentFor(request.DaysRequested) {
+			// Never approve without the required document; the request stays with its approvers
+			if err := NewLeaveAttachmentModel(a.db).CheckRequiredDocuments(request); err != nil {
+				if errors.Is(err, ErrDocumentRequired) {
+					return nil
+				}
+				return err
+			}
+		}
+		// The balance may have been used by other leave since the request was made
+		advanceDays, covered, err := NewLeaveBalanceModel(a.db).requestAdvance(request, policy)
+		if err != nil || !covered {
+			return err
+		}
+		if advanceDays > request.AdvanceDays {
+			request.SetAdvance(policy, advanceDays)
+		}
+		// Long or advance leave is never approved without management: the earlier stages are approved and the
+		// request waits for management
+		status, eventType = StatusApproved, ApprovalEventAutoApproved
+		if request.RequiresManagementApproval() {
+			if request.Status == StatusHRApproved {
+				return nil
+			}
+			status = StatusHRApproved
+		}
+	case UndecidedAutoReject:
+		status, eventType = StatusRejected, ApprovalEventAutoRejected
+	default:
+		return nil
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":                       status,
+			"advance_days":                 request.AdvanceDays,
+			"management_approval_required": request.ManagementApprovalRequired,
+		}
+		if status == StatusHRApproved {
+			now := time.Now()
+			updates["hr_approved_at"] = &now
+			updates["escalated_to_id"] = nil
+			updates["escalated_to_group"] = ""
+			updates["escalated_at"] = nil
+		}
+		update := tx.Model(&LeaveRequest{}).Where("id = ? AND status = ?", request.ID, request.Status).
+			Updates(updates)
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrLeaveRequestNotPending // Decided in the meantime
+		}
+
+		if status.IsBalanceDeducted() {
+			if err := NewLeaveBalanceModel(tx).IncrementUsedDays(request.UserID, request.StartDate.Year(), request.LeaveType, request.DaysRequested, request.ID); err != nil {
+				return err
+			}
+		}
+		if status != StatusHRApproved {
+			if err := NewLeaveCalendarModel(tx).UpdateCalendarEntryStatus(request.ID, status); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&LeaveApprovalEvent{
+			LeaveRequestID: request.ID,
+			Stage:          stage,
+			EventType:      eventType,
+			Note:           "Still undecided when the leave started",
+		}).Error
+	})
+	if errors.Is(err, ErrLeaveRequestNotPending) {
+		// An approver's decision stands; nobody is told about an automatic one
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	request.Status = status
+	if status == StatusHRApproved {
+		// Still waiting for management, so there is no outcome to tell the requester yet
+		return nil
+	}
+	if err := NewLeaveNotificationModel(a.db).CreateApprovalNotification(request, status == StatusApproved); err != nil {
+		return err
+	}
+	if status == StatusApproved {
+		if _, err := NewApprovalDelegationModel(a.db).ActivateAutoDelegation(request); err != nil {
+			return err
+		}
+		result.AutoApproved++
+	} else {
+		result.AutoRejected++
+	}
+	return nil
+}
+
+// teamLeadOf returns the lead of a user's primary team, or 0 when there is none
+func (a *ApprovalSLAModel) teamLeadOf(userID uint) (uint, error) {
+	user, err := NewUserModel(a.db).GetUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	team, err := GetTeamByID(user.PrimaryTeamID)
+	if err != nil {
+		return 0, nil
+	}
+	return team.TeamLeadID, nil
+}
+
+// stageApprovalPermission returns the permission of the users who approve a stage
+func stageApprovalPermission(stage ApprovalStage) string {
+	switch stage {
+	case StageHR:
+		return "APPROVE_LEAVE_HR"
+	case StageManagement:
+		return "APPROVE_LEAVE_MANAGEMENT"
+	default:
+		return "APPROVE_LEAVE_TEAM"
+	}
+}
+
+// latestTime returns the later of two times
+func latestTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
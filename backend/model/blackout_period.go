@@ -0,0 +1,209 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BlackoutScope represents who a blackout period applies to
+type BlackoutScope string
+
+const (
+	BlackoutScopeCompany   BlackoutScope = "COMPANY"    // Everyone
+	BlackoutScopeTeam      BlackoutScope = "TEAM"       // Members of TeamID
+	BlackoutScopeLeaveType BlackoutScope = "LEAVE_TYPE" // Requests of the listed leave types
+)
+
+var (
+	ErrBlackoutPeriod        = errors.New("leave falls in a blackout period")
+	ErrInvalidBlackoutPeriod = errors.New("invalid blackout period")
+)
+
+// BlackoutPeriod is a date range in which leave cannot be requested
+type BlackoutPeriod struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Name             string         `gorm:"not null" json:"name"`
+	Description      string         `gorm:"type:text" json:"description,omitempty"`
+	Scope            BlackoutScope  `gorm:"not null;index" json:"scope"`
+	TeamID           *uint          `gorm:"index" json:"team_id,omitempty"`          // For TEAM scope
+	LeaveTypes       LeaveTypeList  `gorm:"type:jsonb" json:"leave_types,omitempty"` // For LEAVE_TYPE scope
+	StartDate        time.Time      `gorm:"not null" json:"start_date"`
+	EndDate          time.Time      `gorm:"not null" json:"end_date"`                       // Inclusive
+	ExemptLeaveTypes LeaveTypeList  `gorm:"type:jsonb" json:"exempt_leave_types,omitempty"` // E.g. SICK and EMERGENCY
+	IsActive         bool           `gorm:"default:true" json:"is_active"`
+	CreatedByID      uint           `json:"created_by_id"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at,omitempty"`
+}
+
+// Validate checks the dates and that the scope has its target
+func (b *BlackoutPeriod) Validate() error {
+	if b.EndDate.Before(b.StartDate) {
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidBlackoutPeriod)
+	}
+	switch b.Scope {
+	case BlackoutScopeCompany:
+	case BlackoutScopeTeam:
+		if b.TeamID == nil {
+			return fmt.Errorf("%w: team_id is required for TEAM scope", ErrInvalidBlackoutPeriod)
+		}
+	case BlackoutScopeLeaveType:
+		if len(b.LeaveTypes) == 0 {
+			return fmt.Errorf("%w: leave_types is required for LEAVE_TYPE scope", ErrInvalidBlackoutPeriod)
+		}
+	default:
+		return fmt.Errorf("%w: unknown scope %q", ErrInvalidBlackoutPeriod, b.Scope)
+	}
+	return nil
+}
+
+// Blocks reports whether the blackout forbids a leave type for a member of the given teams
+func (b *BlackoutPeriod) Blocks(leaveType LeaveType, teamIDs []uint) bool {
+	if len(b.ExemptLeaveTypes) > 0 && b.ExemptLeaveTypes.Contains(leaveType) {
+		return false
+	}
+	switch b.Scope {
+	case BlackoutScopeCompany:
+		return true
+	case BlackoutScopeTeam:
+		for _, teamID := range teamIDs {
+			if b.TeamID != nil && *b.TeamID == teamID {
+				return true
+			}
+		}
+		return false
+	case BlackoutScopeLeaveType:
+		return b.LeaveTypes.Contains(leaveType)
+	}
+	return false
+}
+
+// BlackoutPeriodModel handles blackout period database operations
+type BlackoutPeriodModel struct {
+	db *gorm.DB
+}
+
+func NewBlackoutPeriodModel(db *gorm.DB) *BlackoutPeriodModel {
+	return &BlackoutPeriodModel{
+		db: db,
+	}
+}
+
+// CreateBlackoutPeriod validates and stores a blackout period
+func (b *BlackoutPeriodModel) CreateBlackoutPeriod(period *BlackoutPeriod) error {
+	if err := period.Validate(); err != nil {
+		return err
+	}
+	// Select all columns so that an inactive period is not stored with the column default
+	return b.db.Select("*").Create(period).Error
+}
+
+// GetBlackoutPeriod retrieves a blackout period by ID
+func (b *BlackoutPeriodModel) GetBlackoutPeriod(id uint) (*BlackoutPeriod, error) {
+	var period BlackoutPeriod
+	if err := b.db.First(&period, id).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// GetBlackoutPeriods lists the blackout periods overlapping a date range, all of them for zero dates
+func (b *BlackoutPeriodModel) GetBlackoutPeriods(startDate, endDate time.Time) ([]BlackoutPeriod, error) {
+	query := b.db.Model(&BlackoutPeriod{})
+	if !startDate.IsZero() {
+		query = query.Where("end_date >= ?", startDate)
+	}
+	if !endDate.IsZero() {
+		query = query.Where("start_date <= ?", endDate)
+	}
+
+	var periods []BlackoutPeriod
+	if err := query.Order("start_date ASC").Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// UpdateBlackoutPeriod validates and saves a blackout period
+func (b *BlackoutPeriodModel) UpdateBlackoutPeriod(period *BlackoutPeriod) error {
+	if err := period.Validate(); err != nil {
+		return err
+	}
+	return b.db.Save(period).Error
+}
+
+// DeleteBlackoutPeriod soft deletes a blackout period
+func (b *BlackoutPeriodModel) DeleteBlackoutPeriod(id uint) error {
+	result := b.db.Delete(&BlackoutPeriod{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetUserBlackoutPeriods returns the active blackout periods overlapping a date range that apply to a user
+// for any leave type, ignoring exemptions
+func (b *BlackoutPeriodModel) GetUserBlackoutPeriods(userID uint, startDate, endDate time.Time) ([]BlackoutPeriod, error) {
+	teamIDs, err := NewUserModel(b.db).GetUserTeamIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	periods, err := b.activePeriods(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	applicable := []BlackoutPeriod{}
+	for _, period := range periods {
+		if period.Scope != BlackoutScopeTeam || period.Blocks("", teamIDs) {
+			applicable = append(applicable, period)
+		}
+	}
+	return applicable, nil
+}
+
+// CheckLeaveRequest returns an ErrBlackoutPeriod error naming every blackout period the request falls in
+func (b *BlackoutPeriodModel) CheckLeaveRequest(request *LeaveRequest) error {
+	periods, err := b.activePeriods(request.StartDate, request.EndDate)
+	if err != nil || len(periods) == 0 {
+		return err
+	}
+
+	teamIDs, err := NewUserModel(b.db).GetUserTeamIDs(request.UserID)
+	if err != nil {
+		return err
+	}
+
+	var blocking []string
+	for _, period := range periods {
+		if period.Blocks(request.LeaveType, teamIDs) {
+			blocking = append(blocking, fmt.Sprintf("%q (%s to %s)",
+				period.Name, period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02")))
+		}
+	}
+	if len(blocking) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s leave cannot be taken during %s", ErrBlackoutPeriod,
+		strings.ToLower(string(request.LeaveType)), strings.Join(blocking, ", "))
+}
+
+// activePeriods returns the active blackout periods overlapping a date range
+func (b *BlackoutPeriodModel) activePeriods(startDate, endDate time.Time) ([]BlackoutPeriod, error) {
+	var periods []BlackoutPeriod
+	if err := b.db.Where("is_active = ? AND start_date <= ? AND end_date >= ?", true, endDate, startDate).
+		Order("start_date ASC").
+		Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
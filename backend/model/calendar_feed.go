@@ -0,0 +1,163 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarFeedType represents what an iCalendar feed contains
+type CalendarFeedType string
+
+const (
+	FeedPersonal CalendarFeedType = "PERSONAL" // The owner's own leave
+	FeedTeam     CalendarFeedType = "TEAM"     // Leave of everyone in the owner's teams
+	FeedCompany  CalendarFeedType = "COMPANY"  // Company holidays
+)
+
+// IsValid reports whether the feed type is known
+func (t CalendarFeedType) IsValid() bool {
+	return t == FeedPersonal || t == FeedTeam || t == FeedCompany
+}
+
+var ErrInvalidFeedType = errors.New("feed type must be PERSONAL, TEAM or COMPANY")
+
+// CalendarFeed is a secret-token URL a user subscribes to from a calendar app. Only a hash of the
+// token is stored; the token itself is shown once when the feed is created.
+type CalendarFeed struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	UserID         uint             `gorm:"not null;index" json:"user_id"`
+	FeedType       CalendarFeedType `gorm:"not null" json:"feed_type"`
+	TokenHash      string           `gorm:"not null;uniqueIndex" json:"-"`
+	LastAccessedAt *time.Time       `json:"last_accessed_at,omitempty"`
+	RevokedAt      *time.Time       `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// CalendarFeedModel handles calendar feed database operations
+type CalendarFeedModel struct {
+	db *gorm.DB
+}
+
+func NewCalendarFeedModel(db *gorm.DB) *CalendarFeedModel {
+	return &CalendarFeedModel{
+		db: db,
+	}
+}
+
+// CreateFeed issues a new feed token for a user, revoking their previous feed of the same type.
+// It returns the feed and the plain token.
+func (f *CalendarFeedModel) CreateFeed(userID uint, feedType CalendarFeedType) (*CalendarFeed, string, error) {
+	if !feedType.IsValid() {
+		return nil, "", ErrInvalidFeedType
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	feed := &CalendarFeed{
+		UserID:    userID,
+		FeedType:  feedType,
+		TokenHash: hashFeedToken(token),
+	}
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&CalendarFeed{}).
+			Where("user_id = ? AND feed_type = ? AND revoked_at IS NULL", userID, feedType).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(feed).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return feed, token, nil
+}
+
+// GetUserFeeds lists a user's feeds, newest first
+func (f *CalendarFeedModel) GetUserFeeds(userID uint) ([]CalendarFeed, error) {
+	var feeds []CalendarFeed
+	if err := f.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&feeds).Error; err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// GetFeedByToken returns the unrevoked feed of a token whose owner can still log in
+func (f *CalendarFeedModel) GetFeedByToken(token string) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	if err := f.db.Joins("JOIN users ON users.id = calendar_feeds.user_id").
+		Where("calendar_feeds.token_hash = ? AND calendar_feeds.revoked_at IS NULL AND users.is_active_user = ?", hashFeedToken(token), true).
+		First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// RevokeFeed stops a feed of the user from serving events
+func (f *CalendarFeedModel) RevokeFeed(id uint, userID uint) error {
+	result := f.db.Model(&CalendarFeed{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAccessed records when a calendar app last fetched the feed
+func (f *CalendarFeedModel) MarkAccessed(feed *CalendarFeed, now time.Time) error {
+	return f.db.Model(feed).UpdateColumn("last_accessed_at", now).Error
+}
+
+// GetFeedLeaveRequests returns the leave requests of a personal or team feed ending on or after a date,
+// including cancelled, rejected and deleted ones so that subscribers can drop them
+func (f *CalendarFeedModel) GetFeedLeaveRequests(feed *CalendarFeed, since time.Time) ([]LeaveRequest, error) {
+	query := f.db.Unscoped().Where("end_date >= ?", since)
+
+	switch feed.FeedType {
+	case FeedPersonal:
+		query = query.Where("user_id = ?", feed.UserID)
+	case FeedTeam:
+		teamIDs, err := NewUserModel(f.db).GetUserTeamIDs(feed.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if len(teamIDs) == 0 {
+			query = query.Where("user_id = ?", feed.UserID)
+			break
+		}
+		query = query.Where("user_id = ? OR user_id IN (?) OR user_id IN (?)", feed.UserID,
+			f.db.Model(&User{}).Select("id").Where("primary_team_id IN ?", teamIDs),
+			f.db.Table("team_members").Select("user_id").Where("team_id IN ?", teamIDs))
+	default:
+		return []LeaveRequest{}, nil
+	}
+
+	var requests []LeaveRequest
+	if err := query.Preload("User").
+		Order("start_date ASC").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// hashFeedToken returns the stored form of a feed token
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
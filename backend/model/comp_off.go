@@ -0,0 +1,465 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CompOffHoursPerDay is the number of worked hours that earn one day of time off
+const CompOffHoursPerDay = 8
+
+// CompOffClaimStatus represents the status of a comp-off claim
+type CompOffClaimStatus string
+
+const (
+	CompOffStatusPending   CompOffClaimStatus = "PENDING"
+	CompOffStatusApproved  CompOffClaimStatus = "APPROVED"
+	CompOffStatusRejected  CompOffClaimStatus = "REJECTED"
+	CompOffStatusWithdrawn CompOffClaimStatus = "WITHDRAWN"
+)
+
+var (
+	ErrInvalidCompOffClaim = errors.New("invalid comp-off claim")
+	ErrCompOffNotPending   = errors.New("comp-off claim is no longer pending")
+	ErrNotCompOffApprover  = errors.New("not an approver of this comp-off claim")
+)
+
+// CompOffWorkedDay is a day of overtime or weekend work and the hours worked on it
+type CompOffWorkedDay struct {
+	Date  time.Time `json:"date"`
+	Hours float64   `json:"hours"`
+}
+
+// CompOffWorkedDays represents the worked days of a claim for JSON storage
+type CompOffWorkedDays []CompOffWorkedDay
+
+// Value implements the driver.Valuer interface
+func (d CompOffWorkedDays) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan implements the sql.Scanner interface
+func (d *CompOffWorkedDays) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return gorm.ErrInvalidData
+	}
+
+	return json.Unmarshal(bytes, d)
+}
+
+// CompOffClaim asks for time off in lieu of days worked outside the normal schedule. Once the team lead approves
+// it, its days are credited to the COMP_OFF balance of the year worked and can be used until it expires.
+type CompOffClaim struct {
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	UserID      uint               `gorm:"not null;index" json:"user_id"`
+	WorkedDays  CompOffWorkedDays  `gorm:"type:jsonb" json:"worked_days"`
+	TotalHours  float64            `gorm:"not null" json:"total_hours"`
+	Days        int                `gorm:"not null" json:"days"` // Days of time off the worked hours earn
+	Year        int                `gorm:"not null" json:"year"` // Year of the balance that is credited
+	Reason      string             `gorm:"type:text" json:"reason,omitempty"`
+	Status      CompOffClaimStatus `gorm:"not null;default:'PENDING';index" json:"status"`
+	TeamLeadID  *uint              `json:"team_lead_id,omitempty"`
+	DecidedByID *uint              `json:"decided_by_id,omitempty"`
+	DecidedAt   *time.Time         `json:"decided_at,omitempty"`
+	Comments    string             `gorm:"type:text" json:"comments,omitempty"`
+	ExpiresOn   *time.Time         `json:"expires_on,omitempty"`                   // Last day the credited days can be used
+	ExpiredAt   *time.Time         `json:"expired_at,omitempty"`                   // When the expiry was processed
+	ExpiredDays int                `gorm:"not null;default:0" json:"expired_days"` // Unused days forfeited at expiry
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// approvalRequest describes the claim as a pending leave request, so that it is decided by the same team lead
+// and delegates as leave of the COMP_OFF type
+func (c *CompOffClaim) approvalRequest() *LeaveRequest {
+	return &LeaveRequest{
+		UserID:     c.UserID,
+		LeaveType:  LeaveTypeCompOff,
+		Status:     StatusPending,
+		TeamLeadID: c.TeamLeadID,
+	}
+}
+
+// CompOffClaimModel handles comp-off claim database operations
+type CompOffClaimModel struct {
+	db *gorm.DB
+}
+
+func NewCompOffClaimModel(db *gorm.DB) *CompOffClaimModel {
+	return &CompOffClaimModel{
+		db: db,
+	}
+}
+
+// GetClaim retrieves a comp-off claim by ID
+func (m *CompOffClaimModel) GetClaim(id uint) (*CompOffClaim, error) {
+	var claim CompOffClaim
+	if err := m.db.Preload("User").First(&claim, id).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// GetUserClaims lists the comp-off claims of a user, newest first
+func (m *CompOffClaimModel) GetUserClaims(userID uint) ([]CompOffClaim, error) {
+	var claims []CompOffClaim
+	if err := m.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// GetPendingClaimsFor lists the pending comp-off claims a user may decide now
+func (m *CompOffClaimModel) GetPendingClaimsFor(userID uint) ([]CompOffClaim, error) {
+	var claims []CompOffClaim
+	if err := m.db.Where("status = ? AND user_id <> ?", CompOffStatusPending, userID).
+		Preload("User").
+		Order("created_at ASC").
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+
+	decidable := []CompOffClaim{}
+	for i := range claims {
+		actorID, err := m.deciderFor(&claims[i], userID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID != 0 {
+			decidable = append(decidable, claims[i])
+		}
+	}
+	return decidable, nil
+}
+
+// SubmitClaim validates a claim, works out the days it earns and stores it for the team lead's approval
+func (m *CompOffClaimModel) SubmitClaim(claim *CompOffClaim) error {
+	if err := m.validateClaim(claim); err != nil {
+		return err
+	}
+
+	user, err := NewLeaveRequestModel(m.db).getUserWithTeam(claim.UserID)
+	if err != nil {
+		return err
+	}
+	team, err := GetTeamByID(user.PrimaryTeamID)
+	if err != nil {
+		return err
+	}
+	if team.TeamLeadID == 0 || team.TeamLeadID == claim.UserID {
+		return fmt.Errorf("%w: your team has no team lead to approve the claim", ErrInvalidCompOffClaim)
+	}
+	claim.TeamLeadID = &team.TeamLeadID
+	claim.Status = CompOffStatusPending
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(claim).Error; err != nil {
+			return err
+		}
+		return NewLeaveNotificationModel(tx).CreateCompOffClaimNotification(claim, team.TeamLeadID)
+	})
+}
+
+// DecideClaim approves or rejects a pending claim. Approval credits its days to the user's COMP_OFF balance and
+// starts the expiry window of the policy.
+func (m *CompOffClaimModel) DecideClaim(claimID, approverID uint, approve bool, comments string) (*CompOffClaim, error) {
+	claim, err := m.GetClaim(claimID)
+	if err != nil {
+		return nil, err
+	}
+	if claim.Status != CompOffStatusPending {
+		return nil, ErrCompOffNotPending
+	}
+	actorID, err := m.deciderFor(claim, approverID)
+	if err != nil {
+		return nil, err
+	}
+	if actorID == 0 {
+		return nil, ErrNotCompOffApprover
+	}
+
+	comments = strings.TrimSpace(comments)
+	if actorID != approverID {
+		comments = strings.TrimSpace("Decided by a delegate on behalf of the team lead. " + comments)
+	}
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the claim so that two approvers cannot credit it twice
+		var locked CompOffClaim
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, claim.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != CompOffStatusPending {
+			return ErrCompOffNotPending
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":        CompOffStatusRejected,
+			"decided_by_id": approverID,
+			"decided_at":    now,
+			"comments":      comments,
+		}
+		if approve {
+			policy, err := NewLeavePolicyModel(tx).GetLeavePolicyByTypeAndYear(LeaveTypeCompOff, claim.Year)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: there is no active %s policy for %d", ErrInvalidCompOffClaim, LeaveTypeCompOff, claim.Year)
+				}
+				return err
+			}
+			expiresOn := policy.CompOffExpiryDate(claim.lastWorkedDate())
+			updates["status"] = CompOffStatusApproved
+			updates["expires_on"] = expiresOn
+			claim.ExpiresOn = &expiresOn
+
+			if err := m.creditBalance(tx, claim, approverID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&CompOffClaim{}).Where("id = ?", claim.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		claim.Status = updates["status"].(CompOffClaimStatus)
+		claim.DecidedByID = &approverID
+		claim.DecidedAt = &now
+		claim.Comments = comments
+
+		return NewLeaveNotificationModel(tx).CreateCompOffDecisionNotification(claim, approve)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// WithdrawClaim lets the user take back a pending claim
+func (m *CompOffClaimModel) WithdrawClaim(claimID, userID uint) error {
+	result := m.db.Model(&CompOffClaim{}).
+		Where("id = ? AND user_id = ? AND status = ?", claimID, userID, CompOffStatusPending).
+		Update("status", CompOffStatusWithdrawn)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCompOffNotPending
+	}
+	return nil
+}
+
+// ExpireCompOffCredits forfeits the unused days of approved claims whose expiry date has passed by asOf.
+// Days of a balance are used from the claims that expire first; days that were not earned by a claim, such as
+// manual adjustments, count as used before them. Each claim is processed once, so it is safe to run repeatedly.
+// It returns the number of claims that lost days.
+func (m *CompOffClaimModel) ExpireCompOffCredits(asOf time.Time) (int, error) {
+	today := truncateToDate(asOf)
+	var due []CompOffClaim
+	if err := m.db.Where("status = ? AND expired_at IS NULL AND expires_on < ?", CompOffStatusApproved, today).
+		Order("expires_on ASC, id ASC").
+		Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, claim := range due {
+		forfeited := 0
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			balance, err := NewLeaveBalanceModel(tx).GetUserLeaveBalanceByType(claim.UserID, claim.Year, LeaveTypeCompOff)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if balance != nil {
+				// Days of later claims are still usable and were not used up before this claim's
+				var later []CompOffClaim
+				if err := tx.Where("user_id = ? AND year = ? AND status = ? AND expired_at IS NULL AND id <> ?",
+					claim.UserID, claim.Year, CompOffStatusApproved, claim.ID).
+					Find(&later).Error; err != nil {
+					return err
+				}
+				laterDays := 0
+				for _, other := range later {
+					if other.ExpiresOn.After(*claim.ExpiresOn) || (other.ExpiresOn.Equal(*claim.ExpiresOn) && other.ID > claim.ID) {
+						laterDays += other.Days
+					}
+				}
+
+				unused := balance.RemainingDays - laterDays
+				if unused > claim.Days {
+					unused = claim.Days
+				}
+				if unused > 0 {
+					entry := newLedgerEntry(LedgerCompOffExpiry, -unused,
+						LedgerSource{Reason: fmt.Sprintf("Comp-off claim #%d expired on %s", claim.ID, claim.ExpiresOn.Format("2006-01-02"))})
+					entry.EffectiveDate = claim.ExpiresOn.AddDate(0, 0, 1)
+					if err := NewLeaveBalanceModel(tx).postLedgerEntries(balance, entry); err != nil {
+						return err
+					}
+					forfeited = unused
+				}
+			}
+
+			return tx.Model(&CompOffClaim{}).Where("id = ?", claim.ID).Updates(map[string]interface{}{
+				"expired_at":   time.Now(),
+				"expired_days": forfeited,
+			}).Error
+		})
+		if err != nil {
+			return expired, err
+		}
+		if forfeited > 0 {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// CompOffExpiryDate returns the last day on which days earned by work on a date can be used. Credits never
+// outlive the balance year they were credited to.
+func (p *LeavePolicy) CompOffExpiryDate(workedDate time.Time) time.Time {
+	endOfYear := time.Date(p.Year, 12, 31, 0, 0, 0, 0, time.UTC)
+	if p.CompOffExpiryDays <= 0 {
+		return endOfYear
+	}
+	expiry := truncateToDate(workedDate).AddDate(0, 0, p.CompOffExpiryDays)
+	if expiry.After(endOfYear) {
+		return endOfYear
+	}
+	return expiry
+}
+
+// lastWorkedDate returns the latest worked day of the claim, from which its expiry window runs
+func (c *CompOffClaim) lastWorkedDate() time.Time {
+	var last time.Time
+	for _, day := range c.WorkedDays {
+		if day.Date.After(last) {
+			last = day.Date
+		}
+	}
+	return last
+}
+
+// creditBalance posts the days of an approved claim to the user's COMP_OFF balance, creating it when needed
+func (m *CompOffClaimModel) creditBalance(tx *gorm.DB, claim *CompOffClaim, approverID uint) error {
+	balanceModel := NewLeaveBalanceModel(tx)
+	balance, err := balanceModel.GetUserLeaveBalanceByType(claim.UserID, claim.Year, LeaveTypeCompOff)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		balance = &LeaveBalance{UserID: claim.UserID, LeaveType: LeaveTypeCompOff, Year: claim.Year}
+	}
+
+	return balanceModel.postLedgerEntries(balance, newLedgerEntry(LedgerCompOffCredit, claim.Days, LedgerSource{
+		ActorID: &approverID,
+		Reason: fmt.Sprintf("Comp-off claim #%d for %.1f hours worked, usable until %s",
+			claim.ID, claim.TotalHours, claim.ExpiresOn.Format("2006-01-02")),
+	}))
+}
+
+// validateClaim checks the worked days and works out the total hours, the earned days and the balance year
+func (m *CompOffClaimModel) validateClaim(claim *CompOffClaim) error {
+	if len(claim.WorkedDays) == 0 {
+		return fmt.Errorf("%w: at least one worked day is required", ErrInvalidCompOffClaim)
+	}
+
+	today := truncateToDate(time.Now())
+	seen := map[time.Time]bool{}
+	claim.TotalHours = 0
+	for i := range claim.WorkedDays {
+		day := &claim.WorkedDays[i]
+		day.Date = truncateToDate(day.Date)
+		if day.Date.After(today) {
+			return fmt.Errorf("%w: %s is in the future, only days already worked can be claimed",
+				ErrInvalidCompOffClaim, day.Date.Format("2006-01-02"))
+		}
+		if day.Hours <= 0 || day.Hours > 24 {
+			return fmt.Errorf("%w: hours worked on %s must be between 0 and 24",
+				ErrInvalidCompOffClaim, day.Date.Format("2006-01-02"))
+		}
+		if seen[day.Date] {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidCompOffClaim, day.Date.Format("2006-01-02"))
+		}
+		seen[day.Date] = true
+		if day.Date.Year() != claim.WorkedDays[0].Date.Year() {
+			return fmt.Errorf("%w: all worked days of a claim must be in the same year", ErrInvalidCompOffClaim)
+		}
+		claim.TotalHours += day.Hours
+	}
+	sort.Slice(claim.WorkedDays, func(i, j int) bool { return claim.WorkedDays[i].Date.Before(claim.WorkedDays[j].Date) })
+
+	claim.Year = claim.WorkedDays[0].Date.Year()
+	claim.Days = int(math.Floor(claim.TotalHours / CompOffHoursPerDay))
+	if claim.Days == 0 {
+		return fmt.Errorf("%w: %.1f hours do not add up to a day of time off, which takes %d hours",
+			ErrInvalidCompOffClaim, claim.TotalHours, CompOffHoursPerDay)
+	}
+
+	policy, err := NewLeavePolicyModel(m.db).GetLeavePolicyByTypeAndYear(LeaveTypeCompOff, claim.Year)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: there is no active %s policy for %d", ErrInvalidCompOffClaim, LeaveTypeCompOff, claim.Year)
+		}
+		return err
+	}
+	if expiry := policy.CompOffExpiryDate(claim.lastWorkedDate()); expiry.Before(today) {
+		return fmt.Errorf("%w: time off for these days could only be used until %s",
+			ErrInvalidCompOffClaim, expiry.Format("2006-01-02"))
+	}
+
+	// A day can only be claimed once
+	var open []CompOffClaim
+	if err := m.db.Where("user_id = ? AND status IN ?", claim.UserID,
+		[]CompOffClaimStatus{CompOffStatusPending, CompOffStatusApproved}).
+		Find(&open).Error; err != nil {
+		return err
+	}
+	for _, other := range open {
+		for _, day := range other.WorkedDays {
+			if seen[truncateToDate(day.Date)] {
+				return fmt.Errorf("%w: %s is already claimed by claim #%d",
+					ErrInvalidCompOffClaim, day.Date.Format("2006-01-02"), other.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// deciderFor returns the approver in whose right a user decides a claim: the team lead themselves, or the team
+// lead they stand in for through a delegation covering COMP_OFF leave. It returns 0 if the user cannot decide.
+func (m *CompOffClaimModel) deciderFor(claim *CompOffClaim, userID uint) (uint, error) {
+	if claim.Status != CompOffStatusPending || claim.UserID == userID {
+		return 0, nil
+	}
+
+	request := claim.approvalRequest()
+	leaveRequestModel := NewLeaveRequestModel(m.db)
+	canDecide, err := leaveRequestModel.canDecide(request, userID)
+	if err != nil {
+		return 0, err
+	}
+	if canDecide {
+		return userID, nil
+	}
+	return leaveRequestModel.delegatorFor(request, userID)
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CompanyHoliday is a public holiday on which the whole company is off
+type CompanyHoliday struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"not null" json:"name"`
+	Date        time.Time      `gorm:"not null;index" json:"date"`
+	Description string         `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty"`
+}
+
+// CompanyHolidayModel handles company holiday database operations
+type CompanyHolidayModel struct {
+	db *gorm.DB
+}
+
+func NewCompanyHolidayModel(db *gorm.DB) *CompanyHolidayModel {
+	return &CompanyHolidayModel{
+		db: db,
+	}
+}
+
+// CreateHoliday stores a company holiday
+func (h *CompanyHolidayModel) CreateHoliday(holiday *CompanyHoliday) error {
+	return h.db.Create(holiday).Error
+}
+
+// GetHolidays lists the company holidays within a date range
+func (h *CompanyHolidayModel) GetHolidays(startDate, endDate time.Time) ([]CompanyHoliday, error) {
+	var holidays []CompanyHoliday
+	if err := h.db.Where("date >= ? AND date <= ?", startDate, endDate).
+		Order("date ASC").
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// GetHolidaysIncludingDeleted lists the company holidays within a date range, including removed ones
+// so that calendar subscribers can drop them
+func (h *CompanyHolidayModel) GetHolidaysIncludingDeleted(startDate, endDate time.Time) ([]CompanyHoliday, error) {
+	var holidays []CompanyHoliday
+	if err := h.db.Unscoped().
+		Where("date >= ? AND date <= ?", startDate, endDate).
+		Order("date ASC").
+		Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// DeleteHoliday soft deletes a company holiday
+func (h *CompanyHolidayModel) DeleteHoliday(id uint) error {
+	result := h.db.Delete(&CompanyHoliday{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,453 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccrualFrequency controls whether a leave policy grants its allocation up-front or earns it over the year
+type AccrualFrequency string
+
+const (
+	AccrualNone      AccrualFrequency = "NONE"       // Full allocation granted at the start of the year
+	AccrualMonthly   AccrualFrequency = "MONTHLY"    // Earned in twelve monthly credits
+	AccrualPayPeriod AccrualFrequency = "PAY_PERIOD" // Earned in PayPeriodsPerYear equal credits
+)
+
+// TenureBand grants extra days per year once a user has been employed for at least MinYears
+type TenureBand struct {
+	MinYears  int `json:"min_years"`
+	ExtraDays int `json:"extra_days"`
+}
+
+// TenureBands represents the tenure bands of a policy for JSON storage
+type TenureBands []TenureBand
+
+// Value implements the driver.Valuer interface
+func (t TenureBands) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// Scan implements the sql.Scanner interface
+func (t *TenureBands) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return gorm.ErrInvalidData
+	}
+
+	return json.Unmarshal(bytes, t)
+}
+
+// ExtraDays returns the extra days of the highest band reached after the given years of tenure
+func (t TenureBands) ExtraDays(tenureYears int) int {
+	extra, bestMinYears := 0, -1
+	for _, band := range t {
+		if tenureYears >= band.MinYears && band.MinYears > bestMinYears {
+			extra, bestMinYears = band.ExtraDays, band.MinYears
+		}
+	}
+	return extra
+}
+
+// AccrualPeriod is one accrual slot of a policy year; credits are posted once the period has ended
+type AccrualPeriod struct {
+	Number int // 1-based within the year
+	Start  time.Time
+	End    time.Time // Inclusive last day
+}
+
+// LeaveAccrual records one posted accrual credit. The unique index makes posting a period idempotent.
+type LeaveAccrual struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_leave_accrual_period" json:"user_id"`
+	LeaveType   LeaveType `gorm:"not null;uniqueIndex:idx_leave_accrual_period" json:"leave_type"`
+	Year        int       `gorm:"not null;uniqueIndex:idx_leave_accrual_period" json:"year"`
+	Period      int       `gorm:"not null;uniqueIndex:idx_leave_accrual_period" json:"period"`
+	PeriodStart time.Time `gorm:"not null" json:"period_start"`
+	PeriodEnd   time.Time `gorm:"not null" json:"period_end"`
+	Days        float64   `gorm:"not null;default:0" json:"days"`        // Days credited to the balance
+	CappedDays  float64   `gorm:"not null;default:0" json:"capped_days"` // Days withheld because the balance reached the accrual cap
+	TenureYears int       `gorm:"not null;default:0" json:"tenure_years"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AccrualSummary compares what a user has accrued so far with what the year is projected to end at
+type AccrualSummary struct {
+	Frequency        AccrualFrequency `json:"frequency"`
+	AccruedToDate    float64          `json:"accrued_to_date"`
+	ProjectedYearEnd float64          `json:"projected_year_end"` // Accrued days plus the credits still scheduled this year, before the cap
+	PeriodsPosted    int              `json:"periods_posted"`
+	PeriodsInYear    int              `json:"periods_in_year"`
+}
+
+// IsAccrued reports whether the policy earns its allocation over the year instead of granting it up-front
+func (p *LeavePolicy) IsAccrued() bool {
+	return p.AccrualFrequency == AccrualMonthly || p.AccrualFrequency == AccrualPayPeriod
+}
+
+// InitialAllocation returns the allocation a new balance starts with; accrued policies start empty
+func (p *LeavePolicy) InitialAllocation(employmentStart, employmentEnd *time.Time) int {
+	if p.IsAccrued() {
+		return 0
+	}
+	return p.ProRatedAllocation(employmentStart, employmentEnd)
+}
+
+// AccrualPeriods splits the policy year into its accrual periods
+func (p *LeavePolicy) AccrualPeriods() []AccrualPeriod {
+	startOfYear := time.Date(p.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	switch p.AccrualFrequency {
+	case AccrualMonthly:
+		periods := make([]AccrualPeriod, 12)
+		for i := range periods {
+			start := startOfYear.AddDate(0, i, 0)
+			periods[i] = AccrualPeriod{Number: i + 1, Start: start, End: start.AddDate(0, 1, -1)}
+		}
+		return periods
+	case AccrualPayPeriod:
+		count := p.PayPeriodsPerYear
+		if count <= 0 {
+			count = 26
+		}
+		daysInYear := int(startOfYear.AddDate(1, 0, 0).Sub(startOfYear).Hours() / 24)
+		periods := make([]AccrualPeriod, count)
+		for i := range periods {
+			from := i * daysInYear / count
+			to := (i+1)*daysInYear/count - 1
+			periods[i] = AccrualPeriod{Number: i + 1, Start: startOfYear.AddDate(0, 0, from), End: startOfYear.AddDate(0, 0, to)}
+		}
+		return periods
+	default:
+		return nil
+	}
+}
+
+// PeriodCredit returns the days earned in a period: the yearly entitlement including the tenure bonus,
+// divided evenly over the periods and scaled by the share of the period the user was employed
+func (p *LeavePolicy) PeriodCredit(period AccrualPeriod, tenureYears int, employmentStart, employmentEnd *time.Time) float64 {
+	periods := len(p.AccrualPeriods())
+	if periods == 0 {
+		return 0
+	}
+
+	from, to := period.Start, period.End
+	if employmentStart != nil {
+		start := truncateToDate(*employmentStart)
+		if start.After(from) {
+			from = start
+		}
+	}
+	if employmentEnd != nil {
+		end := truncateToDate(*employmentEnd)
+		if end.Before(to) {
+			to = end
+		}
+	}
+	if to.Before(from) {
+		return 0
+	}
+
+	employed := (to.Sub(from).Hours()/24 + 1) / (period.End.Sub(period.Start).Hours()/24 + 1)
+	entitlement := float64(p.DefaultAllocation + p.TenureBands.ExtraDays(tenureYears))
+	return roundAccrual(entitlement / float64(periods) * employed)
+}
+
+// TenureYears returns the completed years of employment on a date, counted from the employment start date
+// or, when it is not recorded, from the account creation
+func (u *User) TenureYears(on time.Time) int {
+	start := u.CreatedAt
+	if u.EmploymentStartDate != nil {
+		start = *u.EmploymentStartDate
+	}
+	if on.Before(start) {
+		return 0
+	}
+
+	years := on.Year() - start.Year()
+	if on.Month() < start.Month() || (on.Month() == start.Month() && on.Day() < start.Day()) {
+		years--
+	}
+	return years
+}
+
+// LeaveAccrualModel handles periodic leave accrual
+type LeaveAccrualModel struct {
+	db *gorm.DB
+}
+
+func NewLeaveAccrualModel(db *gorm.DB) *LeaveAccrualModel {
+	return &LeaveAccrualModel{
+		db: db,
+	}
+}
+
+// RunAccruals posts every accrual period of the year that has ended on or before asOf and has not been posted yet.
+// Periods of the previous year missed by the last runs of that year, such as December after a Dec 31 outage, are
+// posted too. It is safe to run repeatedly; it returns the number of credits posted.
+func (a *LeaveAccrualModel) RunAccruals(asOf time.Time) (int, error) {
+	year := asOf.Year()
+
+	var policies []LeavePolicy
+	if err := a.db.Where("year IN ? AND is_active = ? AND accrual_frequency IN ?", []int{year - 1, year}, true,
+		[]AccrualFrequency{AccrualMonthly, AccrualPayPeriod}).
+		Order("year ASC").
+		Find(&policies).Error; err != nil {
+		return 0, err
+	}
+	if len(policies) == 0 {
+		return 0, nil
+	}
+
+	// Users who left during either year still earn the periods they were employed for
+	startOfLastYear := time.Date(year-1, 1, 1, 0, 0, 0, 0, time.UTC)
+	var users []User
+	if err := a.db.Where("(is_active_user = ? OR termination_date IS NOT NULL) AND (employment_end_date IS NULL OR employment_end_date >= ?)", true, startOfLastYear).
+		Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for i := range users {
+		for j := range policies {
+			policy := &policies[j]
+			startOfYear := time.Date(policy.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+			if users[i].EmploymentEndDate != nil && users[i].EmploymentEndDate.Before(startOfYear) {
+				continue
+			}
+			if policy.Year < year {
+				// Last year is only revisited until all its periods are posted
+				due, err := a.hasUnpostedPeriods(&users[i], policy, asOf)
+				if err != nil {
+					return posted, err
+				}
+				if !due {
+					continue
+				}
+			}
+			count, err := a.accrueUser(&users[i], policy, asOf)
+			if err != nil {
+				return posted, err
+			}
+			posted += count
+		}
+	}
+
+	return posted, nil
+}
+
+// hasUnpostedPeriods reports whether a policy has ended periods the user earns days for that are not posted yet
+func (a *LeaveAccrualModel) hasUnpostedPeriods(user *User, policy *LeavePolicy, asOf time.Time) (bool, error) {
+	var postedPeriods []int
+	if err := a.db.Model(&LeaveAccrual{}).
+		Where("user_id = ? AND leave_type = ? AND year = ?", user.ID, policy.LeaveType, policy.Year).
+		Pluck("period", &postedPeriods).Error; err != nil {
+		return false, err
+	}
+	posted := make(map[int]bool, len(postedPeriods))
+	for _, period := range postedPeriods {
+		posted[period] = true
+	}
+
+	today := truncateToDate(asOf)
+	for _, period := range policy.AccrualPeriods() {
+		if period.End.After(today) {
+			break
+		}
+		if !posted[period.Number] &&
+			policy.PeriodCredit(period, user.TenureYears(period.End), user.EmploymentStartDate, user.EmploymentEndDate) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// accrueUser posts the due periods of one policy for one user and updates the balance allocation
+func (a *LeaveAccrualModel) accrueUser(user *User, policy *LeavePolicy, asOf time.Time) (int, error) {
+	today := truncateToDate(asOf)
+	posted := 0
+
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		balanceModel := NewLeaveBalanceModel(tx)
+		if err := balanceModel.InitializeUserLeaveBalances(user.ID, policy.Year); err != nil {
+			return err
+		}
+		balance, err := balanceModel.GetUserLeaveBalanceByType(user.ID, policy.Year, policy.LeaveType)
+		if err != nil {
+			return err
+		}
+
+		var existing []LeaveAccrual
+		if err := tx.Where("user_id = ? AND leave_type = ? AND year = ?", user.ID, policy.LeaveType, policy.Year).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		postedPeriods := make(map[int]bool)
+		accrued := 0.0
+		for _, accrual := range existing {
+			postedPeriods[accrual.Period] = true
+			accrued += accrual.Days
+		}
+
+		for _, period := range policy.AccrualPeriods() {
+			if period.End.After(today) {
+				break
+			}
+			if postedPeriods[period.Number] {
+				continue
+			}
+
+			tenure := user.TenureYears(period.End)
+			credit := policy.PeriodCredit(period, tenure, user.EmploymentStartDate, user.EmploymentEndDate)
+			if credit == 0 {
+				continue // Not employed during this period
+			}
+
+			days, capped := credit, 0.0
+			if policy.AccrualCap > 0 {
+				room := float64(policy.AccrualCap) - (accrued + float64(balance.CarryOverDays-balance.UsedDays))
+				if room < 0 {
+					room = 0
+				}
+				if days > room {
+					days, capped = roundAccrual(room), roundAccrual(credit-room)
+				}
+			}
+
+			accrual := &LeaveAccrual{
+				UserID:      user.ID,
+				LeaveType:   policy.LeaveType,
+				Year:        policy.Year,
+				Period:      period.Number,
+				PeriodStart: period.Start,
+				PeriodEnd:   period.End,
+				Days:        days,
+				CappedDays:  capped,
+				TenureYears: tenure,
+			}
+			// A concurrent run may have posted the period already
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(accrual)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			accrued += days
+			posted++
+		}
+
+		// A settled advance is earned back by the accruals, so it stays on the allocation
+		extra, err := balanceModel.nonPolicyAllocation(user.ID, policy.Year, policy.LeaveType)
+		if err != nil {
+			return err
+		}
+		allocation := accruedAllocation(accrued) + extra
+		if allocation == balance.TotalAllocated {
+			return nil
+		}
+		return balanceModel.postLedgerEntries(balance,
+			newLedgerEntry(LedgerAccrual, allocation-balance.TotalAllocated, LedgerSource{Reason: fmt.Sprintf("Accrued through %s", today.Format("2006-01-02"))}))
+	})
+
+	return posted, err
+}
+
+// GetUserAccruals returns the accrual history of a user for a year
+func (a *LeaveAccrualModel) GetUserAccruals(userID uint, year int) ([]LeaveAccrual, error) {
+	var accruals []LeaveAccrual
+	if err := a.db.Where("user_id = ? AND year = ?", userID, year).
+		Order("leave_type ASC, period ASC").
+		Find(&accruals).Error; err != nil {
+		return nil, err
+	}
+	return accruals, nil
+}
+
+// GetAccruedDays returns the total days accrued by a user for a leave type and year
+func (a *LeaveAccrualModel) GetAccruedDays(userID uint, year int, leaveType LeaveType) (float64, error) {
+	var total float64
+	if err := a.db.Model(&LeaveAccrual{}).
+		Where("user_id = ? AND year = ? AND leave_type = ?", userID, year, leaveType).
+		Select("COALESCE(SUM(days), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetAccrualSummaries returns the accrued-to-date and projected year-end figures of every accrued policy, keyed by leave type
+func (a *LeaveAccrualModel) GetAccrualSummaries(userID uint, year int) (map[LeaveType]AccrualSummary, error) {
+	summaries := make(map[LeaveType]AccrualSummary)
+
+	var user User
+	if err := a.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	policies, err := NewLeavePolicyModel(a.db).GetLeavePoliciesByYear(year)
+	if err != nil {
+		return nil, err
+	}
+
+	accruals, err := a.GetUserAccruals(userID, year)
+	if err != nil {
+		return nil, err
+	}
+	postedByType := make(map[LeaveType]map[int]float64)
+	for _, accrual := range accruals {
+		if postedByType[accrual.LeaveType] == nil {
+			postedByType[accrual.LeaveType] = make(map[int]float64)
+		}
+		postedByType[accrual.LeaveType][accrual.Period] = accrual.Days
+	}
+
+	for _, policy := range policies {
+		if !policy.IsAccrued() {
+			continue
+		}
+
+		periods := policy.AccrualPeriods()
+		summary := AccrualSummary{Frequency: policy.AccrualFrequency, PeriodsInYear: len(periods)}
+		for _, period := range periods {
+			if days, ok := postedByType[policy.LeaveType][period.Number]; ok {
+				summary.AccruedToDate += days
+				summary.ProjectedYearEnd += days
+				summary.PeriodsPosted++
+				continue
+			}
+			summary.ProjectedYearEnd += policy.PeriodCredit(period, user.TenureYears(period.End), user.EmploymentStartDate, user.EmploymentEndDate)
+		}
+		summary.AccruedToDate = roundAccrual(summary.AccruedToDate)
+		summary.ProjectedYearEnd = roundAccrual(summary.ProjectedYearEnd)
+		summaries[policy.LeaveType] = summary
+	}
+
+	return summaries, nil
+}
+
+// accruedAllocation converts fractional accrued days to the whole days a balance can be allocated
+func accruedAllocation(accrued float64) int {
+	return int(math.Floor(accrued + 1e-6))
+}
+
+// roundAccrual keeps accrual amounts to four decimals so sums stay stable
+func roundAccrual(days float64) float64 {
+	return math.Round(days*1e4) / 1e4
+}
+
+// truncateToDate drops the time of day, keeping the calendar date in UTC
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
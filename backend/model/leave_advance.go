@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// advanceLimit returns the days a policy lets users borrow against next year's allocation. A missing policy
+// lets nothing be borrowed.
+func (p *LeavePolicy) advanceLimit() int {
+	if p == nil || p.MaxAdvanceDays < 0 {
+		return 0
+	}
+	return p.MaxAdvanceDays
+}
+
+// AdvanceDays returns how many of the requested days go beyond the remaining balance and are taken in advance,
+// and whether the balance, already in deficit or not, stays within the policy's advance limit.
+func AdvanceDays(policy *LeavePolicy, remaining, days int) (int, bool) {
+	if remaining-days < -policy.advanceLimit() {
+		return 0, false
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	if days <= remaining {
+		return 0, true
+	}
+	return days - remaining, true
+}
+
+// InsufficientBalanceMessage explains why a request does not fit the balance and the advance limit
+func InsufficientBalanceMessage(policy *LeavePolicy, remaining, days int) string {
+	if limit := policy.advanceLimit(); limit > 0 {
+		return fmt.Sprintf("%d days remaining and up to %d days can be taken in advance, but %d days requested", remaining, limit, days)
+	}
+	return fmt.Sprintf("%d days remaining, but %d days requested", remaining, days)
+}
+
+// SetAdvance records the days a request takes in advance. When the policy says so, advance leave goes to
+// management after HR whatever its length.
+func (r *LeaveRequest) SetAdvance(policy *LeavePolicy, advanceDays int) {
+	r.AdvanceDays = advanceDays
+	r.ManagementApprovalRequired = advanceDays > 0 && policy != nil && policy.AdvanceNeedsManagement
+}
+
+// RequiresManagementApproval reports whether management has to approve the request after HR
+func (r *LeaveRequest) RequiresManagementApproval() bool {
+	return r.DaysRequested > managementApprovalDays || r.ManagementApprovalRequired
+}
+
+// requestAdvance checks that the balance of a request's year, with what the policy lets users take in advance,
+// still covers the request, and returns how many of its days go beyond the remaining balance. Leave types without
+// a balance always fit.
+func (l *LeaveBalanceModel) requestAdvance(request *LeaveRequest, policy *LeavePolicy) (int, bool, error) {
+	definition, err := NewLeaveTypeDefinitionModel(l.db).GetLeaveType(request.LeaveType)
+	if err != nil {
+		return 0, false, err
+	}
+	if !definition.DeductsBalance {
+		return 0, true, nil
+	}
+	balance, err := l.GetUserLeaveBalanceByType(request.UserID, request.StartDate.Year(), request.LeaveType)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	advanceDays, ok := AdvanceDays(policy, balance.RemainingDays, request.DaysRequested)
+	return advanceDays, ok, nil
+}
+
+// settleAdvance moves the deficit of last year's balance onto the new one: the old balance is brought back to
+// zero and the same days are taken off the new allocation. A balance is settled once, whether it was created by
+// the rollover or existed before.
+func (l *LeaveBalanceModel) settleAdvance(prevBalance *LeaveBalance, balance *LeaveBalance) error {
+	deficit := -prevBalance.RemainingDays
+	if deficit <= 0 {
+		return nil
+	}
+	settled, err := l.hasLedgerEntry(balance, LedgerAdvanceSettlement)
+	if err != nil || settled {
+		return err
+	}
+	if err := l.postLedgerEntries(prevBalance, newLedgerEntry(LedgerAdvanceSettlement, deficit, LedgerSource{
+		Reason: fmt.Sprintf("Advance of %d days settled from the %d allocation", deficit, balance.Year),
+	})); err != nil {
+		return err
+	}
+	return l.postLedgerEntries(balance, newLedgerEntry(LedgerAdvanceSettlement, -deficit, LedgerSource{
+		Reason: fmt.Sprintf("Advance of %d days taken in %d", deficit, prevBalance.Year),
+	}))
+}
+
+// GetBalancesInDeficit returns the balances of a year used beyond their allocation, largest deficit first
+func (l *LeaveBalanceModel) GetBalancesInDeficit(year int) ([]LeaveBalance, error) {
+	var balances []LeaveBalance
+	if err := l.db.Where("year = ? AND remaining_days < 0", year).
+		Preload("User").
+		Order("remaining_days ASC").
+		Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
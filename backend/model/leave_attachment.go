@@ -0,0 +1,101 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDocumentRequired is returned when a request is approved without the supporting document its policy asks for
+var ErrDocumentRequired = errors.New("a supporting document must be attached before this leave can be approved")
+
+// LeaveAttachment is a supporting document uploaded for a leave request, e.g. a medical certificate
+type LeaveAttachment struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	LeaveRequestID uint           `gorm:"not null;index" json:"leave_request_id"`
+	UploadedByID   uint           `gorm:"not null" json:"uploaded_by_id"`
+	FileName       string         `gorm:"not null" json:"file_name"`
+	ContentType    string         `gorm:"not null" json:"content_type"`
+	SizeBytes      int64          `gorm:"not null" json:"size_bytes"`
+	SHA256         string         `gorm:"column:sha256;not null" json:"sha256"`
+	StorageKey     string         `gorm:"not null;uniqueIndex" json:"-"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at,omitempty"`
+}
+
+// LeaveAttachmentModel handles leave attachment database operations
+type LeaveAttachmentModel struct {
+	db *gorm.DB
+}
+
+func NewLeaveAttachmentModel(db *gorm.DB) *LeaveAttachmentModel {
+	return &LeaveAttachmentModel{
+		db: db,
+	}
+}
+
+// CreateAttachment stores the metadata of an uploaded file
+func (a *LeaveAttachmentModel) CreateAttachment(attachment *LeaveAttachment) error {
+	return a.db.Create(attachment).Error
+}
+
+// GetAttachment retrieves an attachment of a leave request
+func (a *LeaveAttachmentModel) GetAttachment(leaveRequestID, id uint) (*LeaveAttachment, error) {
+	var attachment LeaveAttachment
+	if err := a.db.Where("leave_request_id = ?", leaveRequestID).First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetAttachments lists the attachments of a leave request, oldest first
+func (a *LeaveAttachmentModel) GetAttachments(leaveRequestID uint) ([]LeaveAttachment, error) {
+	var attachments []LeaveAttachment
+	if err := a.db.Where("leave_request_id = ?", leaveRequestID).
+		Order("created_at ASC").
+		Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// DeleteAttachment soft deletes an attachment; the stored file is kept for the audit trail
+func (a *LeaveAttachmentModel) DeleteAttachment(leaveRequestID, id uint) error {
+	result := a.db.Where("leave_request_id = ?", leaveRequestID).Delete(&LeaveAttachment{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CheckRequiredDocuments returns ErrDocumentRequired when the request's policy needs a document and none is attached
+func (a *LeaveAttachmentModel) CheckRequiredDocuments(request *LeaveRequest) error {
+	policy, err := NewLeavePolicyModel(a.db).GetLeavePolicyByTypeAndYear(request.LeaveType, request.StartDate.Year())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !policy.RequiresDocumentFor(request.DaysRequested) {
+		return nil
+	}
+
+	var count int64
+	if err := a.db.Model(&LeaveAttachment{}).Where("leave_request_id = ?", request.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if policy.DocumentRequiredAfterDays == 0 {
+		return fmt.Errorf("%w (required for all %s leave)", ErrDocumentRequired, request.LeaveType)
+	}
+	return fmt.Errorf("%w (required for %s leave of more than %d days)", ErrDocumentRequired,
+		request.LeaveType, policy.DocumentRequiredAfterDays)
+}
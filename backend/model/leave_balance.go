@@ -1,27 +1,31 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LeaveBalance represents a user's leave balance for a specific year and leave type
 type LeaveBalance struct {
-	ID             uint           `gorm:"primaryKey" json:"id"`
-	UserID         uint           `gorm:"not null" json:"user_id"`
-	LeaveType      LeaveType      `gorm:"not null" json:"leave_type"`
-	Year           int            `gorm:"not null" json:"year"`
-	TotalAllocated int            `gorm:"not null;default:0" json:"total_allocated"` // Total days allocated for this leave type
-	UsedDays       int            `gorm:"not null;default:0" json:"used_days"`       // Days used this year
-	RemainingDays  int            `gorm:"not null;default:0" json:"remaining_days"`  // Remaining days (calculated)
-	CarryOverDays  int            `gorm:"not null;default:0" json:"carry_over_days"` // Days carried over from previous year
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"deleted_at,omitempty"`
+	ID                uint           `gorm:"primaryKey" json:"id"`
+	UserID            uint           `gorm:"not null" json:"user_id"`
+	LeaveType         LeaveType      `gorm:"not null" json:"leave_type"`
+	Year              int            `gorm:"not null" json:"year"`
+	TotalAllocated    int            `gorm:"not null;default:0" json:"total_allocated"`      // Total days allocated for this leave type
+	UsedDays          int            `gorm:"not null;default:0" json:"used_days"`            // Days used this year
+	RemainingDays     int            `gorm:"not null;default:0" json:"remaining_days"`       // Remaining days (calculated)
+	CarryOverDays     int            `gorm:"not null;default:0" json:"carry_over_days"`      // Days carried over from previous year
+	CarryOverUsedDays int            `gorm:"not null;default:0" json:"carry_over_used_days"` // Part of the used days taken from the carried-over days
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty"`
 
 	// Relationships
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	User       User                 `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Definition *LeaveTypeDefinition `gorm:"foreignKey:LeaveType;references:Code" json:"leave_type_definition,omitempty"`
 }
 
 // LeaveBalanceModel handles leave balance database operations
@@ -35,11 +39,16 @@ func NewLeaveBalanceModel(db *gorm.DB) *LeaveBalanceModel {
 	}
 }
 
-// CreateLeaveBalance creates a new leave balance record
+// CreateLeaveBalance creates a new leave balance record, posting its starting counters to the ledger
 func (l *LeaveBalanceModel) CreateLeaveBalance(balance *LeaveBalance) error {
-	// Calculate remaining days
-	balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-	return l.db.Create(balance).Error
+	source := LedgerSource{Reason: "Balance created"}
+	allocated, carryOver, used := balance.TotalAllocated, balance.CarryOverDays, balance.UsedDays
+	balance.TotalAllocated, balance.CarryOverDays, balance.UsedDays = 0, 0, 0
+	return l.postLedgerEntries(balance,
+		newLedgerEntry(LedgerAllocation, allocated, source),
+		newLedgerEntry(LedgerCarryOver, carryOver, source),
+		newLedgerEntry(LedgerUsage, -used, source),
+	)
 }
 
 // GetUserLeaveBalance retrieves leave balance for a user and year
@@ -53,6 +62,26 @@ func (l *LeaveBalanceModel) GetUserLeaveBalance(userID uint, year int) ([]LeaveB
 	return balances, nil
 }
 
+// GetLeaveBalancesForUsers retrieves leave balances of several users for a year, keyed by user ID
+func (l *LeaveBalanceModel) GetLeaveBalancesForUsers(userIDs []uint, year int) (map[uint][]LeaveBalance, error) {
+	balancesByUser := make(map[uint][]LeaveBalance)
+	if len(userIDs) == 0 {
+		return balancesByUser, nil
+	}
+
+	var balances []LeaveBalance
+	if err := l.db.Where("user_id IN ? AND year = ?", userIDs, year).
+		Order("leave_type ASC").
+		Find(&balances).Error; err != nil {
+		return nil, err
+	}
+
+	for _, balance := range balances {
+		balancesByUser[balance.UserID] = append(balancesByUser[balance.UserID], balance)
+	}
+	return balancesByUser, nil
+}
+
 // GetUserLeaveBalanceByType retrieves leave balance for a specific user, year, and leave type
 func (l *LeaveBalanceModel) GetUserLeaveBalanceByType(userID uint, year int, leaveType LeaveType) (*LeaveBalance, error) {
 	var balance LeaveBalance
@@ -64,46 +93,100 @@ func (l *LeaveBalanceModel) GetUserLeaveBalanceByType(userID uint, year int, lea
 	return &balance, nil
 }
 
-// UpdateLeaveBalance updates a leave balance record
-func (l *LeaveBalanceModel) UpdateLeaveBalance(balance *LeaveBalance) error {
-	// Recalculate remaining days
-	balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-	return l.db.Save(balance).Error
-}
-
-// IncrementUsedDays increments the used days for a leave balance
-func (l *LeaveBalanceModel) IncrementUsedDays(userID uint, year int, leaveType LeaveType, days int) error {
-	balance, err := l.GetUserLeaveBalanceByType(userID, year, leaveType)
-	if err != nil {
-		return err
+// lockBalance loads a balance and locks its row until the surrounding transaction ends
+func (l *LeaveBalanceModel) lockBalance(userID uint, year int, leaveType LeaveType) (*LeaveBalance, error) {
+	var balance LeaveBalance
+	if err := l.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND year = ? AND leave_type = ?", userID, year, leaveType).
+		First(&balance).Error; err != nil {
+		return nil, err
 	}
+	return &balance, nil
+}
 
-	balance.UsedDays += days
-	balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
+// UpdateLeaveBalance updates a leave balance record. The difference to the stored counters is posted to
+// the ledger as adjustment entries.
+func (l *LeaveBalanceModel) UpdateLeaveBalance(balance *LeaveBalance, source LedgerSource) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		var stored LeaveBalance
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, balance.ID).Error; err != nil {
+			return err
+		}
 
-	return l.db.Save(balance).Error
+		if err := NewLeaveBalanceModel(tx).postLedgerEntries(&stored,
+			newLedgerEntry(LedgerAdjustment, balance.TotalAllocated-stored.TotalAllocated, source),
+			newLedgerEntry(LedgerCarryOver, balance.CarryOverDays-stored.CarryOverDays, source),
+			newLedgerEntry(LedgerUsage, stored.UsedDays-balance.UsedDays, source),
+		); err != nil {
+			return err
+		}
+		balance.RemainingDays = stored.RemainingDays
+		return nil
+	})
 }
 
-// DecrementUsedDays decrements the used days for a leave balance
-func (l *LeaveBalanceModel) DecrementUsedDays(userID uint, year int, leaveType LeaveType, days int) error {
-	balance, err := l.GetUserLeaveBalanceByType(userID, year, leaveType)
-	if err != nil {
-		return err
-	}
+// IncrementUsedDays records days taken by an approved leave request
+func (l *LeaveBalanceModel) IncrementUsedDays(userID uint, year int, leaveType LeaveType, days int, leaveRequestID uint) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		m := NewLeaveBalanceModel(tx)
+		balance, err := m.lockBalance(userID, year, leaveType)
+		if err != nil {
+			return err
+		}
 
-	if balance.UsedDays >= days {
-		balance.UsedDays -= days
-	} else {
-		balance.UsedDays = 0
-	}
+		// Carried-over days are used first, as long as the leave starts before they expire
+		reason := "Leave approved"
+		if available := balance.CarryOverDays - balance.CarryOverUsedDays; available > 0 {
+			var request LeaveRequest
+			if err := m.db.Select("start_date").First(&request, leaveRequestID).Error; err != nil {
+				return err
+			}
+			usable, err := m.carryOverUsableOn(balance, request.StartDate)
+			if err != nil {
+				return err
+			}
+			if usable {
+				fromCarryOver := min(days, available)
+				balance.CarryOverUsedDays += fromCarryOver
+				reason = fmt.Sprintf("Leave approved, %d carried-over days used", fromCarryOver)
+			}
+		}
+
+		return m.postLedgerEntries(balance,
+			newLedgerEntry(LedgerUsage, -days, LedgerSource{LeaveRequestID: &leaveRequestID, Reason: reason}))
+	})
+}
+
+// DecrementUsedDays gives back days of a cancelled or shortened leave request
+func (l *LeaveBalanceModel) DecrementUsedDays(userID uint, year int, leaveType LeaveType, days int, leaveRequestID uint) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		m := NewLeaveBalanceModel(tx)
+		balance, err := m.lockBalance(userID, year, leaveType)
+		if err != nil {
+			return err
+		}
 
-	balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
+		// Never give back more than was used; ordinary days are given back before carried-over ones
+		if days > balance.UsedDays {
+			days = balance.UsedDays
+		}
+		if balance.CarryOverUsedDays > balance.UsedDays-days {
+			balance.CarryOverUsedDays = balance.UsedDays - days
+		}
 
-	return l.db.Save(balance).Error
+		return m.postLedgerEntries(balance,
+			newLedgerEntry(LedgerReversal, days, LedgerSource{LeaveRequestID: &leaveRequestID, Reason: "Leave cancelled"}))
+	})
 }
 
 // InitializeUserLeaveBalances initializes leave balances for a user for a specific year
 func (l *LeaveBalanceModel) InitializeUserLeaveBalances(userID uint, year int) error {
+	// Employment dates decide the pro-rated allocation
+	var user User
+	if err := l.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+
 	// Get leave policies for the year
 	policyModel := NewLeavePolicyModel(l.db)
 	policies, err := policyModel.GetLeavePoliciesByYear(year)
@@ -121,16 +204,13 @@ func (l *LeaveBalanceModel) InitializeUserLeaveBalances(userID uint, year int) e
 		if err == gorm.ErrRecordNotFound {
 			// Create new balance
 			balance := &LeaveBalance{
-				UserID:         userID,
-				LeaveType:      policy.LeaveType,
-				Year:           year,
-				TotalAllocated: policy.DefaultAllocation,
-				UsedDays:       0,
-				CarryOverDays:  0,
+				UserID:    userID,
+				LeaveType: policy.LeaveType,
+				Year:      year,
 			}
-			balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
-
-			if err := l.db.Create(balance).Error; err != nil {
+			allocation := policy.InitialAllocation(user.EmploymentStartDate, user.EmploymentEndDate)
+			if err := l.postLedgerEntries(balance,
+				newLedgerEntry(LedgerAllocation, allocation, LedgerSource{Reason: "Policy allocation"})); err != nil {
 				return err
 			}
 		}
@@ -154,8 +234,18 @@ func (l *LeaveBalanceModel) GetLeaveBalanceSummary(userID uint, year int) (map[L
 	return summary, nil
 }
 
-// ResetLeaveBalancesForNewYear resets leave balances for a new year with carry-over rules
+// ResetLeaveBalancesForNewYear rolls a user's balances over to a new year with carry-over rules. Balances the new
+// year already has, for example from a balance lookup or an accrual run, are kept and get the carry-over added.
+// Carry-over is posted once per balance, so rolling a user over again changes nothing. All balances of the user
+// are rolled over in one transaction.
 func (l *LeaveBalanceModel) ResetLeaveBalancesForNewYear(userID uint, newYear int) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		return NewLeaveBalanceModel(tx).rollOverUser(userID, newYear)
+	})
+}
+
+// rollOverUser creates the missing balances of a new year and carries last year's remaining days over
+func (l *LeaveBalanceModel) rollOverUser(userID uint, newYear int) error {
 	// Get previous year's balances
 	prevYear := newYear - 1
 	prevBalances, err := l.GetUserLeaveBalance(userID, prevYear)
@@ -163,6 +253,11 @@ func (l *LeaveBalanceModel) ResetLeaveBalancesForNewYear(userID uint, newYear in
 		return err
 	}
 
+	var user User
+	if err := l.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+
 	// Get leave policies for the new year
 	policyModel := NewLeavePolicyModel(l.db)
 	policies, err := policyModel.GetLeavePoliciesByYear(newYear)
@@ -170,13 +265,14 @@ func (l *LeaveBalanceModel) ResetLeaveBalancesForNewYear(userID uint, newYear in
 		return err
 	}
 
-	// Create new balances with carry-over
 	for _, policy := range policies {
 		var carryOverDays int
+		var prevBalance *LeaveBalance
 
 		// Find previous year's balance for this leave type
-		for _, prevBalance := range prevBalances {
-			if prevBalance.LeaveType == policy.LeaveType {
+		for i := range prevBalances {
+			if prevBalances[i].LeaveType == policy.LeaveType {
+				prevBalance = &prevBalances[i]
 				// Apply carry-over rules
 				if policy.AllowCarryOver && prevBalance.RemainingDays > 0 {
 					if policy.MaxCarryOver > 0 && prevBalance.RemainingDays > policy.MaxCarryOver {
@@ -189,18 +285,111 @@ func (l *LeaveBalanceModel) ResetLeaveBalancesForNewYear(userID uint, newYear in
 			}
 		}
 
-		// Create new balance
-		balance := &LeaveBalance{
-			UserID:         userID,
-			LeaveType:      policy.LeaveType,
-			Year:           newYear,
-			TotalAllocated: policy.DefaultAllocation,
-			UsedDays:       0,
-			CarryOverDays:  carryOverDays,
+		balance, err := l.GetUserLeaveBalanceByType(userID, newYear, policy.LeaveType)
+		if err != nil {
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+			// Create new balance
+			balance = &LeaveBalance{
+				UserID:    userID,
+				LeaveType: policy.LeaveType,
+				Year:      newYear,
+			}
+			allocation := policy.InitialAllocation(user.EmploymentStartDate, user.EmploymentEndDate)
+			if err := l.postLedgerEntries(balance,
+				newLedgerEntry(LedgerAllocation, allocation, LedgerSource{Reason: "Policy allocation"})); err != nil {
+				return err
+			}
+		}
+
+		carriedOver, err := l.hasLedgerEntry(balance, LedgerCarryOver)
+		if err != nil {
+			return err
+		}
+		if !carriedOver {
+			if err := l.postLedgerEntries(balance,
+				newLedgerEntry(LedgerCarryOver, carryOverDays, LedgerSource{Reason: fmt.Sprintf("Carried over from %d", prevYear)}),
+			); err != nil {
+				return err
+			}
+		}
+
+		// Days taken in advance last year come off the new allocation
+		if prevBalance != nil {
+			if err := l.settleAdvance(prevBalance, balance); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RollOverToYear rolls every active user over to a new year, creating missing balances and posting carry-over
+// that is not posted yet. It is safe to run repeatedly and returns the number of users rolled over.
+func (l *LeaveBalanceModel) RollOverToYear(year int) (int, error) {
+	var userIDs []uint
+	if err := l.db.Model(&User{}).
+		Where("is_active_user = ?", true).
+		Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	for i, userID := range userIDs {
+		if err := l.ResetLeaveBalancesForNewYear(userID, year); err != nil {
+			return i, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+// RecalculateUserLeaveAllocations re-applies the pro-rated policy allocation, or the posted accruals for accrued
+// policies, to every leave balance of a user, keeping used and carried-over days. Call it after the user's
+// employment dates change.
+func (l *LeaveBalanceModel) RecalculateUserLeaveAllocations(userID uint) error {
+	var user User
+	if err := l.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	var balances []LeaveBalance
+	if err := l.db.Where("user_id = ?", userID).Find(&balances).Error; err != nil {
+		return err
+	}
+
+	policyModel := NewLeavePolicyModel(l.db)
+	for i := range balances {
+		balance := &balances[i]
+		policy, err := policyModel.GetLeavePolicyByTypeAndYear(balance.LeaveType, balance.Year)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				continue // No active policy, keep the allocation as it is
+			}
+			return err
 		}
-		balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
 
-		if err := l.db.Create(balance).Error; err != nil {
+		allocation := policy.ProRatedAllocation(user.EmploymentStartDate, user.EmploymentEndDate)
+		entryType := LedgerAdjustment
+		if policy.IsAccrued() {
+			entryType = LedgerAccrual
+			// Accrued balances hold what has been posted so far
+			accrued, err := NewLeaveAccrualModel(l.db).GetAccruedDays(userID, balance.Year, balance.LeaveType)
+			if err != nil {
+				return err
+			}
+			allocation = accruedAllocation(accrued)
+		}
+		extra, err := l.nonPolicyAllocation(userID, balance.Year, balance.LeaveType)
+		if err != nil {
+			return err
+		}
+		allocation += extra
+		if allocation == balance.TotalAllocated {
+			continue
+		}
+		if err := l.postLedgerEntries(balance,
+			newLedgerEntry(entryType, allocation-balance.TotalAllocated, LedgerSource{Reason: "Employment dates changed"})); err != nil {
 			return err
 		}
 	}
@@ -237,6 +426,10 @@ func (l *LeaveBalanceModel) GetLeaveUtilizationStats(year int) (map[LeaveType]ma
 		stats[balance.LeaveType]["total_used"] += balance.UsedDays
 		stats[balance.LeaveType]["total_remaining"] += balance.RemainingDays
 		stats[balance.LeaveType]["total_carryover"] += balance.CarryOverDays
+		if balance.RemainingDays < 0 {
+			stats[balance.LeaveType]["users_in_deficit"]++
+			stats[balance.LeaveType]["total_deficit"] -= balance.RemainingDays
+		}
 	}
 
 	return stats, nil
@@ -86,10 +86,10 @@ func (l *LeaveCalendarModel) GetCalendarEntriesForUser(userID uint, startDate, e
 }
 
 // GetCalendarEntriesForTeam retrieves calendar entries for all team members within a date range
-func (l *LeaveCalendarModel) GetCalendarEntriesForTeam(teamLeadID uint, startDate, endDate time.Time) ([]LeaveCalendarEntry, error) {
+func (l *LeaveCalendarModel) GetCalendarEntriesForTeam(teamID uint, startDate, endDate time.Time) ([]LeaveCalendarEntry, error) {
 	// Get team members first
 	userModel := NewUserModel(l.db)
-	teamMembers, err := userModel.GetUsersByTeam(teamLeadID)
+	teamMembers, err := userModel.GetUsersByTeam(teamID)
 	if err != nil {
 		return nil, err
 	}
@@ -176,17 +176,20 @@ func (l *LeaveCalendarModel) GetCalendarStats(userID uint, year int) (map[string
 	stats["total_days_on_leave"] = totalDays
 
 	// Days by leave type
-	leaveTypes := []LeaveType{LeaveTypeAnnual, LeaveTypeSick, LeaveTypePersonal, LeaveTypeEmergency, LeaveTypeMaternity, LeaveTypePaternity, LeaveTypeUnpaid}
+	definitions, err := NewLeaveTypeDefinitionModel(l.db).GetLeaveTypes(true)
+	if err != nil {
+		return nil, err
+	}
 	daysByType := make(map[LeaveType]int64)
 
-	for _, leaveType := range leaveTypes {
+	for _, definition := range definitions {
 		var count int64
 		if err := l.db.Model(&LeaveCalendarEntry{}).
-			Where("user_id = ? AND leave_type = ? AND date >= ? AND date <= ? AND status = ?", userID, leaveType, startDate, endDate, StatusApproved).
+			Where("user_id = ? AND leave_type = ? AND date >= ? AND date <= ? AND status = ?", userID, definition.Code, startDate, endDate, StatusApproved).
 			Count(&count).Error; err != nil {
 			return nil, err
 		}
-		daysByType[leaveType] = count
+		daysByType[definition.Code] = count
 	}
 	stats["days_by_type"] = daysByType
 
@@ -219,13 +222,13 @@ func (l *LeaveCalendarModel) GetCalendarStats(userID uint, year int) (map[string
 }
 
 // GetTeamCalendarStats returns calendar statistics for a team
-func (l *LeaveCalendarModel) GetTeamCalendarStats(teamLeadID uint, year int) (map[string]interface{}, error) {
+func (l *LeaveCalendarModel) GetTeamCalendarStats(teamID uint, year int) (map[string]interface{}, error) {
 	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(year, 12, 31, 23, 59, 59, 999999999, time.UTC)
 
 	// Get team members
 	userModel := NewUserModel(l.db)
-	teamMembers, err := userModel.GetUsersByTeam(teamLeadID)
+	teamMembers, err := userModel.GetUsersByTeam(teamID)
 	if err != nil {
 		return nil, err
 	}
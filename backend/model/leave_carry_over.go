@@ -0,0 +1,158 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CarryOverExpiryDate returns the last day on which days carried into the policy year can be used,
+// or nil when they never expire
+func (p *LeavePolicy) CarryOverExpiryDate() *time.Time {
+	if p.CarryOverExpiryMonth < 1 || p.CarryOverExpiryMonth > 12 || p.CarryOverExpiryDay < 1 {
+		return nil
+	}
+	// Day 31 in a 30-day month means the last day of that month
+	lastDay := time.Date(p.Year, time.Month(p.CarryOverExpiryMonth)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	day := p.CarryOverExpiryDay
+	if day > lastDay {
+		day = lastDay
+	}
+	expiry := time.Date(p.Year, time.Month(p.CarryOverExpiryMonth), day, 0, 0, 0, 0, time.UTC)
+	return &expiry
+}
+
+// carryOverUsableOn reports whether carried-over days of a balance can still be used for leave on a date
+func (l *LeaveBalanceModel) carryOverUsableOn(balance *LeaveBalance, date time.Time) (bool, error) {
+	policy, err := NewLeavePolicyModel(l.db).GetLeavePolicyByTypeAndYear(balance.LeaveType, balance.Year)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+
+	expiry := policy.CarryOverExpiryDate()
+	return expiry == nil || !truncateToDate(date).After(*expiry), nil
+}
+
+// ExpireCarryOverDays forfeits the unused carried-over days of every balance whose policy expiry date
+// has passed by asOf. Balances that have nothing left to forfeit are skipped, so it is safe to run repeatedly.
+// It returns the number of balances that lost days.
+func (l *LeaveBalanceModel) ExpireCarryOverDays(asOf time.Time) (int, error) {
+	policies, err := l.policiesWithCarryOverExpiry(asOf.Year())
+	if err != nil {
+		return 0, err
+	}
+
+	today := truncateToDate(asOf)
+	expired := 0
+	for _, policy := range policies {
+		expiry := policy.CarryOverExpiryDate()
+		if !today.After(*expiry) {
+			continue
+		}
+
+		var balances []LeaveBalance
+		if err := l.db.Where("year = ? AND leave_type = ? AND carry_over_days > carry_over_used_days", policy.Year, policy.LeaveType).
+			Find(&balances).Error; err != nil {
+			return expired, err
+		}
+
+		for i := range balances {
+			balance := &balances[i]
+			entry := newLedgerEntry(LedgerExpiry, -(balance.CarryOverDays - balance.CarryOverUsedDays),
+				LedgerSource{Reason: fmt.Sprintf("Carried-over days expired on %s", expiry.Format("2006-01-02"))})
+			entry.EffectiveDate = expiry.AddDate(0, 0, 1)
+			if err := l.postLedgerEntries(balance, entry); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// SendCarryOverExpiryNotices sends a LEAVE_EXPIRING notification to users with unused carried-over days
+// once the expiry date is within one of the policy's notice lead times. Each lead time is notified once.
+// It returns the number of notifications sent.
+func (l *LeaveBalanceModel) SendCarryOverExpiryNotices(asOf time.Time) (int, error) {
+	policies, err := l.policiesWithCarryOverExpiry(asOf.Year())
+	if err != nil {
+		return 0, err
+	}
+
+	notificationModel := NewLeaveNotificationModel(l.db)
+	today := truncateToDate(asOf)
+	sent := 0
+	for _, policy := range policies {
+		expiry := policy.CarryOverExpiryDate()
+		if today.After(*expiry) || len(policy.ExpiryNoticeDays) == 0 {
+			continue
+		}
+
+		// The nearest lead time that has been reached decides which notice is due
+		daysLeft := int(expiry.Sub(today).Hours() / 24)
+		leadTimes := append(UintArray(nil), policy.ExpiryNoticeDays...)
+		sort.Slice(leadTimes, func(i, j int) bool { return leadTimes[i] < leadTimes[j] })
+		var noticeFrom *time.Time
+		for _, lead := range leadTimes {
+			if daysLeft <= int(lead) {
+				from := expiry.AddDate(0, 0, -int(lead))
+				noticeFrom = &from
+				break
+			}
+		}
+		if noticeFrom == nil {
+			continue
+		}
+
+		var balances []LeaveBalance
+		if err := l.db.Where("year = ? AND leave_type = ? AND carry_over_days > carry_over_used_days", policy.Year, policy.LeaveType).
+			Find(&balances).Error; err != nil {
+			return sent, err
+		}
+
+		title := leaveExpiringTitle(policy.LeaveType)
+		for _, balance := range balances {
+			var count int64
+			if err := l.db.Model(&LeaveNotification{}).
+				Where("user_id = ? AND notification_type = ? AND title = ? AND created_at >= ?",
+					balance.UserID, NotificationTypeLeaveExpiring, title, *noticeFrom).
+				Count(&count).Error; err != nil {
+				return sent, err
+			}
+			if count > 0 {
+				continue
+			}
+
+			unused := balance.CarryOverDays - balance.CarryOverUsedDays
+			if err := notificationModel.CreateLeaveExpiringNotification(balance.UserID, policy.LeaveType, unused, *expiry); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// policiesWithCarryOverExpiry returns the active policies of a year whose carried-over days expire
+func (l *LeaveBalanceModel) policiesWithCarryOverExpiry(year int) ([]LeavePolicy, error) {
+	var policies []LeavePolicy
+	if err := l.db.Where("year = ? AND is_active = ? AND carry_over_expiry_month > 0", year, true).
+		Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	valid := policies[:0]
+	for _, policy := range policies {
+		if policy.CarryOverExpiryDate() != nil {
+			valid = append(valid, policy)
+		}
+	}
+	return valid, nil
+}
@@ -0,0 +1,540 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChangeApproval is the approval path a change to approved leave goes through
+type ChangeApproval string
+
+const (
+	ChangeApprovalNone     ChangeApproval = "NONE"      // Applied immediately
+	ChangeApprovalTeamLead ChangeApproval = "TEAM_LEAD" // The team lead alone
+	ChangeApprovalFull     ChangeApproval = "FULL"      // The same stages as a new request of the amended length
+)
+
+// LeaveChangeStatus represents the status of a change request
+type LeaveChangeStatus string
+
+const (
+	ChangeStatusPending   LeaveChangeStatus = "PENDING"
+	ChangeStatusApproved  LeaveChangeStatus = "APPROVED"
+	ChangeStatusRejected  LeaveChangeStatus = "REJECTED"
+	ChangeStatusWithdrawn LeaveChangeStatus = "WITHDRAWN"
+)
+
+const (
+	ApprovalEventChangeRequested ApprovalEventType = "CHANGE_REQUESTED"
+	ApprovalEventChangeApproved  ApprovalEventType = "CHANGE_APPROVED"
+	ApprovalEventChangeRejected  ApprovalEventType = "CHANGE_REJECTED"
+)
+
+var (
+	ErrInvalidLeaveChange = errors.New("invalid leave change")
+	ErrChangeNotPending   = errors.New("change request is no longer pending")
+	ErrNotChangeApprover  = errors.New("not an approver of the current stage of this change request")
+)
+
+// IsChangeEvent reports whether the event records a change request of approved leave
+func (t ApprovalEventType) IsChangeEvent() bool {
+	return t == ApprovalEventChangeRequested || t == ApprovalEventChangeApproved || t == ApprovalEventChangeRejected
+}
+
+// LeaveChangeRequest proposes new dates or a new type for approved leave. Both the original and the amended
+// version are kept, so the request's history shows what was approved before.
+type LeaveChangeRequest struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	LeaveRequestID uint              `gorm:"not null;index" json:"leave_request_id"`
+	RequestedByID  uint              `gorm:"not null" json:"requested_by_id"`
+	Status         LeaveChangeStatus `gorm:"not null;default:'PENDING';index" json:"status"`
+	Reason         string            `gorm:"type:text" json:"reason,omitempty"`
+
+	// The leave as approved before the change
+	OriginalLeaveType     LeaveType `gorm:"not null" json:"original_leave_type"`
+	OriginalStartDate     time.Time `gorm:"not null" json:"original_start_date"`
+	OriginalEndDate       time.Time `gorm:"not null" json:"original_end_date"`
+	OriginalDaysRequested int       `gorm:"not null" json:"original_days_requested"`
+
+	// The proposed leave
+	LeaveType     LeaveType `gorm:"not null" json:"leave_type"`
+	StartDate     time.Time `gorm:"not null" json:"start_date"`
+	EndDate       time.Time `gorm:"not null" json:"end_date"`
+	DaysRequested int       `gorm:"not null" json:"days_requested"`
+	AdvanceDays   int       `gorm:"not null;default:0" json:"advance_days"` // Days beyond the balance, taken in advance
+
+	// Approval path, copied from the policy of the proposed leave type
+	ApprovalStages StringList    `gorm:"type:jsonb" json:"approval_stages"`
+	CurrentStage   ApprovalStage `json:"current_stage,omitempty"` // Empty once decided
+
+	DecidedByID      *uint      `json:"decided_by_id,omitempty"`
+	DecidedAt        *time.Time `json:"decided_at,omitempty"`
+	DecisionComments string     `gorm:"type:text" json:"decision_comments,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// IsShortening reports whether the change only removes days from the original leave
+func (c *LeaveChangeRequest) IsShortening() bool {
+	return c.LeaveType == c.OriginalLeaveType &&
+		!c.StartDate.Before(c.OriginalStartDate) && !c.EndDate.After(c.OriginalEndDate)
+}
+
+// removesTakenDays reports whether the change would move, retype or drop leave days before today, which have
+// already been taken
+func (c *LeaveChangeRequest) removesTakenDays(today time.Time) bool {
+	originalStart := truncateToDate(c.OriginalStartDate)
+	if !originalStart.Before(today) {
+		return false
+	}
+	takenUntil := today.AddDate(0, 0, -1)
+	if originalEnd := truncateToDate(c.OriginalEndDate); originalEnd.Before(takenUntil) {
+		takenUntil = originalEnd
+	}
+	return c.LeaveType != c.OriginalLeaveType || !truncateToDate(c.StartDate).Equal(originalStart) ||
+		truncateToDate(c.EndDate).Before(takenUntil)
+}
+
+// describe summarises the original and the proposed leave for the timeline
+func (c *LeaveChangeRequest) describe() string {
+	return fmt.Sprintf("%s %s to %s (%d days) changed to %s %s to %s (%d days)",
+		c.OriginalLeaveType, c.OriginalStartDate.Format("2006-01-02"), c.OriginalEndDate.Format("2006-01-02"),
+		c.OriginalDaysRequested,
+		c.LeaveType, c.StartDate.Format("2006-01-02"), c.EndDate.Format("2006-01-02"), c.DaysRequested)
+}
+
+// LeaveChangeRequestModel handles change request database operations
+type LeaveChangeRequestModel struct {
+	db *gorm.DB
+}
+
+func NewLeaveChangeRequestModel(db *gorm.DB) *LeaveChangeRequestModel {
+	return &LeaveChangeRequestModel{
+		db: db,
+	}
+}
+
+// GetChangeRequest retrieves a change request of a leave request
+func (m *LeaveChangeRequestModel) GetChangeRequest(leaveRequestID, id uint) (*LeaveChangeRequest, error) {
+	var change LeaveChangeRequest
+	if err := m.db.Where("leave_request_id = ?", leaveRequestID).First(&change, id).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetChangeRequests lists the change requests of a leave request, oldest first
+func (m *LeaveChangeRequestModel) GetChangeRequests(leaveRequestID uint) ([]LeaveChangeRequest, error) {
+	var changes []LeaveChangeRequest
+	if err := m.db.Where("leave_request_id = ?", leaveRequestID).
+		Order("created_at ASC").
+		Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetPendingChangeRequestsFor lists the pending change requests a user may decide now
+func (m *LeaveChangeRequestModel) GetPendingChangeRequestsFor(userID uint) ([]LeaveChangeRequest, error) {
+	var changes []LeaveChangeRequest
+	if err := m.db.Where("status = ?", ChangeStatusPending).
+		Order("created_at ASC").
+		Find(&changes).Error; err != nil {
+		return nil, err
+	}
+
+	leaveRequestModel := NewLeaveRequestModel(m.db)
+	decidable := []LeaveChangeRequest{}
+	for i := range changes {
+		request, err := leaveRequestModel.GetLeaveRequest(changes[i].LeaveRequestID)
+		if err != nil {
+			continue
+		}
+		actorID, err := m.deciderFor(&changes[i], request, userID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID != 0 {
+			decidable = append(decidable, changes[i])
+		}
+	}
+	return decidable, nil
+}
+
+// RequestChange validates a change proposed by the requester of approved leave and stores it. When the policy
+// needs no approval the change is applied immediately.
+func (m *LeaveChangeRequestModel) RequestChange(request *LeaveRequest, change *LeaveChangeRequest) error {
+	if !request.Status.IsBalanceDeducted() {
+		return fmt.Errorf("%w: only approved leave can be changed, pending requests can be edited", ErrInvalidLeaveChange)
+	}
+
+	var pending int64
+	if err := m.db.Model(&LeaveChangeRequest{}).
+		Where("leave_request_id = ? AND status = ?", request.ID, ChangeStatusPending).
+		Count(&pending).Error; err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: a change of this leave is already waiting for approval", ErrInvalidLeaveChange)
+	}
+
+	change.LeaveRequestID = request.ID
+	change.Status = ChangeStatusPending
+	change.OriginalLeaveType = request.LeaveType
+	change.OriginalStartDate = request.StartDate
+	change.OriginalEndDate = request.EndDate
+	change.OriginalDaysRequested = request.DaysRequested
+	if err := m.validateChange(request, change); err != nil {
+		return err
+	}
+
+	policy, err := NewLeavePolicyModel(m.db).GetLeavePolicyByTypeAndYear(change.LeaveType, change.StartDate.Year())
+	if err != nil {
+		return err
+	}
+	change.ApprovalStages = changeApprovalStages(policy.ChangeApproval, change.DaysRequested,
+		change.AdvanceDays > 0 && policy.AdvanceNeedsManagement)
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if len(change.ApprovalStages) > 0 {
+			change.CurrentStage = ApprovalStage(change.ApprovalStages[0])
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&LeaveApprovalEvent{
+			LeaveRequestID: request.ID,
+			Stage:          change.CurrentStage,
+			EventType:      ApprovalEventChangeRequested,
+			ToApproverID:   &change.RequestedByID,
+			Note:           change.describe(),
+		}).Error; err != nil {
+			return err
+		}
+		if change.CurrentStage == "" {
+			change.DecisionComments = "Applied without approval"
+			return applyLeaveChange(tx, request, change, change.RequestedByID, change.RequestedByID)
+		}
+		return nil
+	})
+}
+
+// DecideChange approves or rejects the current stage of a change request. The change is applied once its last
+// stage is approved.
+func (m *LeaveChangeRequestModel) DecideChange(change *LeaveChangeRequest, approverID uint, approve bool, comments string) error {
+	if change.Status != ChangeStatusPending {
+		return ErrChangeNotPending
+	}
+
+	request, err := NewLeaveRequestModel(m.db).GetLeaveRequest(change.LeaveRequestID)
+	if err != nil {
+		return err
+	}
+	actorID, err := m.deciderFor(change, request, approverID)
+	if err != nil {
+		return err
+	}
+	if actorID == 0 {
+		return ErrNotChangeApprover
+	}
+
+	stage := change.CurrentStage
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if !approve {
+			now := time.Now()
+			if err := tx.Model(change).Updates(map[string]interface{}{
+				"status":            ChangeStatusRejected,
+				"current_stage":     "",
+				"decided_by_id":     approverID,
+				"decided_at":        now,
+				"decision_comments": comments,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&LeaveApprovalEvent{
+				LeaveRequestID: request.ID,
+				Stage:          stage,
+				EventType:      ApprovalEventChangeRejected,
+				FromApproverID: &actorID,
+				ToApproverID:   &approverID,
+				Note:           comments,
+			}).Error
+		}
+
+		if next := nextChangeStage(change); next != "" {
+			if err := tx.Model(change).Update("current_stage", next).Error; err != nil {
+				return err
+			}
+			return tx.Create(&LeaveApprovalEvent{
+				LeaveRequestID: request.ID,
+				Stage:          stage,
+				EventType:      ApprovalEventChangeApproved,
+				FromApproverID: &actorID,
+				ToApproverID:   &approverID,
+				Note:           strings.TrimSpace(fmt.Sprintf("Stage approved, waiting for %s. %s", next, comments)),
+			}).Error
+		}
+
+		change.DecisionComments = comments
+		return applyLeaveChange(tx, request, change, actorID, approverID)
+	})
+}
+
+// WithdrawChange lets the requester take back a pending change request
+func (m *LeaveChangeRequestModel) WithdrawChange(change *LeaveChangeRequest) error {
+	result := m.db.Model(&LeaveChangeRequest{}).
+		Where("id = ? AND status = ?", change.ID, ChangeStatusPending).
+		Updates(map[string]interface{}{"status": ChangeStatusWithdrawn, "current_stage": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrChangeNotPending
+	}
+	return nil
+}
+
+// validateChange checks the proposed leave the way a new request would be checked. Shortening only needs the
+// dates to be valid and to keep the days already taken, so it is possible even when the notice period has passed.
+func (m *LeaveChangeRequestModel) validateChange(request *LeaveRequest, change *LeaveChangeRequest) error {
+	if change.EndDate.Before(change.StartDate) {
+		return fmt.Errorf("%w: end date must not be before start date", ErrInvalidLeaveChange)
+	}
+	change.DaysRequested = int(change.EndDate.Sub(change.StartDate).Hours()/24) + 1
+	if change.LeaveType == change.OriginalLeaveType && change.StartDate.Equal(change.OriginalStartDate) &&
+		change.EndDate.Equal(change.OriginalEndDate) {
+		return fmt.Errorf("%w: the change does not differ from the approved leave", ErrInvalidLeaveChange)
+	}
+	// Days before today have been taken: they stay as approved and are never refunded
+	if change.removesTakenDays(truncateToDate(time.Now())) {
+		return fmt.Errorf("%w: days before today have been taken and cannot be changed or refunded", ErrInvalidLeaveChange)
+	}
+	if change.IsShortening() {
+		return m.checkBalance(request, change)
+	}
+
+	if change.StartDate.Before(time.Now().Truncate(24*time.Hour)) && !change.StartDate.Equal(change.OriginalStartDate) {
+		return fmt.Errorf("%w: start date cannot be moved into the past", ErrInvalidLeaveChange)
+	}
+	if _, err := NewLeaveTypeDefinitionModel(m.db).GetActiveLeaveType(change.LeaveType); err != nil {
+		return err
+	}
+
+	hasOverlap, err := NewLeaveRequestModel(m.db).CheckLeaveOverlap(request.UserID, change.StartDate, change.EndDate, &request.ID)
+	if err != nil {
+		return err
+	}
+	if hasOverlap {
+		return fmt.Errorf("%w: the new dates overlap with other approved or pending leave", ErrInvalidLeaveChange)
+	}
+
+	proposed := *request
+	proposed.LeaveType = change.LeaveType
+	proposed.StartDate = change.StartDate
+	proposed.EndDate = change.EndDate
+	proposed.DaysRequested = change.DaysRequested
+	proposed.SubmittedByID = nil // The requester changes the leave, so the usual notice applies
+	if err := NewLeavePolicyModel(m.db).ValidateLeaveRequestAgainstPolicy(&proposed); err != nil {
+		return err
+	}
+
+	return m.checkBalance(request, change)
+}
+
+// checkBalance makes sure the balance, with what the policy lets users take in advance, covers the days the
+// change adds, and records how many days of the changed leave are taken in advance
+func (m *LeaveChangeRequestModel) checkBalance(request *LeaveRequest, change *LeaveChangeRequest) error {
+	definition, err := NewLeaveTypeDefinitionModel(m.db).GetLeaveType(change.LeaveType)
+	if err != nil || !definition.DeductsBalance {
+		return err
+	}
+
+	sameBalance := change.LeaveType == change.OriginalLeaveType && change.StartDate.Year() == change.OriginalStartDate.Year()
+	needed := change.DaysRequested
+	if sameBalance {
+		needed -= change.OriginalDaysRequested
+	}
+	if needed <= 0 {
+		// Shortened leave gives back the advance days first
+		change.AdvanceDays = request.AdvanceDays + needed
+		if change.AdvanceDays < 0 {
+			change.AdvanceDays = 0
+		}
+		return nil
+	}
+
+	balance, err := NewLeaveBalanceModel(m.db).GetUserLeaveBalanceByType(request.UserID, change.StartDate.Year(), change.LeaveType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: no %s balance for %d", ErrInvalidLeaveChange, change.LeaveType, change.StartDate.Year())
+		}
+		return err
+	}
+	policy, err := NewLeavePolicyModel(m.db).GetLeavePolicyByTypeAndYear(change.LeaveType, change.StartDate.Year())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	// The days of the leave as approved are used from this balance and come back with the change
+	available := balance.RemainingDays
+	if sameBalance {
+		available += change.OriginalDaysRequested
+	}
+	advance, ok := AdvanceDays(policy, available, change.DaysRequested)
+	if !ok {
+		return fmt.Errorf("%w: the change needs %d more days, %s", ErrInvalidLeaveChange, needed,
+			InsufficientBalanceMessage(policy, balance.RemainingDays, needed))
+	}
+	change.AdvanceDays = advance
+	return nil
+}
+
+// deciderFor returns the approver in whose right a user decides the current stage of a change: the user
+// themselves, or the approver they stand in for through a delegation. It returns 0 if the user cannot decide.
+func (m *LeaveChangeRequestModel) deciderFor(change *LeaveChangeRequest, request *LeaveRequest, userID uint) (uint, error) {
+	if change.Status != ChangeStatusPending || change.CurrentStage == "" || request.UserID == userID {
+		return 0, nil
+	}
+
+	canDecide, err := m.canDecideStage(change.CurrentStage, request, userID)
+	if err != nil || canDecide {
+		if canDecide {
+			return userID, nil
+		}
+		return 0, err
+	}
+
+	delegations, err := NewApprovalDelegationModel(m.db).GetActiveDelegationsTo(userID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, delegation := range delegations {
+		if !delegation.LeaveTypes.Contains(request.LeaveType) || delegation.DelegatorID == request.UserID {
+			continue
+		}
+		canDecide, err := m.canDecideStage(change.CurrentStage, request, delegation.DelegatorID)
+		if err != nil {
+			return 0, err
+		}
+		if canDecide {
+			return delegation.DelegatorID, nil
+		}
+	}
+	return 0, nil
+}
+
+// canDecideStage reports whether a user approves a workflow stage of a request in their own right
+func (m *LeaveChangeRequestModel) canDecideStage(stage ApprovalStage, request *LeaveRequest, userID uint) (bool, error) {
+	if stage == StageTeamLead {
+		return request.TeamLeadID != nil && *request.TeamLeadID == userID, nil
+	}
+	return NewUserModel(m.db).HasUserPermission(userID, stageApprovalPermission(stage))
+}
+
+// changeApprovalStages returns the stages a change has to pass. Management approves last when the changed
+// leave is taken in advance and the policy asks for it.
+func changeApprovalStages(approval ChangeApproval, days int, advanceNeedsManagement bool) StringList {
+	var stages StringList
+	switch approval {
+	case ChangeApprovalNone:
+		stages = StringList{}
+	case ChangeApprovalFull:
+		stages = StringList{string(StageTeamLead), string(StageHR)}
+		if days > managementApprovalDays {
+			stages = append(stages, string(StageManagement))
+		}
+	default:
+		stages = StringList{string(StageTeamLead)}
+	}
+	if advanceNeedsManagement && (len(stages) == 0 || stages[len(stages)-1] != string(StageManagement)) {
+		stages = append(stages, string(StageManagement))
+	}
+	return stages
+}
+
+// nextChangeStage returns the stage after the current one, or "" when the current stage is the last
+func nextChangeStage(change *LeaveChangeRequest) ApprovalStage {
+	for i, stage := range change.ApprovalStages {
+		if ApprovalStage(stage) == change.CurrentStage && i+1 < len(change.ApprovalStages) {
+			return ApprovalStage(change.ApprovalStages[i+1])
+		}
+	}
+	return ""
+}
+
+// applyLeaveChange moves the leave to the proposed dates and type: the used days of the original leave are given
+// back, the amended leave is booked and its calendar entries are replaced. The decider approved the last stage,
+// possibly on behalf of the actor.
+func applyLeaveChange(tx *gorm.DB, request *LeaveRequest, change *LeaveChangeRequest, actorID, deciderID uint) error {
+	stage := change.CurrentStage
+
+	// Lock the request so that a concurrent cancellation or change sees the outcome
+	var current LeaveRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, request.ID).Error; err != nil {
+		return err
+	}
+	if !current.Status.IsBalanceDeducted() || current.LeaveType != change.OriginalLeaveType ||
+		!current.StartDate.Equal(change.OriginalStartDate) || !current.EndDate.Equal(change.OriginalEndDate) {
+		return fmt.Errorf("%w: the leave was changed or cancelled after the change was requested", ErrInvalidLeaveChange)
+	}
+
+	balanceModel := NewLeaveBalanceModel(tx)
+	if err := balanceModel.DecrementUsedDays(current.UserID, current.StartDate.Year(), current.LeaveType,
+		current.DaysRequested, current.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&LeaveRequest{}).Where("id = ?", current.ID).Updates(map[string]interface{}{
+		"leave_type":     change.LeaveType,
+		"start_date":     change.StartDate,
+		"end_date":       change.EndDate,
+		"days_requested": change.DaysRequested,
+		"advance_days":   change.AdvanceDays,
+	}).Error; err != nil {
+		return err
+	}
+	current.LeaveType = change.LeaveType
+	current.StartDate = change.StartDate
+	current.EndDate = change.EndDate
+	current.DaysRequested = change.DaysRequested
+	current.AdvanceDays = change.AdvanceDays
+
+	year := change.StartDate.Year()
+	if _, err := balanceModel.GetUserLeaveBalanceByType(current.UserID, year, change.LeaveType); errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := balanceModel.InitializeUserLeaveBalances(current.UserID, year); err != nil {
+			return err
+		}
+	}
+	if err := balanceModel.IncrementUsedDays(current.UserID, year, change.LeaveType, change.DaysRequested, current.ID); err != nil {
+		return err
+	}
+
+	if err := NewLeaveCalendarModel(tx).CreateCalendarEntriesForLeaveRequest(&current); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(change).Updates(map[string]interface{}{
+		"status":            ChangeStatusApproved,
+		"current_stage":     "",
+		"decided_by_id":     deciderID,
+		"decided_at":        now,
+		"decision_comments": change.DecisionComments,
+	}).Error; err != nil {
+		return err
+	}
+
+	*request = current
+	return tx.Create(&LeaveApprovalEvent{
+		LeaveRequestID: current.ID,
+		Stage:          stage,
+		EventType:      ApprovalEventChangeApproved,
+		FromApproverID: &actorID,
+		ToApproverID:   &deciderID,
+		Note:           strings.TrimSpace(fmt.Sprintf("%s. %s", change.describe(), change.DecisionComments)),
+	}).Error
+}
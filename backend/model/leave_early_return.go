@@ -0,0 +1,145 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ApprovalEventReturnedEarly records that approved leave was cut short at a return date
+const ApprovalEventReturnedEarly ApprovalEventType = "RETURNED_EARLY"
+
+// ErrInvalidEarlyReturn is returned when leave cannot be cut short at the given date
+var ErrInvalidEarlyReturn = errors.New("invalid early return")
+
+// ReturnEarly cuts approved leave short: the leave ends the day before the return date, the calendar entries
+// from the return date on are marked cancelled and their days are given back to the balance. Days before today
+// have been taken and are never given back. The approvers of the request are notified.
+func (l *LeaveRequestModel) ReturnEarly(requestID, userID uint, returnDate time.Time, reason string) (*LeaveRequest, int, error) {
+	returnDate = truncateToDate(returnDate)
+	if returnDate.Before(truncateToDate(time.Now())) {
+		return nil, 0, fmt.Errorf("%w: the return date cannot be in the past, days already taken are not refunded", ErrInvalidEarlyReturn)
+	}
+
+	var request LeaveRequest
+	var cancelledDays int
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the request so that a concurrent change or second early return sees the outcome
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error; err != nil {
+			return err
+		}
+		if request.UserID != userID {
+			return fmt.Errorf("%w: only the requester can return early from their leave", ErrInvalidEarlyReturn)
+		}
+		if !request.Status.IsBalanceDeducted() {
+			return fmt.Errorf("%w: only approved leave can be cut short, pending requests can be edited or cancelled", ErrInvalidEarlyReturn)
+		}
+		startDate := truncateToDate(request.StartDate)
+		if !returnDate.After(startDate) || returnDate.After(truncateToDate(request.EndDate)) {
+			return fmt.Errorf("%w: the return date must be after the first day and no later than the last day of the leave", ErrInvalidEarlyReturn)
+		}
+
+		newEndDate := returnDate.AddDate(0, 0, -1)
+		newDays := int(newEndDate.Sub(startDate).Hours()/24) + 1
+		cancelledDays = request.DaysRequested - newDays
+		if cancelledDays <= 0 {
+			return fmt.Errorf("%w: no days are left to cancel after %s", ErrInvalidEarlyReturn, newEndDate.Format("2006-01-02"))
+		}
+
+		updates := map[string]interface{}{
+			"end_date":       newEndDate,
+			"days_requested": newDays,
+			"cancelled_days": request.CancelledDays + cancelledDays,
+		}
+		if request.OriginalEndDate == nil {
+			originalEndDate := request.EndDate
+			updates["original_end_date"] = originalEndDate
+			request.OriginalEndDate = &originalEndDate
+		}
+		if err := tx.Model(&LeaveRequest{}).Where("id = ?", request.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		request.EndDate = newEndDate
+		request.DaysRequested = newDays
+		request.CancelledDays += cancelledDays
+
+		if err := tx.Model(&LeaveCalendarEntry{}).
+			Where("leave_request_id = ? AND date >= ?", request.ID, returnDate).
+			Update("status", StatusCancelled).Error; err != nil {
+			return err
+		}
+
+		if err := NewLeaveBalanceModel(tx).DecrementUsedDays(request.UserID, request.StartDate.Year(), request.LeaveType,
+			cancelledDays, request.ID); err != nil {
+			return err
+		}
+
+		// A standing delegation activated by the leave ends with it
+		if err := tx.Model(&ApprovalDelegation{}).
+			Where("source_leave_request_id = ? AND end_date > ?", request.ID, newEndDate).
+			Update("end_date", newEndDate).Error; err != nil {
+			return err
+		}
+
+		note := fmt.Sprintf("Returned on %s, %d days cancelled and refunded", returnDate.Format("2006-01-02"), cancelledDays)
+		if reason != "" {
+			note = fmt.Sprintf("%s. %s", note, reason)
+		}
+		if err := tx.Create(&LeaveApprovalEvent{
+			LeaveRequestID: request.ID,
+			EventType:      ApprovalEventReturnedEarly,
+			ToApproverID:   &userID,
+			Note:           note,
+		}).Error; err != nil {
+			return err
+		}
+
+		return notifyEarlyReturn(tx, &request, returnDate, cancelledDays)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &request, cancelledDays, nil
+}
+
+// notifyEarlyReturn tells everyone who approved the request that the leave was cut short: the team lead, and
+// the HR and management approvers when those stages approved it
+func notifyEarlyReturn(tx *gorm.DB, request *LeaveRequest, returnDate time.Time, cancelledDays int) error {
+	recipients := map[uint]bool{}
+	if request.TeamLeadID != nil {
+		recipients[*request.TeamLeadID] = true
+	}
+
+	userModel := NewUserModel(tx)
+	for stage, approvedAt := range map[ApprovalStage]*time.Time{
+		StageHR:         request.HRApprovedAt,
+		StageManagement: request.ManagementApprovedAt,
+	} {
+		if approvedAt == nil {
+			continue
+		}
+		approvers, err := userModel.GetUserIDsWithPermission(stageApprovalPermission(stage))
+		if err != nil {
+			return err
+		}
+		for _, approverID := range approvers {
+			recipients[approverID] = true
+		}
+	}
+	delete(recipients, request.UserID)
+
+	message := fmt.Sprintf("Leave request #%d (%s) now ends on %s; the employee returns on %s and %d days were cancelled.",
+		request.ID, strings.ToLower(string(request.LeaveType)), request.EndDate.Format("2006-01-02"),
+		returnDate.Format("2006-01-02"), cancelledDays)
+	notificationModel := NewLeaveNotificationModel(tx)
+	for recipientID := range recipients {
+		if err := notificationModel.CreateEarlyReturnNotification(request, recipientID, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotEligible is returned when a user does not meet the eligibility rules of a leave policy
+var ErrNotEligible = errors.New("not eligible for this leave type")
+
+// StringList represents a list of strings for JSON storage
+type StringList []string
+
+// Value implements the driver.Valuer interface
+func (l StringList) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return gorm.ErrInvalidData
+	}
+
+	return json.Unmarshal(bytes, l)
+}
+
+// Allows reports whether the list contains a value; an empty list allows every value
+func (l StringList) Allows(value string) bool {
+	if len(l) == 0 {
+		return true
+	}
+	for _, v := range l {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// LeaveEligibility tells whether a user can currently request a leave type, and why not
+type LeaveEligibility struct {
+	LeaveType   LeaveType `json:"leave_type"`
+	DisplayName string    `json:"display_name"`
+	Eligible    bool      `json:"eligible"`
+	Reasons     []string  `json:"reasons,omitempty"`
+}
+
+// EligibilityReasons returns why a user cannot take leave under the policy starting on a date, nothing when they can
+func (p *LeavePolicy) EligibilityReasons(user *User, on time.Time) []string {
+	var reasons []string
+
+	if p.MinTenureMonths > 0 {
+		if user.EmploymentStartDate == nil {
+			reasons = append(reasons, fmt.Sprintf("requires %d months of employment and no employment start date is recorded",
+				p.MinTenureMonths))
+		} else if eligibleFrom := user.EmploymentStartDate.AddDate(0, p.MinTenureMonths, 0); on.Before(eligibleFrom) {
+			reasons = append(reasons, fmt.Sprintf("requires %d months of employment, available from %s",
+				p.MinTenureMonths, eligibleFrom.Format("2006-01-02")))
+		}
+	}
+
+	if p.NotDuringProbation && user.ProbationEndDate != nil && !on.After(*user.ProbationEndDate) {
+		reasons = append(reasons, fmt.Sprintf("not available during probation, which ends on %s",
+			user.ProbationEndDate.Format("2006-01-02")))
+	}
+
+	if !p.EligibleEmploymentTypes.Allows(string(user.EmploymentType)) {
+		reasons = append(reasons, fmt.Sprintf("only available to %s employees",
+			strings.Join(p.EligibleEmploymentTypes, ", ")))
+	}
+
+	if !p.EligibleGenders.Allows(string(user.Gender)) {
+		reasons = append(reasons, fmt.Sprintf("only available to employees whose recorded gender is %s",
+			strings.Join(p.EligibleGenders, " or ")))
+	}
+
+	return reasons
+}
+
+// CheckEligibility returns an ErrNotEligible error listing every rule of the policy a user breaks for leave
+// starting on a date
+func (l *LeavePolicyModel) CheckEligibility(policy *LeavePolicy, userID uint, on time.Time) error {
+	var user User
+	if err := l.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	reasons := policy.EligibilityReasons(&user, on)
+	if len(reasons) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrNotEligible, strings.Join(reasons, "; "))
+}
+
+// GetLeaveEligibility evaluates every active leave type for a user and leave starting on a date
+func (l *LeavePolicyModel) GetLeaveEligibility(userID uint, on time.Time) ([]LeaveEligibility, error) {
+	var user User
+	if err := l.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	definitions, err := NewLeaveTypeDefinitionModel(l.db).GetLeaveTypes(false)
+	if err != nil {
+		return nil, err
+	}
+
+	eligibility := make([]LeaveEligibility, 0, len(definitions))
+	for _, definition := range definitions {
+		result := LeaveEligibility{
+			LeaveType:   definition.Code,
+			DisplayName: definition.DisplayName,
+		}
+
+		policy, err := l.GetLeavePolicyByTypeAndYear(definition.Code, on.Year())
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			result.Reasons = []string{fmt.Sprintf("no active policy for %d", on.Year())}
+		case err != nil:
+			return nil, err
+		default:
+			result.Reasons = policy.EligibilityReasons(&user, on)
+		}
+
+		result.Eligible = len(result.Reasons) == 0
+		eligibility = append(eligibility, result)
+	}
+	return eligibility, nil
+}
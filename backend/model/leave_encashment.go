@@ -0,0 +1,284 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EncashmentStatus represents the status of a leave encashment request
+type EncashmentStatus string
+
+const (
+	EncashmentStatusPending   EncashmentStatus = "PENDING"
+	EncashmentStatusApproved  EncashmentStatus = "APPROVED"
+	EncashmentStatusRejected  EncashmentStatus = "REJECTED"
+	EncashmentStatusWithdrawn EncashmentStatus = "WITHDRAWN"
+)
+
+var (
+	ErrInvalidEncashment    = errors.New("invalid leave encashment")
+	ErrEncashmentNotPending = errors.New("leave encashment is no longer pending")
+)
+
+// LeaveEncashment asks for unused leave days to be paid out instead of taken. The amount is fixed when it is
+// approved, from the user's salary at that time, and paid in the payroll period of the approval.
+type LeaveEncashment struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	UserID        uint             `gorm:"not null;index" json:"user_id"`
+	LeaveType     LeaveType        `gorm:"not null" json:"leave_type"`
+	Year          int              `gorm:"not null" json:"year"` // Year of the balance the days come from
+	Days          int              `gorm:"not null" json:"days"`
+	Reason        string           `gorm:"type:text" json:"reason,omitempty"`
+	Status        EncashmentStatus `gorm:"not null;default:'PENDING';index" json:"status"`
+	Rate          float64          `gorm:"not null;default:0" json:"rate"`       // Share of the daily salary paid per day, from the policy
+	DailyRate     float64          `gorm:"not null;default:0" json:"daily_rate"` // Daily salary at approval
+	Amount        float64          `gorm:"not null;default:0" json:"amount"`
+	Currency      string           `json:"currency,omitempty"`
+	PayrollPeriod string           `gorm:"index" json:"payroll_period,omitempty"` // YYYY-MM in which it is paid
+	DecidedByID   *uint            `json:"decided_by_id,omitempty"`
+	DecidedAt     *time.Time       `json:"decided_at,omitempty"`
+	Comments      string           `gorm:"type:text" json:"comments,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// LeaveEncashmentModel handles leave encashment database operations
+type LeaveEncashmentModel struct {
+	db *gorm.DB
+}
+
+func NewLeaveEncashmentModel(db *gorm.DB) *LeaveEncashmentModel {
+	return &LeaveEncashmentModel{
+		db: db,
+	}
+}
+
+// GetEncashment retrieves an encashment request by ID
+func (m *LeaveEncashmentModel) GetEncashment(id uint) (*LeaveEncashment, error) {
+	var encashment LeaveEncashment
+	if err := m.db.Preload("User").First(&encashment, id).Error; err != nil {
+		return nil, err
+	}
+	return &encashment, nil
+}
+
+// GetUserEncashments lists the encashment requests of a user, newest first
+func (m *LeaveEncashmentModel) GetUserEncashments(userID uint) ([]LeaveEncashment, error) {
+	var encashments []LeaveEncashment
+	if err := m.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&encashments).Error; err != nil {
+		return nil, err
+	}
+	return encashments, nil
+}
+
+// GetPendingEncashments lists the encashment requests waiting for a decision, oldest first
+func (m *LeaveEncashmentModel) GetPendingEncashments() ([]LeaveEncashment, error) {
+	var encashments []LeaveEncashment
+	if err := m.db.Where("status = ?", EncashmentStatusPending).
+		Preload("User").
+		Order("created_at ASC").
+		Find(&encashments).Error; err != nil {
+		return nil, err
+	}
+	return encashments, nil
+}
+
+// SubmitEncashment validates a request against the policy limits and stores it for approval. Payroll
+// approvers are notified.
+func (m *LeaveEncashmentModel) SubmitEncashment(encashment *LeaveEncashment) error {
+	if _, err := m.checkLimits(m.db, encashment, 0); err != nil {
+		return err
+	}
+	encashment.Status = EncashmentStatusPending
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(encashment).Error; err != nil {
+			return err
+		}
+
+		approvers, err := NewUserModel(tx).GetUserIDsWithPermission("MANAGE_PAYROLL")
+		if err != nil {
+			return err
+		}
+		notificationModel := NewLeaveNotificationModel(tx)
+		for _, approverID := range approvers {
+			if approverID == encashment.UserID {
+				continue
+			}
+			if err := notificationModel.CreateEncashmentRequestNotification(encashment, approverID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DecideEncashment approves or rejects a pending request. Approval checks the limits again, takes the days off
+// the balance and fixes the amount from the user's current salary and the policy rate.
+func (m *LeaveEncashmentModel) DecideEncashment(id, approverID uint, approve bool, comments string, now time.Time) (*LeaveEncashment, error) {
+	var encashment LeaveEncashment
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the request so that it cannot be paid out twice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&encashment, id).Error; err != nil {
+			return err
+		}
+		if encashment.Status != EncashmentStatusPending {
+			return ErrEncashmentNotPending
+		}
+		if encashment.UserID == approverID {
+			return fmt.Errorf("%w: you cannot decide your own encashment", ErrInvalidEncashment)
+		}
+
+		updates := map[string]interface{}{
+			"status":        EncashmentStatusRejected,
+			"decided_by_id": approverID,
+			"decided_at":    now,
+			"comments":      strings.TrimSpace(comments),
+		}
+		if approve {
+			policy, err := m.checkLimits(tx, &encashment, encashment.ID)
+			if err != nil {
+				return err
+			}
+
+			var user User
+			if err := tx.First(&user, encashment.UserID).Error; err != nil {
+				return err
+			}
+			dailyRate := DailySalaryRate(&user)
+			amount := roundMoney(float64(encashment.Days) * dailyRate * policy.EncashmentRate)
+
+			balanceModel := NewLeaveBalanceModel(tx)
+			balance, err := balanceModel.GetUserLeaveBalanceByType(encashment.UserID, encashment.Year, encashment.LeaveType)
+			if err != nil {
+				return err
+			}
+			if err := balanceModel.postLedgerEntries(balance, newLedgerEntry(LedgerEncashment, -encashment.Days, LedgerSource{
+				ActorID: &approverID,
+				Reason:  fmt.Sprintf("Leave encashment #%d paid in %s", encashment.ID, PayrollPeriodOf(now)),
+			})); err != nil {
+				return err
+			}
+
+			encashment.Rate = policy.EncashmentRate
+			encashment.DailyRate = dailyRate
+			encashment.Amount = amount
+			encashment.Currency = user.SalaryCurrency
+			encashment.PayrollPeriod = PayrollPeriodOf(now)
+			updates["status"] = EncashmentStatusApproved
+			updates["rate"] = encashment.Rate
+			updates["daily_rate"] = encashment.DailyRate
+			updates["amount"] = encashment.Amount
+			updates["currency"] = encashment.Currency
+			updates["payroll_period"] = encashment.PayrollPeriod
+		}
+		if err := tx.Model(&LeaveEncashment{}).Where("id = ?", encashment.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		encashment.Status = updates["status"].(EncashmentStatus)
+		encashment.DecidedByID = &approverID
+		encashment.DecidedAt = &now
+		encashment.Comments = updates["comments"].(string)
+
+		return NewLeaveNotificationModel(tx).CreateEncashmentDecisionNotification(&encashment, approve)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &encashment, nil
+}
+
+// WithdrawEncashment lets the user take back a pending request
+func (m *LeaveEncashmentModel) WithdrawEncashment(id, userID uint) error {
+	result := m.db.Model(&LeaveEncashment{}).
+		Where("id = ? AND user_id = ? AND status = ?", id, userID, EncashmentStatusPending).
+		Update("status", EncashmentStatusWithdrawn)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEncashmentNotPending
+	}
+	return nil
+}
+
+// checkLimits checks a request against the policy of its leave type and year: encashment must be allowed,
+// pending and approved requests of the year stay within MaxEncashmentDays, and MinBalanceAfterEncashment days
+// remain on the balance. The request itself, given by excludeID once stored, is not counted twice.
+func (m *LeaveEncashmentModel) checkLimits(db *gorm.DB, encashment *LeaveEncashment, excludeID uint) (*LeavePolicy, error) {
+	if encashment.Days <= 0 {
+		return nil, fmt.Errorf("%w: days must be at least 1", ErrInvalidEncashment)
+	}
+
+	policy, err := NewLeavePolicyModel(db).GetLeavePolicyByTypeAndYear(encashment.LeaveType, encashment.Year)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: there is no active %s policy for %d", ErrInvalidEncashment, encashment.LeaveType, encashment.Year)
+		}
+		return nil, err
+	}
+	if !policy.AllowEncashment {
+		return nil, fmt.Errorf("%w: %s leave cannot be encashed", ErrInvalidEncashment, encashment.LeaveType)
+	}
+
+	var reserved int64
+	if err := db.Model(&LeaveEncashment{}).
+		Where("user_id = ? AND leave_type = ? AND year = ? AND id <> ?", encashment.UserID, encashment.LeaveType, encashment.Year, excludeID).
+		Where("status IN ?", []EncashmentStatus{EncashmentStatusPending, EncashmentStatusApproved}).
+		Select("COALESCE(SUM(days), 0)").
+		Scan(&reserved).Error; err != nil {
+		return nil, err
+	}
+	if policy.MaxEncashmentDays > 0 && int(reserved)+encashment.Days > policy.MaxEncashmentDays {
+		return nil, fmt.Errorf("%w: at most %d days of %s leave can be encashed for %d, %d are already requested or paid",
+			ErrInvalidEncashment, policy.MaxEncashmentDays, encashment.LeaveType, encashment.Year, reserved)
+	}
+
+	balance, err := NewLeaveBalanceModel(db).GetUserLeaveBalanceByType(encashment.UserID, encashment.Year, encashment.LeaveType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: no %s balance for %d", ErrInvalidEncashment, encashment.LeaveType, encashment.Year)
+		}
+		return nil, err
+	}
+	// Pending requests are not off the balance yet
+	pending := int64(0)
+	if err := db.Model(&LeaveEncashment{}).
+		Where("user_id = ? AND leave_type = ? AND year = ? AND status = ? AND id <> ?",
+			encashment.UserID, encashment.LeaveType, encashment.Year, EncashmentStatusPending, excludeID).
+		Select("COALESCE(SUM(days), 0)").
+		Scan(&pending).Error; err != nil {
+		return nil, err
+	}
+	available := balance.RemainingDays - int(pending) - policy.MinBalanceAfterEncashment
+	if encashment.Days > available {
+		if available < 0 {
+			available = 0
+		}
+		return nil, fmt.Errorf("%w: %d days can be encashed, %d days must stay on the balance",
+			ErrInvalidEncashment, available, policy.MinBalanceAfterEncashment)
+	}
+	return policy, nil
+}
+
+// DailySalaryRate returns a user's salary for one day of leave. Salaries are yearly and leave days are
+// calendar days, so a day is 1/365 of the salary.
+func DailySalaryRate(user *User) float64 {
+	return roundMoney(user.Salary / 365)
+}
+
+// roundMoney rounds an amount to cents
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
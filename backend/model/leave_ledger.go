@@ -0,0 +1,371 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LedgerEntryType represents the kind of change a ledger entry records
+type LedgerEntryType string
+
+const (
+	LedgerAllocation LedgerEntryType = "ALLOCATION" // Policy allocation granted for the year
+	LedgerAccrual    LedgerEntryType = "ACCRUAL"    // Days earned by periodic accrual
+	LedgerCarryOver  LedgerEntryType = "CARRY_OVER" // Days carried over from the previous year
+	LedgerUsage      LedgerEntryType = "USAGE"      // Days taken by an approved leave request
+	LedgerReversal   LedgerEntryType = "REVERSAL"   // Used days given back by a cancelled or shortened request
+	LedgerAdjustment LedgerEntryType = "ADJUSTMENT" // Manual or rule-driven change of the allocation
+	LedgerExpiry     LedgerEntryType = "EXPIRY"     // Carried-over days forfeited after they expired
+
+	LedgerCompOffCredit LedgerEntryType = "COMP_OFF_CREDIT" // Days earned by an approved comp-off claim
+	LedgerCompOffExpiry LedgerEntryType = "COMP_OFF_EXPIRY" // Unused comp-off days forfeited after the claim expired
+	LedgerEncashment    LedgerEntryType = "ENCASHMENT"      // Unused days paid out by an approved encashment
+
+	LedgerAdvanceSettlement LedgerEntryType = "ADVANCE_SETTLEMENT" // Days taken in advance, moved from last year's deficit onto this year's allocation
+)
+
+// ErrLedgerEntryImmutable is returned when a ledger entry is about to be updated or deleted
+var ErrLedgerEntryImmutable = errors.New("leave ledger entries are immutable")
+
+// LeaveLedgerEntry is one immutable change to a leave balance. Days is the signed effect on the remaining
+// balance, so the remaining days of a balance are the sum of its entries.
+type LeaveLedgerEntry struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	UserID         uint            `gorm:"not null;index:idx_leave_ledger_balance" json:"user_id"`
+	LeaveType      LeaveType       `gorm:"not null;index:idx_leave_ledger_balance" json:"leave_type"`
+	Year           int             `gorm:"not null;index:idx_leave_ledger_balance" json:"year"`
+	EntryType      LedgerEntryType `gorm:"not null" json:"entry_type"`
+	Days           int             `gorm:"not null" json:"days"`
+	Reason         string          `gorm:"type:text" json:"reason,omitempty"`
+	LeaveRequestID *uint           `gorm:"index" json:"leave_request_id,omitempty"` // Request that used or gave back the days
+	ActorID        *uint           `json:"actor_id,omitempty"`                      // Admin who made a manual change
+	EffectiveDate  time.Time       `gorm:"not null" json:"effective_date"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	// Remaining days of the balance after this entry, filled in statements
+	RunningBalance int `gorm:"-" json:"running_balance"`
+}
+
+// BeforeUpdate prevents changing a posted entry; corrections are new entries
+func (e *LeaveLedgerEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerEntryImmutable
+}
+
+// BeforeDelete prevents removing a posted entry; corrections are new entries
+func (e *LeaveLedgerEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerEntryImmutable
+}
+
+// LedgerSource identifies what caused a ledger entry
+type LedgerSource struct {
+	LeaveRequestID *uint
+	ActorID        *uint
+	Reason         string
+}
+
+// LedgerTotals are the balance counters derived from ledger entries
+type LedgerTotals struct {
+	TotalAllocated int `json:"total_allocated"`
+	UsedDays       int `json:"used_days"`
+	CarryOverDays  int `json:"carry_over_days"`
+	RemainingDays  int `json:"remaining_days"`
+}
+
+// apply adds the effect of one entry to the totals
+func (t *LedgerTotals) apply(entryType LedgerEntryType, days int) {
+	switch entryType {
+	case LedgerAllocation, LedgerAccrual, LedgerAdjustment, LedgerCompOffCredit, LedgerCompOffExpiry, LedgerAdvanceSettlement:
+		t.TotalAllocated += days
+	case LedgerCarryOver, LedgerExpiry:
+		t.CarryOverDays += days
+	case LedgerUsage, LedgerReversal, LedgerEncashment:
+		t.UsedDays -= days
+	}
+	t.RemainingDays += days
+}
+
+// LedgerDrift is a balance whose stored counters disagree with its ledger
+type LedgerDrift struct {
+	BalanceID uint         `json:"balance_id"`
+	UserID    uint         `json:"user_id"`
+	LeaveType LeaveType    `json:"leave_type"`
+	Year      int          `json:"year"`
+	Stored    LedgerTotals `json:"stored"`
+	Ledger    LedgerTotals `json:"ledger"`
+	NoEntries bool         `json:"no_entries"` // Balance predates the ledger and has no entries at all
+}
+
+// LeaveStatement lists the ledger entries of a user for a year with running balances per leave type
+type LeaveStatement struct {
+	UserID  uint                       `json:"user_id"`
+	Year    int                        `json:"year"`
+	Entries []LeaveLedgerEntry         `json:"entries"`
+	Totals  map[LeaveType]LedgerTotals `json:"totals"`
+}
+
+// postLedgerEntries records entries against a balance and applies them to its counters in one transaction.
+// The balance is created when it has no ID yet. Entries with zero days are skipped. The counters of an existing
+// balance are re-read under a row lock, so concurrent posts never overwrite each other; the caller's
+// carry_over_used_days is kept.
+func (l *LeaveBalanceModel) postLedgerEntries(balance *LeaveBalance, entries ...LeaveLedgerEntry) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		if balance.ID != 0 {
+			var current LeaveBalance
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, balance.ID).Error; err != nil {
+				return err
+			}
+			balance.TotalAllocated = current.TotalAllocated
+			balance.UsedDays = current.UsedDays
+			balance.CarryOverDays = current.CarryOverDays
+		}
+
+		totals := LedgerTotals{
+			TotalAllocated: balance.TotalAllocated,
+			UsedDays:       balance.UsedDays,
+			CarryOverDays:  balance.CarryOverDays,
+		}
+		posted := make([]LeaveLedgerEntry, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Days == 0 {
+				continue
+			}
+			totals.apply(entry.EntryType, entry.Days)
+			posted = append(posted, entry)
+		}
+
+		balance.TotalAllocated = totals.TotalAllocated
+		balance.UsedDays = totals.UsedDays
+		balance.CarryOverDays = totals.CarryOverDays
+		balance.RemainingDays = balance.TotalAllocated + balance.CarryOverDays - balance.UsedDays
+		if balance.CarryOverUsedDays > balance.CarryOverDays {
+			balance.CarryOverUsedDays = balance.CarryOverDays
+		}
+		if err := tx.Omit("User").Save(balance).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range posted {
+			posted[i].UserID = balance.UserID
+			posted[i].LeaveType = balance.LeaveType
+			posted[i].Year = balance.Year
+			if posted[i].EffectiveDate.IsZero() {
+				posted[i].EffectiveDate = now
+			}
+			if err := tx.Create(&posted[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// nonPolicyAllocation returns the days of a balance's allocation that do not come from its policy: comp-off
+// credits net of expired ones, and settled advances. Recalculated allocations and settlements keep them.
+func (l *LeaveBalanceModel) nonPolicyAllocation(userID uint, year int, leaveType LeaveType) (int, error) {
+	var days int
+	if err := l.db.Model(&LeaveLedgerEntry{}).
+		Where("user_id = ? AND year = ? AND leave_type = ? AND entry_type IN ?", userID, year, leaveType,
+			[]LedgerEntryType{LedgerCompOffCredit, LedgerCompOffExpiry, LedgerAdvanceSettlement}).
+		Select("COALESCE(SUM(days), 0)").
+		Scan(&days).Error; err != nil {
+		return 0, err
+	}
+	return days, nil
+}
+
+// hasLedgerEntry reports whether a balance has an entry of the given type
+func (l *LeaveBalanceModel) hasLedgerEntry(balance *LeaveBalance, entryType LedgerEntryType) (bool, error) {
+	var count int64
+	if err := l.db.Model(&LeaveLedgerEntry{}).
+		Where("user_id = ? AND year = ? AND leave_type = ? AND entry_type = ?", balance.UserID, balance.Year,
+			balance.LeaveType, entryType).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// newLedgerEntry builds an entry from its type, signed days and source
+func newLedgerEntry(entryType LedgerEntryType, days int, source LedgerSource) LeaveLedgerEntry {
+	return LeaveLedgerEntry{
+		EntryType:      entryType,
+		Days:           days,
+		Reason:         source.Reason,
+		LeaveRequestID: source.LeaveRequestID,
+		ActorID:        source.ActorID,
+	}
+}
+
+// AdjustLeaveBalance sets the allocation and carry-over of a balance through adjustment entries,
+// creating the balance when it does not exist yet
+func (l *LeaveBalanceModel) AdjustLeaveBalance(userID uint, year int, leaveType LeaveType, totalAllocated, carryOverDays int, source LedgerSource) (*LeaveBalance, error) {
+	balance, err := l.GetUserLeaveBalanceByType(userID, year, leaveType)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if _, err := NewLeaveTypeDefinitionModel(l.db).GetLeaveType(leaveType); err != nil {
+			return nil, err
+		}
+		balance = &LeaveBalance{UserID: userID, LeaveType: leaveType, Year: year}
+	}
+
+	if err := l.postLedgerEntries(balance,
+		newLedgerEntry(LedgerAdjustment, totalAllocated-balance.TotalAllocated, source),
+		newLedgerEntry(LedgerCarryOver, carryOverDays-balance.CarryOverDays, source),
+	); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// GetLedgerEntries returns the ledger entries of a user for a year in posting order
+func (l *LeaveBalanceModel) GetLedgerEntries(userID uint, year int) ([]LeaveLedgerEntry, error) {
+	var entries []LeaveLedgerEntry
+	if err := l.db.Where("user_id = ? AND year = ?", userID, year).
+		Order("id ASC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetLeaveStatement returns a user's ledger for a year with running balances and totals per leave type
+func (l *LeaveBalanceModel) GetLeaveStatement(userID uint, year int) (*LeaveStatement, error) {
+	entries, err := l.GetLedgerEntries(userID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &LeaveStatement{
+		UserID:  userID,
+		Year:    year,
+		Entries: entries,
+		Totals:  make(map[LeaveType]LedgerTotals),
+	}
+	for i := range statement.Entries {
+		entry := &statement.Entries[i]
+		totals := statement.Totals[entry.LeaveType]
+		totals.apply(entry.EntryType, entry.Days)
+		statement.Totals[entry.LeaveType] = totals
+		entry.RunningBalance = totals.RemainingDays
+	}
+	return statement, nil
+}
+
+// CheckLedgerConsistency compares the stored balance counters of a year with the totals of their ledger
+// entries and returns the balances that drifted. A userID of 0 checks every user.
+func (l *LeaveBalanceModel) CheckLedgerConsistency(year int, userID uint) ([]LedgerDrift, error) {
+	balanceQuery := l.db.Where("year = ?", year)
+	entryQuery := l.db.Model(&LeaveLedgerEntry{}).Where("year = ?", year)
+	if userID != 0 {
+		balanceQuery = balanceQuery.Where("user_id = ?", userID)
+		entryQuery = entryQuery.Where("user_id = ?", userID)
+	}
+
+	var balances []LeaveBalance
+	if err := balanceQuery.Order("user_id ASC, leave_type ASC").Find(&balances).Error; err != nil {
+		return nil, err
+	}
+
+	var sums []struct {
+		UserID    uint
+		LeaveType LeaveType
+		EntryType LedgerEntryType
+		Days      int
+	}
+	if err := entryQuery.Select("user_id, leave_type, entry_type, SUM(days) AS days").
+		Group("user_id, leave_type, entry_type").
+		Scan(&sums).Error; err != nil {
+		return nil, err
+	}
+
+	type balanceKey struct {
+		userID    uint
+		leaveType LeaveType
+	}
+	ledger := make(map[balanceKey]LedgerTotals)
+	for _, sum := range sums {
+		key := balanceKey{sum.UserID, sum.LeaveType}
+		totals := ledger[key]
+		totals.apply(sum.EntryType, sum.Days)
+		ledger[key] = totals
+	}
+
+	drifts := make([]LedgerDrift, 0)
+	for _, balance := range balances {
+		stored := LedgerTotals{
+			TotalAllocated: balance.TotalAllocated,
+			UsedDays:       balance.UsedDays,
+			CarryOverDays:  balance.CarryOverDays,
+			RemainingDays:  balance.RemainingDays,
+		}
+		totals, hasEntries := ledger[balanceKey{balance.UserID, balance.LeaveType}]
+		if hasEntries && totals == stored {
+			continue
+		}
+		if !hasEntries && stored == (LedgerTotals{}) {
+			continue
+		}
+		drifts = append(drifts, LedgerDrift{
+			BalanceID: balance.ID,
+			UserID:    balance.UserID,
+			LeaveType: balance.LeaveType,
+			Year:      balance.Year,
+			Stored:    stored,
+			Ledger:    totals,
+			NoEntries: !hasEntries,
+		})
+	}
+	return drifts, nil
+}
+
+// ReconcileLeaveBalances fixes the drift found by CheckLedgerConsistency. The ledger is the source of truth,
+// so drifted counters are rewritten from it; balances without any entries get opening entries for their
+// current counters instead, so that existing data is carried into the ledger.
+func (l *LeaveBalanceModel) ReconcileLeaveBalances(year int, userID uint, actorID *uint) ([]LedgerDrift, error) {
+	drifts, err := l.CheckLedgerConsistency(year, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.db.Transaction(func(tx *gorm.DB) error {
+		txModel := NewLeaveBalanceModel(tx)
+		for _, drift := range drifts {
+			var balance LeaveBalance
+			if err := tx.First(&balance, drift.BalanceID).Error; err != nil {
+				return err
+			}
+
+			if drift.NoEntries {
+				source := LedgerSource{ActorID: actorID, Reason: "Opening balance"}
+				opening := &LeaveBalance{ID: balance.ID, UserID: balance.UserID, LeaveType: balance.LeaveType, Year: balance.Year, CreatedAt: balance.CreatedAt}
+				if err := txModel.postLedgerEntries(opening,
+					newLedgerEntry(LedgerAllocation, balance.TotalAllocated, source),
+					newLedgerEntry(LedgerCarryOver, balance.CarryOverDays, source),
+					newLedgerEntry(LedgerUsage, -balance.UsedDays, source),
+				); err != nil {
+					return err
+				}
+				continue
+			}
+
+			balance.TotalAllocated = drift.Ledger.TotalAllocated
+			balance.UsedDays = drift.Ledger.UsedDays
+			balance.CarryOverDays = drift.Ledger.CarryOverDays
+			balance.RemainingDays = drift.Ledger.RemainingDays
+			if err := tx.Save(&balance).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
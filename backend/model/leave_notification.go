@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,6 +19,11 @@ const (
 	NotificationTypeLeaveReminder    NotificationType = "LEAVE_REMINDER"
 	NotificationTypeBalanceLow       NotificationType = "BALANCE_LOW"
 	NotificationTypeLeaveExpiring    NotificationType = "LEAVE_EXPIRING"
+	NotificationTypeLeaveShortened   NotificationType = "LEAVE_SHORTENED"
+	NotificationTypeLeaveRecorded    NotificationType = "LEAVE_RECORDED"
+	NotificationTypeCompOffClaimed   NotificationType = "COMP_OFF_CLAIMED"
+	NotificationTypeCompOffDecided   NotificationType = "COMP_OFF_DECIDED"
+	NotificationTypeEncashment       NotificationType = "ENCASHMENT"
 )
 
 // NotificationStatus represents the status of a notification
@@ -148,6 +154,20 @@ func (l *LeaveNotificationModel) CreateLeaveRequestNotification(leaveRequest *Le
 	return l.CreateNotification(notification)
 }
 
+// CreateApprovalRequiredNotification tells an approver that a leave request now waits on them
+func (l *LeaveNotificationModel) CreateApprovalRequiredNotification(leaveRequest *LeaveRequest, approverID uint, message string) error {
+	notification := &LeaveNotification{
+		UserID:           approverID,
+		LeaveRequestID:   &leaveRequest.ID,
+		NotificationType: NotificationTypeApprovalRequired,
+		Title:            "Leave Request Needs Your Approval",
+		Message:          message,
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
 // CreateApprovalNotification creates a notification for leave request approval
 func (l *LeaveNotificationModel) CreateApprovalNotification(leaveRequest *LeaveRequest, approved bool) error {
 	var notificationType NotificationType
@@ -196,7 +216,126 @@ func (l *LeaveNotificationModel) CreateBalanceLowNotification(userID uint, leave
 		LeaveRequestID:   nil,
 		NotificationType: NotificationTypeBalanceLow,
 		Title:            "Low Leave Balance",
-		Message:          "Your " + string(leaveType) + " leave balance is low. You have " + string(rune(remainingDays)) + " days remaining.",
+		Message:          fmt.Sprintf("Your %s leave balance is low. You have %d days remaining.", leaveType, remainingDays),
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// CreateLeaveExpiringNotification warns a user that unused carried-over days are about to expire
+func (l *LeaveNotificationModel) CreateLeaveExpiringNotification(userID uint, leaveType LeaveType, days int, expiresOn time.Time) error {
+	notification := &LeaveNotification{
+		UserID:           userID,
+		LeaveRequestID:   nil,
+		NotificationType: NotificationTypeLeaveExpiring,
+		Title:            leaveExpiringTitle(leaveType),
+		Message:          fmt.Sprintf("You have %d carried-over %s leave days that expire after %s. Use them or they will be forfeited.", days, leaveType, expiresOn.Format("2006-01-02")),
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// leaveExpiringTitle is the title of LEAVE_EXPIRING notifications, one per leave type
+func leaveExpiringTitle(leaveType LeaveType) string {
+	return "Carried-over " + string(leaveType) + " Leave Expiring"
+}
+
+// CreateEarlyReturnNotification tells an approver that approved leave was cut short
+func (l *LeaveNotificationModel) CreateEarlyReturnNotification(leaveRequest *LeaveRequest, approverID uint, message string) error {
+	notification := &LeaveNotification{
+		UserID:           approverID,
+		LeaveRequestID:   &leaveRequest.ID,
+		NotificationType: NotificationTypeLeaveShortened,
+		Title:            "Leave Cut Short",
+		Message:          message,
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// CreateSubmittedOnBehalfNotification tells an employee that leave was submitted for them by someone else
+func (l *LeaveNotificationModel) CreateSubmittedOnBehalfNotification(leaveRequest *LeaveRequest, message string) error {
+	notification := &LeaveNotification{
+		UserID:           leaveRequest.UserID,
+		LeaveRequestID:   &leaveRequest.ID,
+		NotificationType: NotificationTypeLeaveRecorded,
+		Title:            "Leave Submitted On Your Behalf",
+		Message:          message,
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// CreateCompOffClaimNotification tells a team lead that a comp-off claim waits for their approval
+func (l *LeaveNotificationModel) CreateCompOffClaimNotification(claim *CompOffClaim, approverID uint) error {
+	notification := &LeaveNotification{
+		UserID:           approverID,
+		LeaveRequestID:   nil,
+		NotificationType: NotificationTypeCompOffClaimed,
+		Title:            "Comp-off Claim Needs Your Approval",
+		Message:          fmt.Sprintf("Comp-off claim #%d asks for %d days of time off for %.1f hours worked.", claim.ID, claim.Days, claim.TotalHours),
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// CreateCompOffDecisionNotification tells a user that their comp-off claim was approved or rejected
+func (l *LeaveNotificationModel) CreateCompOffDecisionNotification(claim *CompOffClaim, approved bool) error {
+	title := "Comp-off Claim Rejected"
+	message := fmt.Sprintf("Your comp-off claim #%d has been rejected.", claim.ID)
+	if approved {
+		title = "Comp-off Claim Approved"
+		message = fmt.Sprintf("Your comp-off claim #%d has been approved. %d days were added to your %s balance and can be used until %s.",
+			claim.ID, claim.Days, LeaveTypeCompOff, claim.ExpiresOn.Format("2006-01-02"))
+	}
+
+	notification := &LeaveNotification{
+		UserID:           claim.UserID,
+		LeaveRequestID:   nil,
+		NotificationType: NotificationTypeCompOffDecided,
+		Title:            title,
+		Message:          message,
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// CreateEncashmentRequestNotification tells a payroll approver that an encashment request waits for a decision
+func (l *LeaveNotificationModel) CreateEncashmentRequestNotification(encashment *LeaveEncashment, approverID uint) error {
+	notification := &LeaveNotification{
+		UserID:           approverID,
+		LeaveRequestID:   nil,
+		NotificationType: NotificationTypeEncashment,
+		Title:            "Leave Encashment Needs Your Approval",
+		Message:          fmt.Sprintf("Encashment #%d asks for %d days of %s leave from %d to be paid out.", encashment.ID, encashment.Days, encashment.LeaveType, encashment.Year),
+		Status:           NotificationStatusUnread,
+		IsRead:           false,
+	}
+	return l.CreateNotification(notification)
+}
+
+// CreateEncashmentDecisionNotification tells a user that their encashment request was approved or rejected
+func (l *LeaveNotificationModel) CreateEncashmentDecisionNotification(encashment *LeaveEncashment, approved bool) error {
+	title := "Leave Encashment Rejected"
+	message := fmt.Sprintf("Your request to encash %d days of %s leave has been rejected.", encashment.Days, encashment.LeaveType)
+	if approved {
+		title = "Leave Encashment Approved"
+		message = fmt.Sprintf("Your request to encash %d days of %s leave has been approved. %.2f %s will be paid with the %s payroll.",
+			encashment.Days, encashment.LeaveType, encashment.Amount, encashment.Currency, encashment.PayrollPeriod)
+	}
+
+	notification := &LeaveNotification{
+		UserID:           encashment.UserID,
+		LeaveRequestID:   nil,
+		NotificationType: NotificationTypeEncashment,
+		Title:            title,
+		Message:          message,
 		Status:           NotificationStatusUnread,
 		IsRead:           false,
 	}
@@ -217,6 +356,17 @@ func (l *LeaveNotificationModel) CreateReminderNotification(userID uint, leaveRe
 	return l.CreateNotification(notification)
 }
 
+// HasNotificationForRequest reports whether a user already got a notification of a type about a leave request since a time
+func (l *LeaveNotificationModel) HasNotificationForRequest(userID uint, leaveRequestID uint, notificationType NotificationType, since time.Time) (bool, error) {
+	var count int64
+	if err := l.db.Model(&LeaveNotification{}).
+		Where("user_id = ? AND leave_request_id = ? AND notification_type = ? AND created_at >= ?", userID, leaveRequestID, notificationType, since).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // GetNotificationsByType retrieves notifications by type for a user
 func (l *LeaveNotificationModel) GetNotificationsByType(userID uint, notificationType NotificationType) ([]LeaveNotification, error) {
 	var notifications []LeaveNotification
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ApprovalEventSubmittedOnBehalf ApprovalEventType = "SUBMITTED_ON_BEHALF" // ToApproverID submitted the request for the employee
+	ApprovalEventPreApproved       ApprovalEventType = "PRE_APPROVED"        // ToApproverID approved it on submission, skipping the chain
+)
+
+// IsSubmittedOnBehalf reports whether someone other than the employee submitted the request. Such requests may
+// start in the past, for example sick leave reported by phone.
+func (r *LeaveRequest) IsSubmittedOnBehalf() bool {
+	return r.SubmittedByID != nil && *r.SubmittedByID != r.UserID
+}
+
+// CanSubmitOnBehalf reports whether a submitter may record leave for a user: HR and management approvers for
+// anyone, team leads for the members of their team. The SUBMIT_LEAVE_ON_BEHALF permission is checked by
+// the caller.
+func (l *LeaveRequestModel) CanSubmitOnBehalf(submitterID, userID uint) (bool, error) {
+	if submitterID == userID {
+		return false, nil
+	}
+
+	userModel := NewUserModel(l.db)
+	for _, permission := range []string{"APPROVE_LEAVE_HR", "APPROVE_LEAVE_MANAGEMENT"} {
+		hasPermission, err := userModel.HasUserPermission(submitterID, permission)
+		if err != nil || hasPermission {
+			return hasPermission, err
+		}
+	}
+
+	// The team lead of the user's primary team, who would approve the request
+	user, err := l.getUserWithTeam(userID)
+	if err != nil {
+		return false, err
+	}
+	team, err := GetTeamByID(user.PrimaryTeamID)
+	if err != nil {
+		return false, nil
+	}
+	return team.TeamLeadID == submitterID, nil
+}
+
+// SubmitOnBehalf creates a validated request submitted by someone else together with its calendar entries.
+// A pre-approved request skips the approval chain: it is approved at once and its days are booked. Both are
+// recorded in the request's timeline.
+func (l *LeaveRequestModel) SubmitOnBehalf(request *LeaveRequest, preApprove bool, comments string) error {
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		if preApprove {
+			request.Status = StatusApproved
+		}
+		if err := NewLeaveRequestModel(tx).CreateLeaveRequest(request); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&LeaveApprovalEvent{
+			LeaveRequestID: request.ID,
+			EventType:      ApprovalEventSubmittedOnBehalf,
+			ToApproverID:   request.SubmittedByID,
+		}).Error; err != nil {
+			return err
+		}
+
+		message := "A leave request was submitted on your behalf."
+		if preApprove {
+			if err := NewLeaveBalanceModel(tx).IncrementUsedDays(request.UserID, request.StartDate.Year(), request.LeaveType,
+				request.DaysRequested, request.ID); err != nil {
+				return err
+			}
+			if err := tx.Create(&LeaveApprovalEvent{
+				LeaveRequestID: request.ID,
+				EventType:      ApprovalEventPreApproved,
+				ToApproverID:   request.SubmittedByID,
+				Note:           strings.TrimSpace(comments),
+			}).Error; err != nil {
+				return err
+			}
+			message = "Leave was recorded and approved on your behalf."
+		}
+
+		if err := NewLeaveCalendarModel(tx).CreateCalendarEntriesForLeaveRequest(request); err != nil {
+			return err
+		}
+		return NewLeaveNotificationModel(tx).CreateSubmittedOnBehalfNotification(request, message)
+	})
+}
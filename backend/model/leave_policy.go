@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,21 +10,106 @@ import (
 
 // LeavePolicy represents leave policies and rules for the organization
 type LeavePolicy struct {
-	ID                 uint      `gorm:"primaryKey"`
-	LeaveType          LeaveType `gorm:"not null"`
-	Year               int       `gorm:"not null"`
-	DefaultAllocation  int       `gorm:"not null;default:0"`  // Default days allocated per user
-	MaxAllocation      int       `gorm:"not null;default:0"`  // Maximum days that can be allocated
-	MinNoticeDays      int       `gorm:"not null;default:1"`  // Minimum notice required in days
-	MaxConsecutiveDays int       `gorm:"not null;default:30"` // Maximum consecutive days allowed
-	AllowCarryOver     bool      `gorm:"default:true"`        // Whether carry-over is allowed
-	MaxCarryOver       int       `gorm:"not null;default:0"`  // Maximum days that can be carried over
-	RequiresApproval   bool      `gorm:"default:true"`        // Whether this leave type requires approval
-	IsActive           bool      `gorm:"default:true"`        // Whether this policy is active
-	Description        string    `gorm:"type:text"`           // Policy description
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
-	DeletedAt          gorm.DeletedAt
+	ID                        uint             `gorm:"primaryKey"`
+	LeaveType                 LeaveType        `gorm:"not null"`
+	Year                      int              `gorm:"not null"`
+	DefaultAllocation         int              `gorm:"not null;default:0"`           // Default days allocated per user
+	MaxAllocation             int              `gorm:"not null;default:0"`           // Maximum days that can be allocated
+	MinNoticeDays             int              `gorm:"not null;default:1"`           // Minimum notice required in days
+	MaxConsecutiveDays        int              `gorm:"not null;default:30"`          // Maximum consecutive days allowed
+	AllowCarryOver            bool             `gorm:"default:true"`                 // Whether carry-over is allowed
+	MaxCarryOver              int              `gorm:"not null;default:0"`           // Maximum days that can be carried over
+	CarryOverExpiryMonth      int              `gorm:"not null;default:0"`           // Month in which carried-over days expire, 0 if they never expire
+	CarryOverExpiryDay        int              `gorm:"not null;default:0"`           // Last day of that month on which carried-over days can be used
+	ExpiryNoticeDays          UintArray        `gorm:"type:jsonb"`                   // Days before the expiry to send LEAVE_EXPIRING notifications
+	RequiresApproval          bool             `gorm:"default:true"`                 // Whether this leave type requires approval
+	UndecidedAction           UndecidedAction  `gorm:"not null;default:'NONE'"`      // What happens to a request still undecided when the leave starts
+	ProRataRounding           ProRataRounding  `gorm:"not null;default:'NEAREST'"`   // How allocations of partial-year employees are rounded
+	AccrualFrequency          AccrualFrequency `gorm:"not null;default:'NONE'"`      // Whether the allocation is granted up-front or earned per period
+	PayPeriodsPerYear         int              `gorm:"not null;default:26"`          // Number of periods for PAY_PERIOD accrual
+	AccrualCap                int              `gorm:"not null;default:0"`           // Remaining balance above which accrual stops, 0 for no cap
+	TenureBands               TenureBands      `gorm:"type:jsonb"`                   // Extra yearly days after a number of years of employment
+	RequiresDocument          bool             `gorm:"default:false"`                // Whether approval needs a supporting document
+	DocumentRequiredAfterDays int              `gorm:"not null;default:0"`           // Requests longer than this many days need the document, 0 for every request
+	MinTenureMonths           int              `gorm:"not null;default:0"`           // Months of employment before the leave can start, 0 for none
+	NotDuringProbation        bool             `gorm:"default:false"`                // Whether leave cannot start before the probation ends
+	EligibleEmploymentTypes   StringList       `gorm:"type:jsonb"`                   // Employment types that may take the leave, empty for all
+	EligibleGenders           StringList       `gorm:"type:jsonb"`                   // Recorded genders that may take the leave, empty for all
+	ChangeApproval            ChangeApproval   `gorm:"not null;default:'TEAM_LEAD'"` // Who approves changes to approved leave of this type
+	CompOffExpiryDays         int              `gorm:"not null;default:0"`           // Days after the work that comp-off credits can be used, 0 until the end of the year
+	AllowEncashment           bool             `gorm:"default:false"`                // Whether unused days can be paid out
+	MaxEncashmentDays         int              `gorm:"not null;default:0"`           // Days that can be paid out per year, 0 for no limit
+	MinBalanceAfterEncashment int              `gorm:"not null;default:0"`           // Days that must stay on the balance after a payout
+	EncashmentRate            float64          `gorm:"not null;default:1"`           // Share of the daily salary paid per encashed day
+	MaxAdvanceDays            int              `gorm:"not null;default:0"`           // Days that can be taken beyond the balance, against next year's allocation
+	AdvanceNeedsManagement    bool             `gorm:"default:false"`                // Whether leave taken in advance needs management approval whatever its length
+	IsActive                  bool             `gorm:"default:true"`                 // Whether this policy is active
+	Description               string           `gorm:"type:text"`                    // Policy description
+	CreatedAt                 time.Time
+	UpdatedAt                 time.Time
+	DeletedAt                 gorm.DeletedAt
+
+	// Relationships
+	Definition *LeaveTypeDefinition `gorm:"foreignKey:LeaveType;references:Code"`
+}
+
+// ProRataRounding controls how an allocation pro-rated by employment dates is rounded to whole days
+type ProRataRounding string
+
+const (
+	ProRataNearest ProRataRounding = "NEAREST"
+	ProRataUp      ProRataRounding = "UP"
+	ProRataDown    ProRataRounding = "DOWN"
+	ProRataNone    ProRataRounding = "NONE" // Not pro-rated: anyone employed during the year gets the full allocation
+)
+
+// ProRatedAllocation returns the default allocation for the part of the policy year covered by the employment dates.
+// A nil start or end date means employed since before or until after the year.
+func (p *LeavePolicy) ProRatedAllocation(employmentStart, employmentEnd *time.Time) int {
+	fraction := EmploymentFraction(p.Year, employmentStart, employmentEnd)
+	if fraction == 0 {
+		return 0
+	}
+
+	// Drop float noise so that e.g. 12.0000001 is not rounded up to 13
+	days := math.Round(float64(p.DefaultAllocation)*fraction*1e6) / 1e6
+	switch p.ProRataRounding {
+	case ProRataNone:
+		return p.DefaultAllocation
+	case ProRataUp:
+		return int(math.Ceil(days))
+	case ProRataDown:
+		return int(math.Floor(days))
+	default:
+		return int(math.Round(days))
+	}
+}
+
+// EmploymentFraction returns the share of the calendar days of a year, between 0 and 1, on which a user is employed
+func EmploymentFraction(year int, employmentStart, employmentEnd *time.Time) float64 {
+	startOfYear := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	endOfYear := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	from, to := startOfYear, endOfYear
+	if employmentStart != nil {
+		start := time.Date(employmentStart.Year(), employmentStart.Month(), employmentStart.Day(), 0, 0, 0, 0, time.UTC)
+		if start.After(from) {
+			from = start
+		}
+	}
+	if employmentEnd != nil {
+		end := time.Date(employmentEnd.Year(), employmentEnd.Month(), employmentEnd.Day(), 0, 0, 0, 0, time.UTC)
+		if end.Before(to) {
+			to = end
+		}
+	}
+	if to.Before(from) {
+		return 0
+	}
+
+	employedDays := to.Sub(from).Hours()/24 + 1
+	daysInYear := endOfYear.Sub(startOfYear).Hours()/24 + 1
+	return employedDays / daysInYear
 }
 
 // LeavePolicyModel handles leave policy database operations
@@ -87,45 +173,52 @@ func (l *LeavePolicyModel) DeactivateLeavePolicy(id uint) error {
 	return l.db.Model(&LeavePolicy{}).Where("id = ?", id).Update("is_active", false).Error
 }
 
-// InitializeDefaultPolicies creates default leave policies for a year
+// InitializeDefaultPolicies creates the default leave policies of a year whose leave type has no policy yet,
+// so leave types added later are seeded on existing deployments without touching configured policies
 func (l *LeavePolicyModel) InitializeDefaultPolicies(year int) error {
-	// Check if policies already exist for this year
-	var count int64
-	if err := l.db.Model(&LeavePolicy{}).Where("year = ?", year).Count(&count).Error; err != nil {
-		return err
-	}
-
-	if count > 0 {
-		return nil // Policies already exist
-	}
-
 	// Default policies
 	policies := []LeavePolicy{
 		{
-			LeaveType:          LeaveTypeAnnual,
-			Year:               year,
-			DefaultAllocation:  20, // 20 days annual leave
-			MaxAllocation:      30,
-			MinNoticeDays:      7,  // 1 week notice
-			MaxConsecutiveDays: 15, // Max 15 consecutive days
-			AllowCarryOver:     true,
-			MaxCarryOver:       5, // Max 5 days carry-over
-			RequiresApproval:   true,
-			IsActive:           true,
-			Description:        "Annual vacation leave policy",
+			LeaveType:                 LeaveTypeAnnual,
+			Year:                      year,
+			DefaultAllocation:         20, // 20 days annual leave
+			MaxAllocation:             30,
+			MinNoticeDays:             7,  // 1 week notice
+			MaxConsecutiveDays:        15, // Max 15 consecutive days
+			AllowCarryOver:            true,
+			MaxCarryOver:              5, // Max 5 days carry-over
+			CarryOverExpiryMonth:      3, // Carried-over days expire after March 31
+			CarryOverExpiryDay:        31,
+			ExpiryNoticeDays:          UintArray{30, 7},
+			NotDuringProbation:        true, // Annual leave can be taken once the probation is over
+			AllowEncashment:           true, // Days above the carry-over cap can be paid out
+			MaxEncashmentDays:         10,
+			MinBalanceAfterEncashment: 5,
+			EncashmentRate:            1,
+			MaxAdvanceDays:            5,
+			AdvanceNeedsManagement:    true,
+			RequiresApproval:          true,
+			UndecidedAction:           UndecidedAutoReject,
+			ProRataRounding:           ProRataNearest,
+			IsActive:                  true,
+			Description:               "Annual vacation leave policy",
 		},
 		{
-			LeaveType:          LeaveTypeSick,
-			Year:               year,
-			DefaultAllocation:  10, // 10 days sick leave
-			MaxAllocation:      15,
-			MinNoticeDays:      0,     // No notice required for sick leave
-			MaxConsecutiveDays: 5,     // Max 5 consecutive days without medical certificate
-			AllowCarryOver:     false, // Sick leave doesn't carry over
-			MaxCarryOver:       0,
-			RequiresApproval:   true,
-			IsActive:           true,
-			Description:        "Sick leave policy",
+			LeaveType:                 LeaveTypeSick,
+			Year:                      year,
+			DefaultAllocation:         10, // 10 days sick leave
+			MaxAllocation:             15,
+			MinNoticeDays:             0,     // No notice required for sick leave
+			MaxConsecutiveDays:        5,     // Max 5 consecutive days without medical certificate
+			AllowCarryOver:            false, // Sick leave doesn't carry over
+			MaxCarryOver:              0,
+			RequiresApproval:          true,
+			RequiresDocument:          true, // Medical certificate for sick leave over two days
+			DocumentRequiredAfterDays: 2,
+			UndecidedAction:           UndecidedAutoApprove,
+			ProRataRounding:           ProRataNearest,
+			IsActive:                  true,
+			Description:               "Sick leave policy",
 		},
 		{
 			LeaveType:          LeaveTypePersonal,
@@ -137,6 +230,8 @@ func (l *LeavePolicyModel) InitializeDefaultPolicies(year int) error {
 			AllowCarryOver:     false,
 			MaxCarryOver:       0,
 			RequiresApproval:   true,
+			UndecidedAction:    UndecidedAutoReject,
+			ProRataRounding:    ProRataNearest,
 			IsActive:           true,
 			Description:        "Personal leave policy",
 		},
@@ -150,34 +245,45 @@ func (l *LeavePolicyModel) InitializeDefaultPolicies(year int) error {
 			AllowCarryOver:     false,
 			MaxCarryOver:       0,
 			RequiresApproval:   true,
+			UndecidedAction:    UndecidedAutoApprove,
+			ProRataRounding:    ProRataNone,
 			IsActive:           true,
 			Description:        "Emergency leave policy",
 		},
 		{
-			LeaveType:          LeaveTypeMaternity,
-			Year:               year,
-			DefaultAllocation:  90, // 90 days maternity leave
-			MaxAllocation:      120,
-			MinNoticeDays:      30, // 30 days notice
-			MaxConsecutiveDays: 90, // Max 90 consecutive days
-			AllowCarryOver:     false,
-			MaxCarryOver:       0,
-			RequiresApproval:   true,
-			IsActive:           true,
-			Description:        "Maternity leave policy",
+			LeaveType:               LeaveTypeMaternity,
+			Year:                    year,
+			DefaultAllocation:       90, // 90 days maternity leave
+			MaxAllocation:           120,
+			MinNoticeDays:           30, // 30 days notice
+			MaxConsecutiveDays:      90, // Max 90 consecutive days
+			AllowCarryOver:          false,
+			MaxCarryOver:            0,
+			RequiresApproval:        true,
+			RequiresDocument:        true, // Medical paperwork for every request
+			MinTenureMonths:         6,    // Six months of employment before the leave starts
+			EligibleEmploymentTypes: StringList{string(EmploymentFullTime), string(EmploymentPartTime)},
+			UndecidedAction:         UndecidedNone,
+			ProRataRounding:         ProRataNone,
+			IsActive:                true,
+			Description:             "Maternity leave policy",
 		},
 		{
-			LeaveType:          LeaveTypePaternity,
-			Year:               year,
-			DefaultAllocation:  15, // 15 days paternity leave
-			MaxAllocation:      30,
-			MinNoticeDays:      14, // 14 days notice
-			MaxConsecutiveDays: 15, // Max 15 consecutive days
-			AllowCarryOver:     false,
-			MaxCarryOver:       0,
-			RequiresApproval:   true,
-			IsActive:           true,
-			Description:        "Paternity leave policy",
+			LeaveType:               LeaveTypePaternity,
+			Year:                    year,
+			DefaultAllocation:       15, // 15 days paternity leave
+			MaxAllocation:           30,
+			MinNoticeDays:           14, // 14 days notice
+			MaxConsecutiveDays:      15, // Max 15 consecutive days
+			AllowCarryOver:          false,
+			MaxCarryOver:            0,
+			MinTenureMonths:         6, // Six months of employment before the leave starts
+			EligibleEmploymentTypes: StringList{string(EmploymentFullTime), string(EmploymentPartTime)},
+			RequiresApproval:        true,
+			UndecidedAction:         UndecidedNone,
+			ProRataRounding:         ProRataNone,
+			IsActive:                true,
+			Description:             "Paternity leave policy",
 		},
 		{
 			LeaveType:          LeaveTypeUnpaid,
@@ -189,13 +295,42 @@ func (l *LeavePolicyModel) InitializeDefaultPolicies(year int) error {
 			AllowCarryOver:     false,
 			MaxCarryOver:       0,
 			RequiresApproval:   true,
+			UndecidedAction:    UndecidedAutoReject,
+			ProRataRounding:    ProRataNone,
 			IsActive:           true,
 			Description:        "Unpaid leave policy",
 		},
+		{
+			LeaveType:          LeaveTypeCompOff,
+			Year:               year,
+			DefaultAllocation:  0, // Days are credited by approved comp-off claims
+			MaxAllocation:      0,
+			MinNoticeDays:      1,
+			MaxConsecutiveDays: 5,
+			AllowCarryOver:     false,
+			MaxCarryOver:       0,
+			CompOffExpiryDays:  90, // Earned days can be used for 90 days after the work
+			RequiresApproval:   true,
+			UndecidedAction:    UndecidedAutoReject,
+			ProRataRounding:    ProRataNone,
+			IsActive:           true,
+			Description:        "Compensatory time off policy",
+		},
 	}
 
 	// Create all policies
 	for _, policy := range policies {
+		// Skip leave types that already have a policy, including deactivated ones
+		var count int64
+		if err := l.db.Model(&LeavePolicy{}).
+			Where("year = ? AND leave_type = ?", year, policy.LeaveType).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
 		if err := l.db.Create(&policy).Error; err != nil {
 			return err
 		}
@@ -243,18 +378,28 @@ func (l *LeavePolicyModel) GetActiveLeaveTypes(year int) ([]LeaveType, error) {
 
 // ValidateLeaveRequestAgainstPolicy validates a leave request against the policy
 func (l *LeavePolicyModel) ValidateLeaveRequestAgainstPolicy(request *LeaveRequest) error {
+	// Blackout periods apply whether or not the leave type has a policy
+	if err := NewBlackoutPeriodModel(l.db).CheckLeaveRequest(request); err != nil {
+		return err
+	}
+
 	policy, err := l.GetLeavePolicyByTypeAndYear(request.LeaveType, request.StartDate.Year())
 	if err != nil {
 		return err
 	}
 
+	// Check eligibility rules on the first day of leave
+	if err := l.CheckEligibility(policy, request.UserID, request.StartDate); err != nil {
+		return err
+	}
+
 	// Check if leave type requires approval
 	if policy.RequiresApproval && request.Status == StatusPending {
 		// This is handled by the approval workflow
 	}
 
-	// Check minimum notice period
-	if policy.MinNoticeDays > 0 {
+	// Check minimum notice period; leave recorded on the employee's behalf is often reported after the fact
+	if policy.MinNoticeDays > 0 && !request.IsSubmittedOnBehalf() {
 		noticeDays := int(time.Until(request.StartDate).Hours() / 24)
 		if noticeDays < policy.MinNoticeDays {
 			return fmt.Errorf("insufficient notice period: %d days required, %d days provided", policy.MinNoticeDays, noticeDays)
@@ -269,6 +414,11 @@ func (l *LeavePolicyModel) ValidateLeaveRequestAgainstPolicy(request *LeaveReque
 	return nil
 }
 
+// RequiresDocumentFor reports whether a request of this many days needs a supporting document
+func (p *LeavePolicy) RequiresDocumentFor(days int) bool {
+	return p.RequiresDocument && days > p.DocumentRequiredAfterDays
+}
+
 // GetLeavePolicyStats returns statistics about leave policies
 func (l *LeavePolicyModel) GetLeavePolicyStats(year int) (map[string]interface{}, error) {
 	var policies []LeavePolicy
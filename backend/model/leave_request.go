@@ -1,7 +1,11 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,6 +24,19 @@ const (
 	StatusCancelled          LeaveRequestStatus = "CANCELLED"
 )
 
+// activeLeaveStatuses are the statuses of leave that is still going to be taken
+var activeLeaveStatuses = []LeaveRequestStatus{
+	StatusPending, StatusTeamLeadApproved, StatusHRApproved, StatusManagementApproved, StatusApproved,
+}
+
+// IsBalanceDeducted reports whether the days of a request in this status have been added to the used days
+func (s LeaveRequestStatus) IsBalanceDeducted() bool {
+	return s == StatusApproved || s == StatusManagementApproved
+}
+
+// ErrLeaveRequestNotPending is returned when a request was decided or withdrawn before a decision was applied
+var ErrLeaveRequestNotPending = errors.New("leave request is no longer pending this decision")
+
 // LeaveType represents the type of leave
 type LeaveType string
 
@@ -31,6 +48,7 @@ const (
 	LeaveTypeMaternity LeaveType = "MATERNITY"
 	LeaveTypePaternity LeaveType = "PATERNITY"
 	LeaveTypeUnpaid    LeaveType = "UNPAID"
+	LeaveTypeCompOff   LeaveType = "COMP_OFF" // Time off in lieu, taken from days earned by approved comp-off claims
 )
 
 // LeaveRequest represents a leave request with approval workflow
@@ -43,6 +61,7 @@ type LeaveRequest struct {
 	DaysRequested int                `gorm:"not null" json:"days_requested"`
 	Reason        string             `gorm:"type:text" json:"reason"`
 	Status        LeaveRequestStatus `gorm:"default:'PENDING'" json:"status"`
+	SubmittedByID *uint              `json:"submitted_by_id,omitempty"` // Set when HR or a team lead submitted it for the employee
 
 	// Approval workflow
 	TeamLeadID         *uint      `json:"team_lead_id"` // Team lead who should approve
@@ -55,14 +74,28 @@ type LeaveRequest struct {
 	ManagementApprovedAt *time.Time `json:"management_approved_at"`
 	ManagementComments   string     `gorm:"type:text" json:"management_comments"`
 
+	// Escalation, set when the current stage missed its SLA and cleared when the stage is decided
+	EscalatedToID    *uint         `json:"escalated_to_id,omitempty"`    // Fallback approver
+	EscalatedToGroup ApprovalStage `json:"escalated_to_group,omitempty"` // Everyone who approves this stage may decide
+	EscalatedAt      *time.Time    `json:"escalated_at,omitempty"`
+
+	// Early return, set when approved leave was cut short and the remaining days were cancelled
+	OriginalEndDate *time.Time `json:"original_end_date,omitempty"` // End date as approved
+	CancelledDays   int        `gorm:"not null;default:0" json:"cancelled_days"`
+
+	// Advance leave, set when the request takes days beyond the remaining balance
+	AdvanceDays                int  `gorm:"not null;default:0" json:"advance_days"`
+	ManagementApprovalRequired bool `gorm:"default:false" json:"management_approval_required"` // Management approves after HR whatever the length
+
 	// Metadata
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty"`
 
 	// Relationships
-	User     User  `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	TeamLead *User `gorm:"foreignKey:TeamLeadID" json:"team_lead,omitempty"`
+	User       User                 `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TeamLead   *User                `gorm:"foreignKey:TeamLeadID" json:"team_lead,omitempty"`
+	Definition *LeaveTypeDefinition `gorm:"foreignKey:LeaveType;references:Code" json:"leave_type_definition,omitempty"`
 }
 
 // LeaveRequestModel handles leave request database operations
@@ -118,10 +151,25 @@ func (l *LeaveRequestModel) GetUserLeaveRequests(userID uint) ([]LeaveRequest, e
 	return requests, nil
 }
 
-// GetPendingTeamLeadApprovals retrieves leave requests pending team lead approval
+// GetPendingTeamLeadApprovals retrieves leave requests pending team lead approval, including requests
+// escalated to the user and requests of team leads who delegated their approvals to the user
 func (l *LeaveRequestModel) GetPendingTeamLeadApprovals(teamLeadID uint) ([]LeaveRequest, error) {
+	delegations, err := NewApprovalDelegationModel(l.db).GetActiveDelegationsTo(teamLeadID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	approvers := l.db.Where("team_lead_id = ?", teamLeadID).Or("escalated_to_id = ?", teamLeadID)
+	for _, delegation := range delegations {
+		if len(delegation.LeaveTypes) == 0 {
+			approvers = approvers.Or("team_lead_id = ? AND user_id <> ?", delegation.DelegatorID, delegation.DelegatorID)
+		} else {
+			approvers = approvers.Or("team_lead_id = ? AND user_id <> ? AND leave_type IN ?", delegation.DelegatorID, delegation.DelegatorID, []LeaveType(delegation.LeaveTypes))
+		}
+	}
+
 	var requests []LeaveRequest
-	if err := l.db.Where("team_lead_id = ? AND status = ?", teamLeadID, StatusPending).
+	if err := l.db.Where(approvers).Where("status = ?", StatusPending).
 		Preload("User").Preload("TeamLead").
 		Order("created_at ASC").
 		Find(&requests).Error; err != nil {
@@ -130,8 +178,8 @@ func (l *LeaveRequestModel) GetPendingTeamLeadApprovals(teamLeadID uint) ([]Leav
 	return requests, nil
 }
 
-// GetPendingHRApprovals retrieves leave requests pending HR approval
-func (l *LeaveRequestModel) GetPendingHRApprovals() ([]LeaveRequest, error) {
+// GetPendingHRApprovals retrieves leave requests pending HR approval that the approver may decide
+func (l *LeaveRequestModel) GetPendingHRApprovals(approverID uint) ([]LeaveRequest, error) {
 	var requests []LeaveRequest
 	if err := l.db.Where("status = ?", StatusTeamLeadApproved).
 		Preload("User").Preload("TeamLead").
@@ -139,11 +187,11 @@ func (l *LeaveRequestModel) GetPendingHRApprovals() ([]LeaveRequest, error) {
 		Find(&requests).Error; err != nil {
 		return nil, err
 	}
-	return requests, nil
+	return l.decidableBy(requests, approverID)
 }
 
-// GetPendingManagementApprovals retrieves leave requests pending management approval
-func (l *LeaveRequestModel) GetPendingManagementApprovals() ([]LeaveRequest, error) {
+// GetPendingManagementApprovals retrieves leave requests pending management approval that the approver may decide
+func (l *LeaveRequestModel) GetPendingManagementApprovals(approverID uint) ([]LeaveRequest, error) {
 	var requests []LeaveRequest
 	if err := l.db.Where("status = ?", StatusHRApproved).
 		Preload("User").Preload("TeamLead").
@@ -151,73 +199,151 @@ func (l *LeaveRequestModel) GetPendingManagementApprovals() ([]LeaveRequest, err
 		Find(&requests).Error; err != nil {
 		return nil, err
 	}
-	return requests, nil
+	return l.decidableBy(requests, approverID)
+}
+
+// decidableBy keeps the requests waiting on a stage the user may decide, in their own right or as a delegate
+func (l *LeaveRequestModel) decidableBy(requests []LeaveRequest, userID uint) ([]LeaveRequest, error) {
+	decidable := []LeaveRequest{}
+	for i := range requests {
+		actorID, err := l.deciderFor(&requests[i], userID)
+		if err != nil {
+			return nil, err
+		}
+		if actorID != 0 {
+			decidable = append(decidable, requests[i])
+		}
+	}
+	return decidable, nil
 }
 
 // ApproveByTeamLead approves a leave request by team lead
-func (l *LeaveRequestModel) ApproveByTeamLead(requestID uint, teamLeadID uint, comments string) error {
+func (l *LeaveRequestModel) ApproveByTeamLead(requestID uint, comments string) error {
 	now := time.Now()
-	return l.db.Model(&LeaveRequest{}).
-		Where("id = ? AND team_lead_id = ? AND status = ?", requestID, teamLeadID, StatusPending).
+	return decided(l.db.Model(&LeaveRequest{}).
+		Where("id = ? AND status = ?", requestID, StatusPending).
 		Updates(map[string]interface{}{
 			"status":                StatusTeamLeadApproved,
 			"team_lead_approved_at": &now,
 			"team_lead_comments":    comments,
-		}).Error
+			"escalated_to_id":       nil,
+			"escalated_to_group":    "",
+			"escalated_at":          nil,
+		}))
 }
 
 // RejectByTeamLead rejects a leave request by team lead
-func (l *LeaveRequestModel) RejectByTeamLead(requestID uint, teamLeadID uint, comments string) error {
-	return l.db.Model(&LeaveRequest{}).
-		Where("id = ? AND team_lead_id = ? AND status = ?", requestID, teamLeadID, StatusPending).
+func (l *LeaveRequestModel) RejectByTeamLead(requestID uint, comments string) error {
+	return decided(l.db.Model(&LeaveRequest{}).
+		Where("id = ? AND status = ?", requestID, StatusPending).
 		Updates(map[string]interface{}{
 			"status":             StatusRejected,
 			"team_lead_comments": comments,
-		}).Error
+			"escalated_to_id":    nil,
+			"escalated_to_group": "",
+			"escalated_at":       nil,
+		}))
 }
 
-// ApproveByHR approves a leave request by HR
-func (l *LeaveRequestModel) ApproveByHR(requestID uint, comments string) error {
+// ApproveByHR approves a leave request by HR. HR approval is final for requests that need no management
+// approval, which go straight to approved.
+func (l *LeaveRequestModel) ApproveByHR(request *LeaveRequest, comments string) error {
+	status := StatusApproved
+	if request.RequiresManagementApproval() {
+		status = StatusHRApproved
+	}
 	now := time.Now()
-	return l.db.Model(&LeaveRequest{}).
-		Where("id = ? AND status = ?", requestID, StatusTeamLeadApproved).
+	return decided(l.db.Model(&LeaveRequest{}).
+		Where("id = ? AND status = ?", request.ID, StatusTeamLeadApproved).
 		Updates(map[string]interface{}{
-			"status":         StatusHRApproved,
-			"hr_approved_at": &now,
-			"hr_comments":    comments,
-		}).Error
+			"status":             status,
+			"hr_approved_at":     &now,
+			"hr_comments":        comments,
+			"escalated_to_id":    nil,
+			"escalated_to_group": "",
+			"escalated_at":       nil,
+		}))
 }
 
 // RejectByHR rejects a leave request by HR
 func (l *LeaveRequestModel) RejectByHR(requestID uint, comments string) error {
-	return l.db.Model(&LeaveRequest{}).
+	return decided(l.db.Model(&LeaveRequest{}).
 		Where("id = ? AND status = ?", requestID, StatusTeamLeadApproved).
 		Updates(map[string]interface{}{
-			"status":      StatusRejected,
-			"hr_comments": comments,
-		}).Error
+			"status":             StatusRejected,
+			"hr_comments":        comments,
+			"escalated_to_id":    nil,
+			"escalated_to_group": "",
+			"escalated_at":       nil,
+		}))
 }
 
 // ApproveByManagement approves a leave request by management
 func (l *LeaveRequestModel) ApproveByManagement(requestID uint, comments string) error {
 	now := time.Now()
-	return l.db.Model(&LeaveRequest{}).
+	return decided(l.db.Model(&LeaveRequest{}).
 		Where("id = ? AND status = ?", requestID, StatusHRApproved).
 		Updates(map[string]interface{}{
 			"status":                 StatusManagementApproved,
 			"management_approved_at": &now,
 			"management_comments":    comments,
-		}).Error
+			"escalated_to_id":        nil,
+			"escalated_to_group":     "",
+			"escalated_at":           nil,
+		}))
 }
 
 // RejectByManagement rejects a leave request by management
 func (l *LeaveRequestModel) RejectByManagement(requestID uint, comments string) error {
-	return l.db.Model(&LeaveRequest{}).
+	return decided(l.db.Model(&LeaveRequest{}).
 		Where("id = ? AND status = ?", requestID, StatusHRApproved).
 		Updates(map[string]interface{}{
 			"status":              StatusRejected,
 			"management_comments": comments,
-		}).Error
+			"escalated_to_id":     nil,
+			"escalated_to_group":  "",
+			"escalated_at":        nil,
+		}))
+}
+
+// decided checks that a decision update found the request still waiting on the stage it decides
+func decided(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaveRequestNotPending
+	}
+	return nil
+}
+
+// ApproveHRFinalRequests moves requests left at HR_APPROVED although they needed no management approval to
+// approved, booking their days and updating their calendar entries. Such requests were final at HR approval
+// before HR approval of short leave set them approved directly.
+func (l *LeaveRequestModel) ApproveHRFinalRequests() error {
+	var requests []LeaveRequest
+	if err := l.db.Where("status = ?", StatusHRApproved).Find(&requests).Error; err != nil {
+		return err
+	}
+
+	for i := range requests {
+		request := &requests[i]
+		if request.RequiresManagementApproval() {
+			continue
+		}
+		if err := l.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&LeaveRequest{}).
+				Where("id = ? AND status = ?", request.ID, StatusHRApproved).
+				Update("status", StatusApproved)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			return NewLeaveRequestModel(tx).applyDecision(request.ID)
+		}); err != nil {
+			return fmt.Errorf("approving leave request %d: %w", request.ID, err)
+		}
+	}
+	return nil
 }
 
 // CancelLeaveRequest cancels a leave request (only by the requester)
@@ -239,6 +365,18 @@ func (l *LeaveRequestModel) GetLeaveRequestsByStatus(status LeaveRequestStatus)
 	return requests, nil
 }
 
+// GetApprovedLeaveStartingOn retrieves fully approved leave requests that start on a date
+func (l *LeaveRequestModel) GetApprovedLeaveStartingOn(date time.Time) ([]LeaveRequest, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	var requests []LeaveRequest
+	if err := l.db.Where("status IN ? AND start_date >= ? AND start_date < ?", []LeaveRequestStatus{StatusApproved, StatusManagementApproved}, day, day.AddDate(0, 0, 1)).
+		Order("start_date ASC").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
 // GetLeaveRequestsInDateRange retrieves leave requests within a date range
 func (l *LeaveRequestModel) GetLeaveRequestsInDateRange(startDate, endDate time.Time) ([]LeaveRequest, error) {
 	var requests []LeaveRequest
@@ -333,10 +471,15 @@ func (l *LeaveRequestModel) CheckLeaveOverlap(userID uint, startDate, endDate ti
 	return count > 0, nil
 }
 
-// ValidateLeaveRequest validates a leave request before creation
+// ValidateLeaveRequest validates a leave request before creation or an edit
 func (l *LeaveRequestModel) ValidateLeaveRequest(request *LeaveRequest) error {
-	// Check if start date is not in the past
-	if request.StartDate.Before(time.Now().Truncate(24 * time.Hour)) {
+	// Only active leave types can be requested
+	if _, err := NewLeaveTypeDefinitionModel(l.db).GetActiveLeaveType(request.LeaveType); err != nil {
+		return err
+	}
+
+	// Check if start date is not in the past; retroactive leave can only be recorded on the employee's behalf
+	if !request.IsSubmittedOnBehalf() && request.StartDate.Before(time.Now().Truncate(24*time.Hour)) {
 		return fmt.Errorf("start date cannot be in the past")
 	}
 
@@ -345,8 +488,12 @@ func (l *LeaveRequestModel) ValidateLeaveRequest(request *LeaveRequest) error {
 		return fmt.Errorf("end date must be after start date")
 	}
 
-	// Check for overlapping leave requests
-	hasOverlap, err := l.CheckLeaveOverlap(request.UserID, request.StartDate, request.EndDate, nil)
+	// Check for overlapping leave requests; an edited request does not overlap itself
+	var excludeID *uint
+	if request.ID != 0 {
+		excludeID = &request.ID
+	}
+	hasOverlap, err := l.CheckLeaveOverlap(request.UserID, request.StartDate, request.EndDate, excludeID)
 	if err != nil {
 		return err
 	}
@@ -377,6 +524,66 @@ func (l *LeaveRequestModel) GetLeaveRequestsByYear(userID uint, year int) ([]Lea
 	return requests, nil
 }
 
+// LeaveRequestListSpec declares the sorting, filters and search supported when listing leave requests
+var LeaveRequestListSpec = ListSpec{
+	SortColumns: map[string]string{
+		"id":             "id",
+		"start_date":     "start_date",
+		"end_date":       "end_date",
+		"days_requested": "days_requested",
+		"created_at":     "created_at",
+		"updated_at":     "updated_at",
+	},
+	DefaultSort: []SortField{{Field: "start_date", Desc: true}},
+	Filters: map[string]FilterFunc{
+		"status": EnumFilter("status",
+			string(StatusPending), string(StatusTeamLeadApproved), string(StatusHRApproved),
+			string(StatusManagementApproved), string(StatusApproved), string(StatusRejected), string(StatusCancelled)),
+		"leave_type":     leaveTypeFilter,
+		"year":           leaveRequestYearFilter,
+		"start_after":    TimeAfterFilter("start_date"),
+		"start_before":   TimeBeforeFilter("start_date"),
+		"created_after":  TimeAfterFilter("created_at"),
+		"created_before": TimeBeforeFilter("created_at"),
+	},
+	SearchColumns: []string{"reason"},
+}
+
+// leaveTypeFilter keeps leave requests of a leave type code; the codes are defined by administrators
+func leaveTypeFilter(value string) (func(*gorm.DB) *gorm.DB, error) {
+	code := strings.ToUpper(value)
+	if !leaveTypeCodePattern.MatchString(code) {
+		return nil, fmt.Errorf("expected a leave type code")
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("leave_type = ?", code)
+	}, nil
+}
+
+// leaveRequestYearFilter keeps leave requests starting in the given year
+func leaveRequestYearFilter(value string) (func(*gorm.DB) *gorm.DB, error) {
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1 {
+		return nil, fmt.Errorf("expected a year")
+	}
+	startOfYear := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	endOfYear := time.Date(year, 12, 31, 23, 59, 59, 999999999, time.UTC)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("start_date >= ? AND start_date <= ?", startOfYear, endOfYear)
+	}, nil
+}
+
+// ListUserLeaveRequests returns one page of a user's leave requests matching the list query
+func (l *LeaveRequestModel) ListUserLeaveRequests(userID uint, q ListQuery) ([]LeaveRequest, *ListResult, error) {
+	var requests []LeaveRequest
+	base := l.db.Model(&LeaveRequest{}).Where("user_id = ?", userID).Preload("User").Preload("TeamLead")
+	result, err := Paginate(base, q, LeaveRequestListSpec, &requests)
+	if err != nil {
+		return nil, nil, err
+	}
+	return requests, result, nil
+}
+
 // GetTeamLeaveRequests retrieves leave requests for all team members
 func (l *LeaveRequestModel) GetTeamLeaveRequests(teamLeadID uint, year int) ([]LeaveRequest, error) {
 	var requests []LeaveRequest
@@ -406,7 +613,10 @@ func (l *LeaveRequestModel) GetTeamLeaveRequests(teamLeadID uint, year int) ([]L
 	return requests, nil
 }
 
-// ProcessLeaveRequestWorkflow processes the approval workflow for a leave request
+// ProcessLeaveRequestWorkflow processes the approval workflow for a leave request. The approver decides
+// in their own right or, through an active delegation, on behalf of someone who could. The stage decision, its
+// event, the calendar and the balance usage of fully approved leave are stored in one transaction, and a
+// request decided concurrently by someone else fails with ErrLeaveRequestNotPending.
 func (l *LeaveRequestModel) ProcessLeaveRequestWorkflow(requestID uint, approverID uint, action string, comments string) error {
 	// Get the leave request
 	request, err := l.GetLeaveRequest(requestID)
@@ -414,95 +624,223 @@ func (l *LeaveRequestModel) ProcessLeaveRequestWorkflow(requestID uint, approver
 		return err
 	}
 
-	// Determine the next step based on current status and action
+	if action != "approve" && action != "reject" {
+		return fmt.Errorf("invalid action or insufficient permissions")
+	}
+
+	// The approver whose decision this is: the user themselves, or the approver they stand in for
+	decidedForID, err := l.deciderFor(request, approverID)
+	if err != nil {
+		return err
+	}
+	if decidedForID == 0 {
+		return fmt.Errorf("invalid action or insufficient permissions")
+	}
+	// An escalated team lead stage is decided in place of the team lead, who stays on the request
+	if decidedForID == approverID && request.Status == StatusPending && request.TeamLeadID != nil {
+		decidedForID = *request.TeamLeadID
+	}
+
+	if action == "approve" {
+		if err := NewLeaveAttachmentModel(l.db).CheckRequiredDocuments(request); err != nil {
+			return err
+		}
+	}
+
+	stage := request.Status
+	return l.db.Transaction(func(tx *gorm.DB) error {
+		txModel := NewLeaveRequestModel(tx)
+		if err := txModel.decideStage(request, action, comments); err != nil {
+			return err
+		}
+
+		// Every decision is recorded with the user who took it and the approver they decided for
+		eventType := ApprovalEventApproved
+		if action == "reject" {
+			eventType = ApprovalEventRejected
+		}
+		if err := tx.Create(&LeaveApprovalEvent{
+			LeaveRequestID: request.ID,
+			Stage:          workflowStage(stage),
+			EventType:      eventType,
+			FromApproverID: &decidedForID,
+			ToApproverID:   &approverID,
+			Note:           comments,
+		}).Error; err != nil {
+			return err
+		}
+
+		return txModel.applyDecision(request.ID)
+	})
+}
+
+// applyDecision brings the calendar entries in line with the status of a decided request and books the days of
+// fully approved leave
+func (l *LeaveRequestModel) applyDecision(requestID uint) error {
+	var request LeaveRequest
+	if err := l.db.First(&request, requestID).Error; err != nil {
+		return err
+	}
+
+	calendarModel := NewLeaveCalendarModel(l.db)
+	switch {
+	case request.Status == StatusRejected:
+		return calendarModel.UpdateCalendarEntryStatus(request.ID, StatusRejected)
+	case request.Status.IsBalanceDeducted():
+		if err := NewLeaveBalanceModel(l.db).IncrementUsedDays(request.UserID, request.StartDate.Year(), request.LeaveType,
+			request.DaysRequested, request.ID); err != nil {
+			return err
+		}
+		return calendarModel.UpdateCalendarEntryStatus(request.ID, request.Status)
+	}
+	return nil
+}
+
+// decideStage approves or rejects the current stage of a request. Who may decide is checked by the caller and
+// recorded in the decision's approval event.
+func (l *LeaveRequestModel) decideStage(request *LeaveRequest, action string, comments string) error {
 	switch request.Status {
 	case StatusPending:
 		if action == "approve" {
-			// Check if user is team lead
-			userModel := NewUserModel(l.db)
-			isTeamLead, err := userModel.IsUserTeamLead(approverID)
-			if err != nil {
-				return err
-			}
-
-			if isTeamLead && request.TeamLeadID != nil && *request.TeamLeadID == approverID {
-				// Team lead approval
-				return l.ApproveByTeamLead(requestID, approverID, comments)
-			}
-		} else if action == "reject" {
-			// Check if user is team lead
-			userModel := NewUserModel(l.db)
-			isTeamLead, err := userModel.IsUserTeamLead(approverID)
-			if err != nil {
-				return err
-			}
-
-			if isTeamLead && request.TeamLeadID != nil && *request.TeamLeadID == approverID {
-				// Team lead rejection
-				return l.RejectByTeamLead(requestID, approverID, comments)
-			}
+			// Team lead approval
+			return l.ApproveByTeamLead(request.ID, comments)
 		}
+		// Team lead rejection
+		return l.RejectByTeamLead(request.ID, comments)
 
 	case StatusTeamLeadApproved:
 		if action == "approve" {
-			// Check if user has HR permission
-			userModel := NewUserModel(l.db)
-			hasHRPermission, err := userModel.HasUserPermission(approverID, "APPROVE_LEAVE_HR")
-			if err != nil {
-				return err
-			}
-
-			if hasHRPermission {
-				// Check if management approval is required (> 4 days)
-				if request.DaysRequested > 4 {
-					// HR approval, but management approval still needed
-					return l.ApproveByHR(requestID, comments)
-				} else {
-					// HR approval is final
-					return l.ApproveByHR(requestID, comments)
-				}
-			}
-		} else if action == "reject" {
-			// Check if user has HR permission
-			userModel := NewUserModel(l.db)
-			hasHRPermission, err := userModel.HasUserPermission(approverID, "APPROVE_LEAVE_HR")
-			if err != nil {
-				return err
-			}
-
-			if hasHRPermission {
-				return l.RejectByHR(requestID, comments)
-			}
+			// HR approval is final for requests up to 4 days, management approval follows for longer or advance leave
+			return l.ApproveByHR(request, comments)
 		}
+		return l.RejectByHR(request.ID, comments)
 
 	case StatusHRApproved:
 		if action == "approve" {
-			// Check if user has management permission
-			userModel := NewUserModel(l.db)
-			hasManagementPermission, err := userModel.HasUserPermission(approverID, "APPROVE_LEAVE_MANAGEMENT")
-			if err != nil {
-				return err
-			}
+			// Management approval - final approval
+			return l.ApproveByManagement(request.ID, comments)
+		}
+		return l.RejectByManagement(request.ID, comments)
+	}
 
-			if hasManagementPermission {
-				// Management approval - final approval
-				return l.ApproveByManagement(requestID, comments)
-			}
-		} else if action == "reject" {
-			// Check if user has management permission
-			userModel := NewUserModel(l.db)
-			hasManagementPermission, err := userModel.HasUserPermission(approverID, "APPROVE_LEAVE_MANAGEMENT")
-			if err != nil {
-				return err
-			}
+	return fmt.Errorf("invalid action or insufficient permissions")
+}
+
+// canDecide reports whether a user may decide the current stage of a request in their own right. Nobody decides
+// their own request, even when it was escalated to a group they approve for.
+func (l *LeaveRequestModel) canDecide(request *LeaveRequest, userID uint) (bool, error) {
+	if request.UserID == userID {
+		return false, nil
+	}
 
-			if hasManagementPermission {
-				return l.RejectByManagement(requestID, comments)
+	userModel := NewUserModel(l.db)
+	switch request.Status {
+	case StatusPending:
+		if request.TeamLeadID != nil && *request.TeamLeadID == userID {
+			isTeamLead, err := userModel.IsUserTeamLead(userID)
+			if err != nil || isTeamLead {
+				return isTeamLead, err
 			}
 		}
+	case StatusTeamLeadApproved:
+		hasHRPermission, err := userModel.HasUserPermission(userID, "APPROVE_LEAVE_HR")
+		if err != nil || hasHRPermission {
+			return hasHRPermission, err
+		}
+	case StatusHRApproved:
+		hasManagementPermission, err := userModel.HasUserPermission(userID, "APPROVE_LEAVE_MANAGEMENT")
+		if err != nil || hasManagementPermission {
+			return hasManagementPermission, err
+		}
+	default:
+		return false, nil
 	}
 
-	return fmt.Errorf("invalid action or insufficient permissions")
+	// An escalated request can also be decided by the fallback approver
+	return l.isEscalatedApprover(request, userID)
+}
+
+// deciderFor returns the approver in whose right a user decides the current stage of a request: the user
+// themselves, or the approver they stand in for through a delegation. It returns 0 if the user cannot decide.
+func (l *LeaveRequestModel) deciderFor(request *LeaveRequest, userID uint) (uint, error) {
+	canDecide, err := l.canDecide(request, userID)
+	if err != nil {
+		return 0, err
+	}
+	if canDecide {
+		return userID, nil
+	}
+	if request.UserID == userID {
+		return 0, nil
+	}
+	return l.delegatorFor(request, userID)
+}
+
+// delegatorFor returns the approver on whose behalf a delegate may decide a request, or 0 if there is none
+func (l *LeaveRequestModel) delegatorFor(request *LeaveRequest, delegateID uint) (uint, error) {
+	delegations, err := NewApprovalDelegationModel(l.db).GetActiveDelegationsTo(delegateID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delegation := range delegations {
+		// Delegates never decide the delegator's own leave
+		if !delegation.LeaveTypes.Contains(request.LeaveType) || delegation.DelegatorID == request.UserID {
+			continue
+		}
+		canDecide, err := l.canDecide(request, delegation.DelegatorID)
+		if err != nil {
+			return 0, err
+		}
+		if canDecide {
+			return delegation.DelegatorID, nil
+		}
+	}
+	return 0, nil
+}
+
+// isEscalatedApprover reports whether a request was escalated to the approver, directly or through their group
+func (l *LeaveRequestModel) isEscalatedApprover(request *LeaveRequest, approverID uint) (bool, error) {
+	if request.EscalatedToID != nil {
+		return *request.EscalatedToID == approverID, nil
+	}
+	if request.EscalatedToGroup == "" {
+		return false, nil
+	}
+	return NewUserModel(l.db).HasUserPermission(approverID, stageApprovalPermission(request.EscalatedToGroup))
+}
+
+// HasDecided reports whether a user decided a stage of a request, for example as the approver it was escalated to
+func (l *LeaveRequestModel) HasDecided(requestID, userID uint) (bool, error) {
+	var count int64
+	if err := l.db.Model(&LeaveApprovalEvent{}).
+		Where("leave_request_id = ? AND to_approver_id = ? AND event_type IN ?", requestID, userID,
+			[]ApprovalEventType{ApprovalEventApproved, ApprovalEventRejected}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// IsApprover reports whether a user takes part in approving a request: its team lead, the approver it was
+// escalated to, HR and management approvers, or a delegate who may currently decide it
+func (l *LeaveRequestModel) IsApprover(request *LeaveRequest, userID uint) (bool, error) {
+	if (request.TeamLeadID != nil && *request.TeamLeadID == userID) ||
+		(request.EscalatedToID != nil && *request.EscalatedToID == userID) {
+		return true, nil
+	}
+	userModel := NewUserModel(l.db)
+	for _, permission := range []string{"APPROVE_LEAVE_HR", "APPROVE_LEAVE_MANAGEMENT"} {
+		hasPermission, err := userModel.HasUserPermission(userID, permission)
+		if err != nil || hasPermission {
+			return hasPermission, err
+		}
+	}
+	if request.UserID == userID {
+		return false, nil
+	}
+	delegatorID, err := l.delegatorFor(request, userID)
+	return delegatorID != 0, err
 }
 
 // GetLeaveRequestWorkflowStatus returns the current workflow status and next approver
@@ -517,6 +855,9 @@ func (l *LeaveRequestModel) GetLeaveRequestWorkflowStatus(requestID uint) (map[s
 		"next_approver":       nil,
 		"is_final":            false,
 		"requires_management": false,
+		"escalated_to_id":     request.EscalatedToID,
+		"escalated_to_group":  request.EscalatedToGroup,
+		"advance_days":        request.AdvanceDays,
 	}
 
 	switch request.Status {
@@ -529,16 +870,12 @@ func (l *LeaveRequestModel) GetLeaveRequestWorkflowStatus(requestID uint) (map[s
 
 	case StatusTeamLeadApproved:
 		status["next_approver"] = "hr"
-		if request.DaysRequested > 4 {
+		if request.RequiresManagementApproval() {
 			status["requires_management"] = true
 		}
 
 	case StatusHRApproved:
-		if request.DaysRequested > 4 {
-			status["next_approver"] = "management"
-		} else {
-			status["is_final"] = true
-		}
+		status["next_approver"] = "management"
 
 	case StatusManagementApproved:
 		status["is_final"] = true
@@ -560,6 +897,20 @@ func (l *LeaveRequestModel) GetLeaveRequestTimeline(requestID uint) ([]map[strin
 		return nil, err
 	}
 
+	// Reminders, escalations, decisions and automatic decisions
+	events, err := NewApprovalSLAModel(l.db).GetApprovalEvents(requestID)
+	if err != nil {
+		return nil, err
+	}
+	decisions := make(map[ApprovalStage]LeaveApprovalEvent)
+	for _, event := range events {
+		if event.EventType.IsDecision() {
+			decisions[event.Stage] = event
+		}
+	}
+	// Stages shown from the request itself; their decision events only name who decided
+	shownStages := make(map[ApprovalStage]bool)
+
 	timeline := []map[string]interface{}{}
 
 	// Request submitted
@@ -578,14 +929,19 @@ func (l *LeaveRequestModel) GetLeaveRequestTimeline(requestID uint) ([]map[strin
 			action = "rejected"
 		}
 
-		timeline = append(timeline, map[string]interface{}{
+		entry := map[string]interface{}{
 			"action":    action,
 			"timestamp": *request.TeamLeadApprovedAt,
 			"user_id":   *request.TeamLeadID,
 			"user_name": request.TeamLead.FirstName + " " + request.TeamLead.LastName,
 			"comments":  request.TeamLeadComments,
 			"level":     "team_lead",
-		})
+		}
+		if event, ok := decisions[StageTeamLead]; ok {
+			l.setTimelineActor(entry, event)
+		}
+		timeline = append(timeline, entry)
+		shownStages[StageTeamLead] = true
 	}
 
 	// HR approval/rejection
@@ -595,14 +951,19 @@ func (l *LeaveRequestModel) GetLeaveRequestTimeline(requestID uint) ([]map[strin
 			action = "rejected"
 		}
 
-		timeline = append(timeline, map[string]interface{}{
+		entry := map[string]interface{}{
 			"action":    action,
 			"timestamp": *request.HRApprovedAt,
-			"user_id":   0, // HR user ID would need to be stored
+			"user_id":   0, // Decided before decisions were recorded as events
 			"user_name": "HR",
 			"comments":  request.HRComments,
 			"level":     "hr",
-		})
+		}
+		if event, ok := decisions[StageHR]; ok {
+			l.setTimelineActor(entry, event)
+		}
+		timeline = append(timeline, entry)
+		shownStages[StageHR] = true
 	}
 
 	// Management approval/rejection
@@ -612,19 +973,74 @@ func (l *LeaveRequestModel) GetLeaveRequestTimeline(requestID uint) ([]map[strin
 			action = "rejected"
 		}
 
-		timeline = append(timeline, map[string]interface{}{
+		entry := map[string]interface{}{
 			"action":    action,
 			"timestamp": *request.ManagementApprovedAt,
-			"user_id":   0, // Management user ID would need to be stored
+			"user_id":   0, // Decided before decisions were recorded as events
 			"user_name": "Management",
 			"comments":  request.ManagementComments,
 			"level":     "management",
-		})
+		}
+		if event, ok := decisions[StageManagement]; ok {
+			l.setTimelineActor(entry, event)
+		}
+		timeline = append(timeline, entry)
+		shownStages[StageManagement] = true
 	}
 
+	for _, event := range events {
+		if event.EventType.IsDecision() && shownStages[event.Stage] {
+			continue
+		}
+		entry := map[string]interface{}{
+			"action":    strings.ToLower(string(event.EventType)),
+			"timestamp": event.CreatedAt,
+			"user_id":   0,
+			"user_name": "System",
+			"comments":  event.Note,
+			"level":     strings.ToLower(string(event.Stage)),
+		}
+		if event.EventType.IsUserAction() && event.ToApproverID != nil {
+			l.setTimelineActor(entry, event)
+		} else if event.ToApproverID != nil {
+			entry["to_user_id"] = *event.ToApproverID
+		}
+		if event.ToGroup != "" {
+			entry["to_group"] = strings.ToLower(string(event.ToGroup))
+		}
+		timeline = append(timeline, entry)
+	}
+
+	sort.SliceStable(timeline, func(a, b int) bool {
+		return timeline[a]["timestamp"].(time.Time).Before(timeline[b]["timestamp"].(time.Time))
+	})
+
 	return timeline, nil
 }
 
+// setTimelineActor names the user who acted in a timeline entry; a delegate stood in for the approver named as
+// on_behalf_of
+func (l *LeaveRequestModel) setTimelineActor(entry map[string]interface{}, event LeaveApprovalEvent) {
+	if event.ToApproverID == nil {
+		return
+	}
+	entry["user_id"] = *event.ToApproverID
+	entry["user_name"] = l.userName(*event.ToApproverID)
+	if event.FromApproverID != nil && *event.FromApproverID != *event.ToApproverID {
+		entry["on_behalf_of"] = *event.FromApproverID
+		entry["on_behalf_of_name"] = l.userName(*event.FromApproverID)
+	}
+}
+
+// userName returns a user's full name for timelines, or an empty string if the user is gone
+func (l *LeaveRequestModel) userName(userID uint) string {
+	var user User
+	if err := l.db.Select("first_name", "last_name").First(&user, userID).Error; err != nil {
+		return ""
+	}
+	return user.FirstName + " " + user.LastName
+}
+
 // GetLeaveRequestSummary returns a summary of leave requests for reporting
 func (l *LeaveRequestModel) GetLeaveRequestSummary(year int) (map[string]interface{}, error) {
 	startOfYear := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -662,6 +1078,25 @@ func (l *LeaveRequestModel) GetLeaveRequestSummary(year int) (map[string]interfa
 	}
 	summary["by_type"] = typeCounts
 
+	// Split the days of decided requests into paid and unpaid leave
+	definitions, err := NewLeaveTypeDefinitionModel(l.db).GetLeaveTypeMap()
+	if err != nil {
+		return nil, err
+	}
+	payDays := map[string]int{"paid": 0, "unpaid": 0}
+	for _, request := range requests {
+		if !request.Status.IsBalanceDeducted() {
+			continue
+		}
+		if definition, ok := definitions[request.LeaveType]; ok && !definition.IsPaid {
+			payDays["unpaid"] += request.DaysRequested
+		} else {
+			payDays["paid"] += request.DaysRequested
+		}
+	}
+	summary["approved_days_by_pay"] = payDays
+	summary["leave_types"] = definitions
+
 	// Count by month
 	monthCounts := make(map[int]int)
 	for _, request := range requests {
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownLeaveType           = errors.New("unknown leave type")
+	ErrInactiveLeaveType          = errors.New("leave type is not active")
+	ErrInvalidLeaveTypeDefinition = errors.New("invalid leave type")
+)
+
+var (
+	leaveTypeCodePattern  = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,29}$`)
+	leaveTypeColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// LeaveTypeDefinition describes a leave type that can be requested. Policies, balances and requests refer to
+// it by code; the LeaveType constants are seeded as the default definitions.
+type LeaveTypeDefinition struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Code           LeaveType `gorm:"not null;uniqueIndex" json:"code"` // E.g. BEREAVEMENT, never changes once created
+	DisplayName    string    `gorm:"not null" json:"display_name"`
+	Description    string    `gorm:"type:text" json:"description,omitempty"`
+	Color          string    `gorm:"not null;default:'#607D8B'" json:"color"` // Hex color used by calendars, e.g. #4CAF50
+	IsPaid         bool      `gorm:"default:true" json:"is_paid"`
+	DeductsBalance bool      `gorm:"default:true" json:"deducts_balance"` // Whether requests need and use up a balance
+	IsActive       bool      `gorm:"default:true" json:"is_active"`       // Inactive types cannot be requested anymore
+	SortOrder      int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Validate checks the code and color formats
+func (d *LeaveTypeDefinition) Validate() error {
+	if !leaveTypeCodePattern.MatchString(string(d.Code)) {
+		return fmt.Errorf("%w: code must be 2 to 30 upper-case letters, digits or underscores starting with a letter",
+			ErrInvalidLeaveTypeDefinition)
+	}
+	if d.DisplayName == "" {
+		return fmt.Errorf("%w: display_name is required", ErrInvalidLeaveTypeDefinition)
+	}
+	if !leaveTypeColorPattern.MatchString(d.Color) {
+		return fmt.Errorf("%w: color must be a hex color such as #4CAF50", ErrInvalidLeaveTypeDefinition)
+	}
+	return nil
+}
+
+// defaultLeaveTypeDefinitions are the built-in leave types
+var defaultLeaveTypeDefinitions = []LeaveTypeDefinition{
+	{Code: LeaveTypeAnnual, DisplayName: "Annual Leave", Color: "#4CAF50", IsPaid: true, DeductsBalance: true, SortOrder: 10},
+	{Code: LeaveTypeSick, DisplayName: "Sick Leave", Color: "#F44336", IsPaid: true, DeductsBalance: true, SortOrder: 20},
+	{Code: LeaveTypePersonal, DisplayName: "Personal Leave", Color: "#2196F3", IsPaid: true, DeductsBalance: true, SortOrder: 30},
+	{Code: LeaveTypeEmergency, DisplayName: "Emergency Leave", Color: "#FF9800", IsPaid: true, DeductsBalance: true, SortOrder: 40},
+	{Code: LeaveTypeMaternity, DisplayName: "Maternity Leave", Color: "#E91E63", IsPaid: true, DeductsBalance: true, SortOrder: 50},
+	{Code: LeaveTypePaternity, DisplayName: "Paternity Leave", Color: "#9C27B0", IsPaid: true, DeductsBalance: true, SortOrder: 60},
+	{Code: LeaveTypeUnpaid, DisplayName: "Unpaid Leave", Color: "#9E9E9E", IsPaid: false, DeductsBalance: false, SortOrder: 70},
+	{Code: LeaveTypeCompOff, DisplayName: "Compensatory Time Off", Color: "#009688", IsPaid: true, DeductsBalance: true, SortOrder: 80},
+}
+
+// LeaveTypeDefinitionModel handles leave type definition database operations
+type LeaveTypeDefinitionModel struct {
+	db *gorm.DB
+}
+
+func NewLeaveTypeDefinitionModel(db *gorm.DB) *LeaveTypeDefinitionModel {
+	return &LeaveTypeDefinitionModel{
+		db: db,
+	}
+}
+
+// SeedDefaultLeaveTypes adds the built-in leave types that are missing, leaving changed ones as they are
+func (d *LeaveTypeDefinitionModel) SeedDefaultLeaveTypes() error {
+	for _, definition := range defaultLeaveTypeDefinitions {
+		definition.IsActive = true
+		if err := d.db.Select("*").
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).
+			Create(&definition).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLeaveTypes lists the leave types in display order, only the active ones unless includeInactive is set
+func (d *LeaveTypeDefinitionModel) GetLeaveTypes(includeInactive bool) ([]LeaveTypeDefinition, error) {
+	query := d.db.Model(&LeaveTypeDefinition{})
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var definitions []LeaveTypeDefinition
+	if err := query.Order("sort_order ASC, code ASC").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// GetLeaveType retrieves a leave type by code, returning ErrUnknownLeaveType when there is none
+func (d *LeaveTypeDefinitionModel) GetLeaveType(code LeaveType) (*LeaveTypeDefinition, error) {
+	var definition LeaveTypeDefinition
+	if err := d.db.Where("code = ?", code).First(&definition).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownLeaveType, code)
+		}
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// GetActiveLeaveType retrieves a leave type that can currently be requested
+func (d *LeaveTypeDefinitionModel) GetActiveLeaveType(code LeaveType) (*LeaveTypeDefinition, error) {
+	definition, err := d.GetLeaveType(code)
+	if err != nil {
+		return nil, err
+	}
+	if !definition.IsActive {
+		return nil, fmt.Errorf("%w: %s", ErrInactiveLeaveType, code)
+	}
+	return definition, nil
+}
+
+// CreateLeaveType validates and stores a leave type together with an optional policy for it, which is what
+// makes the type requestable
+func (d *LeaveTypeDefinitionModel) CreateLeaveType(definition *LeaveTypeDefinition, policy *LeavePolicy) error {
+	if err := definition.Validate(); err != nil {
+		return err
+	}
+
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&LeaveTypeDefinition{}).Where("code = ?", definition.Code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: code %s is already used", ErrInvalidLeaveTypeDefinition, definition.Code)
+		}
+
+		// Select all columns so that false flags are not stored with the column defaults
+		if err := tx.Select("*").Create(definition).Error; err != nil {
+			return err
+		}
+		if policy == nil {
+			return nil
+		}
+		policy.LeaveType = definition.Code
+		return NewLeavePolicyModel(tx).CreateLeavePolicy(policy)
+	})
+}
+
+// UpdateLeaveType validates and saves a leave type; its code cannot change
+func (d *LeaveTypeDefinitionModel) UpdateLeaveType(definition *LeaveTypeDefinition) error {
+	if err := definition.Validate(); err != nil {
+		return err
+	}
+	return d.db.Save(definition).Error
+}
+
+// GetLeaveTypeMap returns all leave types, active or not, by code
+func (d *LeaveTypeDefinitionModel) GetLeaveTypeMap() (map[LeaveType]LeaveTypeDefinition, error) {
+	definitions, err := d.GetLeaveTypes(true)
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[LeaveType]LeaveTypeDefinition, len(definitions))
+	for _, definition := range definitions {
+		byCode[definition.Code] = definition
+	}
+	return byCode, nil
+}
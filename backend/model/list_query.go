@@ -0,0 +1,303 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultListLimit = 10
+	MaxListLimit     = 100
+)
+
+// ListQuery describes one page of a list endpoint: paging, sorting, filters and free-text search
+type ListQuery struct {
+	Page    int
+	Limit   int
+	Cursor  *uint             // When set, keyset pagination on id is used instead of page; 0 is the first page
+	Sort    []SortField       // Sort fields in priority order
+	Filters map[string]string // Raw filter values keyed by filter name
+	Search  string            // Free-text search term
+}
+
+// SortField is a single sort key of a list query
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Offset returns the number of rows to skip for page based pagination
+func (q ListQuery) Offset() int {
+	if q.Page < 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.Limit
+}
+
+// FilterFunc parses a raw filter value and returns the scope applying it
+type FilterFunc func(value string) (func(*gorm.DB) *gorm.DB, error)
+
+// ListSpec declares what a list endpoint allows to be sorted, filtered and searched
+type ListSpec struct {
+	SortColumns   map[string]string     // API sort field -> SQL column
+	DefaultSort   []SortField           // Used when the query has no sort
+	Filters       map[string]FilterFunc // API filter name -> filter
+	SearchColumns []string              // SQL columns matched by free-text search
+}
+
+// ListResult holds the paging information of a list query result
+type ListResult struct {
+	Total   int64
+	HasNext bool
+	HasPrev bool
+}
+
+// Validate checks a list query against the spec and returns the problems found
+func (s ListSpec) Validate(q ListQuery) []string {
+	var errs []string
+
+	for _, sort := range q.Sort {
+		if _, ok := s.SortColumns[sort.Field]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown sort field %q", sort.Field))
+		}
+	}
+
+	for name, value := range q.Filters {
+		filter, ok := s.Filters[name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("unknown filter %q", name))
+			continue
+		}
+		if _, err := filter(value); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid value for %s: %v", name, err))
+		}
+	}
+
+	if q.Cursor != nil {
+		if _, ok := s.SortColumns["id"]; !ok {
+			errs = append(errs, "cursor pagination is not supported for this list")
+		}
+		for _, sort := range q.Sort {
+			if sort.Field != "id" {
+				errs = append(errs, "cursor pagination only supports sorting by id")
+				break
+			}
+		}
+	}
+
+	return errs
+}
+
+// Paginate applies the filters, search, sorting and paging of q to db and loads the page into dest.
+// The query must have been validated against the spec beforehand.
+func Paginate(db *gorm.DB, q ListQuery, spec ListSpec, dest interface{}) (*ListResult, error) {
+	tx, err := applyListFilters(db, q, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the filtered statement reusable for both the count and the page query
+	tx = tx.Session(&gorm.Session{})
+
+	result := &ListResult{}
+	if err := tx.Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	if q.Cursor != nil {
+		idColumn := spec.SortColumns["id"]
+		desc := len(q.Sort) > 0 && q.Sort[0].Desc
+		// Cursor 0 starts the list, in either direction
+		started := *q.Cursor != 0
+		page := tx
+		if desc {
+			if started {
+				page = page.Where(idColumn+" < ?", *q.Cursor)
+			}
+			page = page.Order(idColumn + " DESC")
+		} else {
+			page = page.Where(idColumn+" > ?", *q.Cursor).Order(idColumn + " ASC")
+		}
+
+		var remaining int64
+		if err := page.Session(&gorm.Session{}).Count(&remaining).Error; err != nil {
+			return nil, err
+		}
+		if err := page.Limit(q.Limit).Find(dest).Error; err != nil {
+			return nil, err
+		}
+
+		result.HasNext = remaining > int64(q.Limit)
+		result.HasPrev = started
+		return result, nil
+	}
+
+	page := applyListSort(tx, q, spec)
+	if err := page.Offset(q.Offset()).Limit(q.Limit).Find(dest).Error; err != nil {
+		return nil, err
+	}
+
+	result.HasNext = int64(q.Offset()+q.Limit) < result.Total
+	result.HasPrev = q.Page > 1
+	return result, nil
+}
+
+// FindAll applies the filters, search and sorting of q to db and loads every matching row into dest.
+// Paging is ignored; it is meant for exports where the whole result set is needed.
+func FindAll(db *gorm.DB, q ListQuery, spec ListSpec, dest interface{}) error {
+	tx, err := applyListFilters(db, q, spec)
+	if err != nil {
+		return err
+	}
+	return applyListSort(tx, q, spec).Find(dest).Error
+}
+
+// applyListFilters applies the filter scopes and free-text search of q
+func applyListFilters(db *gorm.DB, q ListQuery, spec ListSpec) (*gorm.DB, error) {
+	tx := db
+	for name, value := range q.Filters {
+		filter, ok := spec.Filters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", name)
+		}
+		scope, err := filter(value)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Scopes(scope)
+	}
+
+	if q.Search != "" && len(spec.SearchColumns) > 0 {
+		tx = tx.Scopes(searchScope(spec.SearchColumns, q.Search))
+	}
+	return tx, nil
+}
+
+// applyListSort orders by the sort fields of q, or the spec default, with id as tiebreaker
+func applyListSort(db *gorm.DB, q ListQuery, spec ListSpec) *gorm.DB {
+	tx := db
+	sorts := q.Sort
+	if len(sorts) == 0 {
+		sorts = spec.DefaultSort
+	}
+	sortedByID := false
+	for _, sort := range sorts {
+		column := spec.SortColumns[sort.Field]
+		if sort.Field == "id" {
+			sortedByID = true
+		}
+		if sort.Desc {
+			tx = tx.Order(column + " DESC")
+		} else {
+			tx = tx.Order(column + " ASC")
+		}
+	}
+	// Break ties on id so pages stay stable
+	if idColumn, ok := spec.SortColumns["id"]; ok && !sortedByID {
+		tx = tx.Order(idColumn + " ASC")
+	}
+	return tx
+}
+
+// searchScope matches the term case-insensitively against any of the columns
+func searchScope(columns []string, term string) func(*gorm.DB) *gorm.DB {
+	pattern := "%" + escapeLike(term) + "%"
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = column + " ILIKE ?"
+		args[i] = pattern
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+}
+
+// escapeLike escapes LIKE wildcards in a user supplied term
+func escapeLike(term string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(term)
+}
+
+// BoolFilter filters a boolean column
+func BoolFilter(column string) FilterFunc {
+	return func(value string) (func(*gorm.DB) *gorm.DB, error) {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" = ?", parsed)
+		}, nil
+	}
+}
+
+// UintFilter filters a numeric ID column
+func UintFilter(column string) FilterFunc {
+	return func(value string) (func(*gorm.DB) *gorm.DB, error) {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("expected a positive number")
+		}
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" = ?", uint(parsed))
+		}, nil
+	}
+}
+
+// EnumFilter filters a string column against a fixed set of allowed values
+func EnumFilter(column string, allowed ...string) FilterFunc {
+	return func(value string) (func(*gorm.DB) *gorm.DB, error) {
+		value = strings.ToUpper(value)
+		for _, a := range allowed {
+			if a == value {
+				return func(db *gorm.DB) *gorm.DB {
+					return db.Where(column+" = ?", value)
+				}, nil
+			}
+		}
+		return nil, fmt.Errorf("expected one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// TimeAfterFilter keeps rows whose column is on or after the given date
+func TimeAfterFilter(column string) FilterFunc {
+	return func(value string) (func(*gorm.DB) *gorm.DB, error) {
+		parsed, err := ParseQueryTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" >= ?", parsed)
+		}, nil
+	}
+}
+
+// TimeBeforeFilter keeps rows whose column is on or before the given date
+func TimeBeforeFilter(column string) FilterFunc {
+	return func(value string) (func(*gorm.DB) *gorm.DB, error) {
+		parsed, err := ParseQueryTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" <= ?", parsed)
+		}, nil
+	}
+}
+
+// ParseQueryTime parses a date given as YYYY-MM-DD or RFC 3339
+func ParseQueryTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 date")
+	}
+	return parsed, nil
+}
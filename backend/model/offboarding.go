@@ -0,0 +1,402 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeaveSettlement is the final leave balance of an offboarded user for one leave type
+type LeaveSettlement struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `gorm:"not null;index" json:"user_id"`
+	LeaveType       LeaveType `gorm:"not null" json:"leave_type"`
+	Year            int       `gorm:"not null" json:"year"`
+	TerminationDate time.Time `gorm:"not null" json:"termination_date"`
+	TotalAllocated  int       `gorm:"not null;default:0" json:"total_allocated"`
+	EarnedDays      float64   `gorm:"not null;default:0" json:"earned_days"` // Allocation pro-rated up to the termination date
+	CarryOverDays   int       `gorm:"not null;default:0" json:"carry_over_days"`
+	UsedDays        int       `gorm:"not null;default:0" json:"used_days"`       // Used days after future leave is cancelled
+	SettlementDays  float64   `gorm:"not null;default:0" json:"settlement_days"` // Positive is owed to the employee, negative is recovered
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// OffboardingLeaveChange describes a leave request that is cancelled or shortened by offboarding
+type OffboardingLeaveChange struct {
+	LeaveRequestID uint               `json:"leave_request_id"`
+	LeaveType      LeaveType          `json:"leave_type"`
+	Status         LeaveRequestStatus `json:"status"`
+	StartDate      time.Time          `json:"start_date"`
+	EndDate        time.Time          `json:"end_date"`
+	NewEndDate     *time.Time         `json:"new_end_date,omitempty"` // Set when the request is shortened instead of cancelled
+	NewDays        int                `json:"new_days,omitempty"`
+	RestoredDays   int                `json:"restored_days"` // Days given back to the balance
+}
+
+// OffboardingTeamChange describes a team whose lead is handed over to the successor
+type OffboardingTeamChange struct {
+	TeamID   uint   `json:"team_id"`
+	TeamName string `json:"team_name"`
+}
+
+// OffboardingPlan lists everything an offboarding changes; it is returned as-is for a dry run
+type OffboardingPlan struct {
+	UserID              uint                     `json:"user_id"`
+	SuccessorID         *uint                    `json:"successor_id,omitempty"`
+	TerminationDate     time.Time                `json:"termination_date"`
+	Reason              string                   `json:"reason,omitempty"`
+	DeactivateNow       bool                     `json:"deactivate_now"` // False when login is disabled later, the day after the termination date
+	CancelledRequests   []OffboardingLeaveChange `json:"cancelled_requests"`
+	ShortenedRequests   []OffboardingLeaveChange `json:"shortened_requests"`
+	ReassignedApprovals []uint                   `json:"reassigned_approvals"` // Pending leave requests handed to the successor
+	ReassignedTeams     []OffboardingTeamChange  `json:"reassigned_teams"`
+	GrantTeamLeadRole   bool                     `json:"grant_team_lead_role"` // Successor receives the TEAM_LEAD role
+	Settlements         []LeaveSettlement        `json:"settlements"`
+}
+
+// OffboardingModel handles employee offboarding
+type OffboardingModel struct {
+	db *gorm.DB
+}
+
+func NewOffboardingModel(db *gorm.DB) *OffboardingModel {
+	return &OffboardingModel{
+		db: db,
+	}
+}
+
+// PlanOffboarding works out what offboarding a user at the termination date changes, without writing anything
+func (o *OffboardingModel) PlanOffboarding(userID uint, terminationDate time.Time, successorID *uint, reason string) (*OffboardingPlan, error) {
+	var user User
+	if err := o.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.TerminationDate != nil {
+		return nil, fmt.Errorf("user is already offboarded with termination date %s", user.TerminationDate.Format("2006-01-02"))
+	}
+
+	terminationDate = time.Date(terminationDate.Year(), terminationDate.Month(), terminationDate.Day(), 0, 0, 0, 0, time.UTC)
+	plan := &OffboardingPlan{
+		UserID:              userID,
+		SuccessorID:         successorID,
+		TerminationDate:     terminationDate,
+		Reason:              reason,
+		DeactivateNow:       terminationDate.Before(truncateToDate(time.Now())),
+		CancelledRequests:   []OffboardingLeaveChange{},
+		ShortenedRequests:   []OffboardingLeaveChange{},
+		ReassignedApprovals: []uint{},
+		ReassignedTeams:     []OffboardingTeamChange{},
+		Settlements:         []LeaveSettlement{},
+	}
+
+	// Leave after the termination date is cancelled, leave running across it ends on it
+	var requests []LeaveRequest
+	if err := o.db.Where("user_id = ? AND status IN ? AND end_date > ?", userID, activeLeaveStatuses, terminationDate).
+		Order("start_date ASC").
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+
+	// Days restored per year and leave type, used to settle the termination year
+	restored := make(map[int]map[LeaveType]int)
+	addRestored := func(year int, leaveType LeaveType, days int) {
+		if restored[year] == nil {
+			restored[year] = make(map[LeaveType]int)
+		}
+		restored[year][leaveType] += days
+	}
+
+	for _, request := range requests {
+		change := OffboardingLeaveChange{
+			LeaveRequestID: request.ID,
+			LeaveType:      request.LeaveType,
+			Status:         request.Status,
+			StartDate:      request.StartDate,
+			EndDate:        request.EndDate,
+		}
+
+		if request.StartDate.After(terminationDate) {
+			if request.Status.IsBalanceDeducted() {
+				change.RestoredDays = request.DaysRequested
+				addRestored(request.StartDate.Year(), request.LeaveType, change.RestoredDays)
+			}
+			plan.CancelledRequests = append(plan.CancelledRequests, change)
+			continue
+		}
+
+		newEnd := terminationDate
+		change.NewEndDate = &newEnd
+		change.NewDays = int(newEnd.Sub(request.StartDate).Hours()/24) + 1
+		if request.Status.IsBalanceDeducted() && request.DaysRequested > change.NewDays {
+			change.RestoredDays = request.DaysRequested - change.NewDays
+			addRestored(request.StartDate.Year(), request.LeaveType, change.RestoredDays)
+		}
+		plan.ShortenedRequests = append(plan.ShortenedRequests, change)
+	}
+
+	// Approvals waiting on the user and the teams they lead go to the successor
+	if err := o.db.Model(&LeaveRequest{}).
+		Where("team_lead_id = ? AND status = ? AND user_id <> ?", userID, StatusPending, userID).
+		Order("id ASC").
+		Pluck("id", &plan.ReassignedApprovals).Error; err != nil {
+		return nil, err
+	}
+	if plan.ReassignedApprovals == nil {
+		plan.ReassignedApprovals = []uint{}
+	}
+
+	var teams []Team
+	if err := o.db.Where("team_lead_id = ?", userID).Order("id ASC").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		plan.ReassignedTeams = append(plan.ReassignedTeams, OffboardingTeamChange{TeamID: team.ID, TeamName: team.Name})
+	}
+
+	if len(plan.ReassignedApprovals) > 0 || len(plan.ReassignedTeams) > 0 {
+		if successorID == nil {
+			return nil, fmt.Errorf("a successor is required: the user leads %d teams and has %d pending approvals",
+				len(plan.ReassignedTeams), len(plan.ReassignedApprovals))
+		}
+	}
+
+	if successorID != nil {
+		if *successorID == userID {
+			return nil, fmt.Errorf("successor must be a different user")
+		}
+		var successor User
+		if err := o.db.First(&successor, *successorID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Errorf("successor not found")
+			}
+			return nil, err
+		}
+		if !successor.IsActiveUser || successor.TerminationDate != nil {
+			return nil, fmt.Errorf("successor must be an active user")
+		}
+
+		if len(plan.ReassignedTeams) > 0 {
+			var count int64
+			if err := o.db.Table("user_roles").
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Where("user_roles.user_id = ? AND roles.name = ?", *successorID, "TEAM_LEAD").
+				Count(&count).Error; err != nil {
+				return nil, err
+			}
+			plan.GrantTeamLeadRole = count == 0
+		}
+	}
+
+	// Final settlement of the termination year
+	year := terminationDate.Year()
+	var balances []LeaveBalance
+	if err := o.db.Where("user_id = ? AND year = ?", userID, year).Order("leave_type ASC").Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	policyModel := NewLeavePolicyModel(o.db)
+
+	for _, balance := range balances {
+		used := balance.UsedDays - restored[year][balance.LeaveType]
+		if used < 0 {
+			used = 0
+		}
+
+		// Earned days follow the policy's pro-rata rule for employment ending on the termination date
+		var earned float64
+		policy, err := policyModel.GetLeavePolicyByTypeAndYear(balance.LeaveType, year)
+		switch {
+		case err == nil:
+			earned = float64(policy.ProRatedAllocation(user.EmploymentStartDate, &terminationDate))
+		case err == gorm.ErrRecordNotFound:
+			// Without a policy, scale the current allocation down to the termination date
+			if full := EmploymentFraction(year, user.EmploymentStartDate, nil); full > 0 {
+				earned = roundDays(float64(balance.TotalAllocated) * EmploymentFraction(year, user.EmploymentStartDate, &terminationDate) / full)
+			}
+		default:
+			return nil, err
+		}
+		if err == nil {
+			// Comp-off days were earned by work already done and settled advances were already taken, so
+			// neither is pro-rated
+			extra, err := NewLeaveBalanceModel(o.db).nonPolicyAllocation(userID, year, balance.LeaveType)
+			if err != nil {
+				return nil, err
+			}
+			earned += float64(extra)
+		}
+
+		plan.Settlements = append(plan.Settlements, LeaveSettlement{
+			UserID:          userID,
+			LeaveType:       balance.LeaveType,
+			Year:            year,
+			TerminationDate: terminationDate,
+			TotalAllocated:  balance.TotalAllocated,
+			EarnedDays:      earned,
+			CarryOverDays:   balance.CarryOverDays,
+			UsedDays:        used,
+			SettlementDays:  roundDays(earned + float64(balance.CarryOverDays) - float64(used)),
+		})
+	}
+
+	return plan, nil
+}
+
+// Offboard plans and carries out the offboarding of a user in a single transaction. The user and their active
+// leave requests are locked before the plan is made, so a concurrent cancellation, decision or early return
+// either happens before and is part of the plan, or waits and finds the request changed. It returns the plan
+// that was applied.
+func (o *OffboardingModel) Offboard(userID uint, terminationDate time.Time, successorID *uint, reason string) (*OffboardingPlan, error) {
+	var plan *OffboardingPlan
+	err := o.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&User{}, userID).Error; err != nil {
+			return err
+		}
+		var requests []LeaveRequest
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND status IN ?", userID, activeLeaveStatuses).
+			Find(&requests).Error; err != nil {
+			return err
+		}
+
+		txModel := NewOffboardingModel(tx)
+		var err error
+		plan, err = txModel.PlanOffboarding(userID, terminationDate, successorID, reason)
+		if err != nil {
+			return err
+		}
+		return txModel.applyOffboarding(plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// applyOffboarding carries out a plan from PlanOffboarding. Requests are only changed while they are still
+// active with the planned dates.
+func (o *OffboardingModel) applyOffboarding(plan *OffboardingPlan) error {
+	return o.db.Transaction(func(tx *gorm.DB) error {
+		balanceModel := NewLeaveBalanceModel(tx)
+		calendarModel := NewLeaveCalendarModel(tx)
+
+		for _, change := range plan.CancelledRequests {
+			if err := planned(tx.Model(&LeaveRequest{}).
+				Where("id = ? AND status = ? AND end_date = ?", change.LeaveRequestID, change.Status, change.EndDate).
+				Update("status", StatusCancelled)); err != nil {
+				return err
+			}
+			if err := calendarModel.UpdateCalendarEntryStatus(change.LeaveRequestID, StatusCancelled); err != nil {
+				return err
+			}
+			if change.RestoredDays > 0 {
+				if err := balanceModel.DecrementUsedDays(plan.UserID, change.StartDate.Year(), change.LeaveType, change.RestoredDays, change.LeaveRequestID); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, change := range plan.ShortenedRequests {
+			if err := planned(tx.Model(&LeaveRequest{}).
+				Where("id = ? AND status = ? AND end_date = ?", change.LeaveRequestID, change.Status, change.EndDate).
+				Updates(map[string]interface{}{
+					"end_date":       *change.NewEndDate,
+					"days_requested": change.NewDays,
+				})); err != nil {
+				return err
+			}
+			if err := tx.Where("leave_request_id = ? AND date > ?", change.LeaveRequestID, *change.NewEndDate).
+				Delete(&LeaveCalendarEntry{}).Error; err != nil {
+				return err
+			}
+			if change.RestoredDays > 0 {
+				if err := balanceModel.DecrementUsedDays(plan.UserID, change.StartDate.Year(), change.LeaveType, change.RestoredDays, change.LeaveRequestID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if plan.SuccessorID != nil {
+			successorID := *plan.SuccessorID
+			if len(plan.ReassignedApprovals) > 0 {
+				if err := tx.Model(&LeaveRequest{}).Where("id IN ?", plan.ReassignedApprovals).
+					Update("team_lead_id", successorID).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, team := range plan.ReassignedTeams {
+				if err := tx.Model(&Team{}).Where("id = ?", team.TeamID).Update("team_lead_id", successorID).Error; err != nil {
+					return err
+				}
+				// The new lead must also be a member of the team
+				member := TeamMember{TeamID: team.TeamID, UserID: successorID}
+				if err := tx.Where(member).FirstOrCreate(&member).Error; err != nil {
+					return err
+				}
+			}
+
+			if plan.GrantTeamLeadRole {
+				var role Role
+				if err := tx.Where("name = ?", "TEAM_LEAD").First(&role).Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) ON CONFLICT DO NOTHING", successorID, role.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for i := range plan.Settlements {
+			if err := tx.Create(&plan.Settlements[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"termination_date":    plan.TerminationDate,
+			"termination_reason":  plan.Reason,
+			"successor_id":        plan.SuccessorID,
+			"employment_end_date": plan.TerminationDate,
+		}
+		if plan.DeactivateNow {
+			updates["is_active_user"] = false
+			updates["refresh_token"] = ""
+			updates["refresh_token_expiry"] = nil
+		}
+		if err := tx.Model(&User{}).Where("id = ?", plan.UserID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// Allocations now end at the termination date
+		return balanceModel.RecalculateUserLeaveAllocations(plan.UserID)
+	})
+}
+
+// planned checks that an offboarding update found the request as it was planned
+func planned(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("leave changed while the user was being offboarded")
+	}
+	return nil
+}
+
+// GetLeaveSettlements returns the final leave settlement of an offboarded user
+func (o *OffboardingModel) GetLeaveSettlements(userID uint) ([]LeaveSettlement, error) {
+	var settlements []LeaveSettlement
+	if err := o.db.Where("user_id = ?", userID).Order("leave_type ASC").Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+// roundDays rounds a number of days to two decimals
+func roundDays(days float64) float64 {
+	return math.Round(days*100) / 100
+}
@@ -0,0 +1,374 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PayrollLineType represents what a payroll line pays or deducts
+type PayrollLineType string
+
+const (
+	PayrollUnpaidLeave PayrollLineType = "UNPAID_LEAVE" // Deduction for approved leave of an unpaid type
+	PayrollEncashment  PayrollLineType = "ENCASHMENT"   // Payout of approved leave encashments
+)
+
+var (
+	ErrInvalidPayrollPeriod = errors.New("invalid payroll period")
+	ErrPayrollPeriodLocked  = errors.New("payroll period is locked")
+)
+
+// PayrollPeriod is a month whose payroll export was locked. Its lines are stored as exported; changes to the
+// month made afterwards are paid or deducted as adjustments in the next open period.
+type PayrollPeriod struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	Period     string        `gorm:"not null;uniqueIndex" json:"period"` // YYYY-MM
+	LockedByID uint          `gorm:"not null" json:"locked_by_id"`
+	LockedAt   time.Time     `gorm:"not null" json:"locked_at"`
+	Lines      []PayrollLine `gorm:"foreignKey:PayrollPeriodID" json:"lines,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// PayrollLine is one amount to pay or deduct for a user in a payroll period
+type PayrollLine struct {
+	ID              uint            `gorm:"primaryKey" json:"-"`
+	PayrollPeriodID uint            `gorm:"not null;index" json:"-"`
+	Period          string          `gorm:"not null" json:"period"`           // Month the line is paid in
+	ForPeriod       string          `gorm:"not null;index" json:"for_period"` // Month the line concerns, earlier for adjustments
+	UserID          uint            `gorm:"not null" json:"user_id"`
+	Email           string          `json:"email"`
+	FullName        string          `json:"full_name"`
+	LineType        PayrollLineType `gorm:"not null" json:"line_type"`
+	LeaveType       LeaveType       `gorm:"not null" json:"leave_type"`
+	Days            int             `gorm:"not null" json:"days"`
+	DailyRate       float64         `gorm:"not null" json:"daily_rate"`
+	Amount          float64         `gorm:"not null" json:"amount"` // Negative for deductions
+	Currency        string          `json:"currency"`
+	IsAdjustment    bool            `gorm:"default:false" json:"is_adjustment"`
+}
+
+// PayrollExport lists the payroll lines of a period with the net amount per currency
+type PayrollExport struct {
+	Period     string             `json:"period"`
+	Locked     bool               `json:"locked"`
+	LockedByID *uint              `json:"locked_by_id,omitempty"`
+	LockedAt   *time.Time         `json:"locked_at,omitempty"`
+	Lines      []PayrollLine      `json:"lines"`
+	Totals     map[string]float64 `json:"totals"`
+}
+
+// payrollKey groups the lines of one user, line type and leave type
+type payrollKey struct {
+	userID    uint
+	lineType  PayrollLineType
+	leaveType LeaveType
+}
+
+// PayrollModel builds and locks payroll exports
+type PayrollModel struct {
+	db *gorm.DB
+}
+
+func NewPayrollModel(db *gorm.DB) *PayrollModel {
+	return &PayrollModel{
+		db: db,
+	}
+}
+
+// PayrollPeriodOf returns the payroll period, YYYY-MM, a time falls in
+func PayrollPeriodOf(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// ParsePayrollPeriod returns the first day of a YYYY-MM payroll period
+func ParsePayrollPeriod(period string) (time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: expected YYYY-MM", ErrInvalidPayrollPeriod)
+	}
+	return start, nil
+}
+
+// GetLockedPeriods lists the locked payroll periods, newest first, without their lines
+func (p *PayrollModel) GetLockedPeriods() ([]PayrollPeriod, error) {
+	var periods []PayrollPeriod
+	if err := p.db.Order("period DESC").Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// GetPayrollExport returns the payroll lines of a period: as stored for a locked period, otherwise computed
+// from the current data together with adjustments for changes to locked periods
+func (p *PayrollModel) GetPayrollExport(period string) (*PayrollExport, error) {
+	if _, err := ParsePayrollPeriod(period); err != nil {
+		return nil, err
+	}
+
+	var locked PayrollPeriod
+	err := p.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("period = ?", period).First(&locked).Error
+	switch {
+	case err == nil:
+		return newPayrollExport(period, &locked, locked.Lines), nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	lines, err := p.buildLines(period)
+	if err != nil {
+		return nil, err
+	}
+	return newPayrollExport(period, nil, lines), nil
+}
+
+// LockPayrollPeriod stores the current export of a period so that it no longer changes. Only periods that have
+// started can be locked, and only once.
+func (p *PayrollModel) LockPayrollPeriod(period string, actorID uint, now time.Time) (*PayrollExport, error) {
+	start, err := ParsePayrollPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	if start.After(now) {
+		return nil, fmt.Errorf("%w: %s has not started yet", ErrInvalidPayrollPeriod, period)
+	}
+
+	var locked PayrollPeriod
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&PayrollPeriod{}).Where("period = ?", period).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %s", ErrPayrollPeriodLocked, period)
+		}
+
+		lines, err := NewPayrollModel(tx).buildLines(period)
+		if err != nil {
+			return err
+		}
+		locked = PayrollPeriod{Period: period, LockedByID: actorID, LockedAt: now, Lines: lines}
+		return tx.Create(&locked).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPayrollExport(period, &locked, locked.Lines), nil
+}
+
+// buildLines computes the lines of an open period: its own deductions and payouts, and the difference between
+// what locked earlier periods booked and what they amount to now
+func (p *PayrollModel) buildLines(period string) ([]PayrollLine, error) {
+	users := map[uint]*User{}
+	lines, err := p.periodLines(period, users)
+	if err != nil {
+		return nil, err
+	}
+
+	var earlier []PayrollPeriod
+	if err := p.db.Where("period < ?", period).Order("period ASC").Find(&earlier).Error; err != nil {
+		return nil, err
+	}
+	for _, locked := range earlier {
+		adjustments, err := p.adjustmentLines(period, locked.Period, users)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, adjustments...)
+	}
+	return lines, nil
+}
+
+// adjustmentLines returns lines, paid in period, for the changes to a locked period since it was booked
+func (p *PayrollModel) adjustmentLines(period, lockedPeriod string, users map[uint]*User) ([]PayrollLine, error) {
+	current, err := p.periodLines(lockedPeriod, users)
+	if err != nil {
+		return nil, err
+	}
+
+	var booked []PayrollLine
+	if err := p.db.Where("for_period = ?", lockedPeriod).Find(&booked).Error; err != nil {
+		return nil, err
+	}
+
+	type totals struct {
+		days   int
+		amount float64
+	}
+	diffs := map[payrollKey]*totals{}
+	var keys []payrollKey
+	add := func(key payrollKey, days int, amount float64) {
+		if diffs[key] == nil {
+			diffs[key] = &totals{}
+			keys = append(keys, key)
+		}
+		diffs[key].days += days
+		diffs[key].amount += amount
+	}
+	for _, line := range current {
+		add(payrollKey{line.UserID, line.LineType, line.LeaveType}, line.Days, line.Amount)
+	}
+	for _, line := range booked {
+		add(payrollKey{line.UserID, line.LineType, line.LeaveType}, -line.Days, -line.Amount)
+	}
+
+	var lines []PayrollLine
+	for _, key := range keys {
+		diff := diffs[key]
+		user, err := p.payrollUser(key.userID, users)
+		if err != nil {
+			return nil, err
+		}
+		line := newPayrollLine(period, lockedPeriod, user, key.lineType, key.leaveType, diff.days)
+		line.IsAdjustment = true
+		if key.lineType == PayrollUnpaidLeave {
+			// Deducted days are priced at the current salary
+			if diff.days == 0 {
+				continue
+			}
+			line.Amount = roundMoney(-float64(diff.days) * line.DailyRate)
+		} else {
+			// Encashment amounts were fixed at approval
+			line.Amount = roundMoney(diff.amount)
+			if diff.days == 0 && line.Amount == 0 {
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// periodLines computes the deductions and payouts that concern a period with the data as it is now
+func (p *PayrollModel) periodLines(period string, users map[uint]*User) ([]PayrollLine, error) {
+	start, err := ParsePayrollPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 1, -1)
+
+	// Approved leave of unpaid types, counted in calendar days within the period
+	var unpaidTypes []LeaveType
+	if err := p.db.Model(&LeaveTypeDefinition{}).Where("is_paid = ?", false).Pluck("code", &unpaidTypes).Error; err != nil {
+		return nil, err
+	}
+	var lines []PayrollLine
+	if len(unpaidTypes) > 0 {
+		var requests []LeaveRequest
+		if err := p.db.Where("status IN ? AND leave_type IN ? AND start_date <= ? AND end_date >= ?",
+			[]LeaveRequestStatus{StatusApproved, StatusManagementApproved}, unpaidTypes, end, start).
+			Order("user_id ASC, start_date ASC").
+			Find(&requests).Error; err != nil {
+			return nil, err
+		}
+
+		unpaidDays := map[payrollKey]int{}
+		var keys []payrollKey
+		for _, request := range requests {
+			from, to := truncateToDate(request.StartDate), truncateToDate(request.EndDate)
+			if from.Before(start) {
+				from = start
+			}
+			if to.After(end) {
+				to = end
+			}
+			key := payrollKey{request.UserID, PayrollUnpaidLeave, request.LeaveType}
+			if _, ok := unpaidDays[key]; !ok {
+				keys = append(keys, key)
+			}
+			unpaidDays[key] += int(to.Sub(from).Hours()/24) + 1
+		}
+		for _, key := range keys {
+			user, err := p.payrollUser(key.userID, users)
+			if err != nil {
+				return nil, err
+			}
+			line := newPayrollLine(period, period, user, PayrollUnpaidLeave, key.leaveType, unpaidDays[key])
+			line.Amount = roundMoney(-float64(line.Days) * line.DailyRate)
+			lines = append(lines, line)
+		}
+	}
+
+	// Encashments approved in the period, at the amounts fixed on approval
+	var encashments []LeaveEncashment
+	if err := p.db.Where("status = ? AND payroll_period = ?", EncashmentStatusApproved, period).
+		Order("user_id ASC, id ASC").
+		Find(&encashments).Error; err != nil {
+		return nil, err
+	}
+	index := map[payrollKey]int{}
+	for _, encashment := range encashments {
+		key := payrollKey{encashment.UserID, PayrollEncashment, encashment.LeaveType}
+		i, ok := index[key]
+		if !ok {
+			user, err := p.payrollUser(encashment.UserID, users)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, newPayrollLine(period, period, user, PayrollEncashment, encashment.LeaveType, 0))
+			i = len(lines) - 1
+			index[key] = i
+		}
+		lines[i].Days += encashment.Days
+		lines[i].Amount = roundMoney(lines[i].Amount + encashment.Amount)
+		lines[i].Currency = encashment.Currency
+		lines[i].DailyRate = roundMoney(lines[i].Amount / float64(lines[i].Days))
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].UserID < lines[j].UserID })
+	return lines, nil
+}
+
+// payrollUser loads a user once per export, including deleted and offboarded users who still get paid
+func (p *PayrollModel) payrollUser(userID uint, users map[uint]*User) (*User, error) {
+	if user, ok := users[userID]; ok {
+		return user, nil
+	}
+	var user User
+	if err := p.db.Unscoped().First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	users[userID] = &user
+	return &user, nil
+}
+
+// newPayrollLine builds a line for a user with the user's current daily rate and currency
+func newPayrollLine(period, forPeriod string, user *User, lineType PayrollLineType, leaveType LeaveType, days int) PayrollLine {
+	return PayrollLine{
+		Period:    period,
+		ForPeriod: forPeriod,
+		UserID:    user.ID,
+		Email:     user.Email,
+		FullName:  user.FirstName + " " + user.LastName,
+		LineType:  lineType,
+		LeaveType: leaveType,
+		Days:      days,
+		DailyRate: DailySalaryRate(user),
+		Currency:  user.SalaryCurrency,
+	}
+}
+
+// newPayrollExport wraps the lines of a period, adding the net amount per currency
+func newPayrollExport(period string, locked *PayrollPeriod, lines []PayrollLine) *PayrollExport {
+	export := &PayrollExport{
+		Period: period,
+		Lines:  lines,
+		Totals: map[string]float64{},
+	}
+	if export.Lines == nil {
+		export.Lines = []PayrollLine{}
+	}
+	if locked != nil {
+		export.Locked = true
+		export.LockedByID = &locked.LockedByID
+		export.LockedAt = &locked.LockedAt
+	}
+	for _, line := range lines {
+		export.Totals[line.Currency] = roundMoney(export.Totals[line.Currency] + line.Amount)
+	}
+	return export
+}
@@ -95,6 +95,21 @@ var permissions = map[uint]Permission{
 		Key:         "DELETE_USERS",
 		Description: "user can delete users",
 	},
+	26: {
+		Id:          26,
+		Key:         "OFFBOARD_USERS",
+		Description: "user can offboard employees and settle their leave",
+	},
+	27: {
+		Id:          27,
+		Key:         "SUBMIT_LEAVE_ON_BEHALF",
+		Description: "user can submit and record leave for other employees",
+	},
+	28: {
+		Id:          28,
+		Key:         "MANAGE_PAYROLL",
+		Description: "user can approve leave encashment and export and lock payroll periods",
+	},
 
 	// Team Management Permissions
 	12: {
@@ -227,6 +242,28 @@ func (p *PermissionModel) GetPermission(id uint) (*Permission, error) {
 	return &permission, nil
 }
 
+// PermissionListSpec declares the sorting, filters and search supported when listing permissions
+var PermissionListSpec = ListSpec{
+	SortColumns: map[string]string{
+		"id":         "id",
+		"key":        "key",
+		"created_at": "created_at",
+	},
+	DefaultSort:   []SortField{{Field: "id"}},
+	Filters:       map[string]FilterFunc{},
+	SearchColumns: []string{"key", "description"},
+}
+
+// ListPermissions returns one page of permissions matching the list query
+func (p *PermissionModel) ListPermissions(q ListQuery) ([]Permission, *ListResult, error) {
+	var permissions []Permission
+	result, err := Paginate(p.db.Model(&Permission{}), q, PermissionListSpec, &permissions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return permissions, result, nil
+}
+
 // UpdatePermission updates a permission in the database
 func (p *PermissionModel) UpdatePermission(permission *Permission) error {
 	return p.db.Save(permission).Error
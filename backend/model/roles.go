@@ -68,28 +68,28 @@ var predefinedRoles = map[string]Role{
 		Name:        "TEAM_LEAD",
 		Description: "Team leader with team management permissions",
 		IsActive:    true,
-		Permissions: UintArray{1, 2, 5, 6, 9, 10, 11, 13, 16, 17, 23, 24}, // All employee permissions + team lead specific + READ_USERS, UPDATE_USERS
+		Permissions: UintArray{1, 2, 5, 6, 9, 10, 11, 13, 16, 17, 23, 24, 27}, // All employee permissions + team lead specific + READ_USERS, UPDATE_USERS, SUBMIT_LEAVE_ON_BEHALF
 	},
 	"HR": {
 		ID:          3,
 		Name:        "HR",
 		Description: "Human Resources with HR-specific permissions",
 		IsActive:    true,
-		Permissions: UintArray{1, 3, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 22, 23, 24}, // HR permissions + CREATE_USERS, READ_USERS, UPDATE_USERS
+		Permissions: UintArray{1, 3, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 22, 23, 24, 26, 27, 28}, // HR permissions + CREATE_USERS, READ_USERS, UPDATE_USERS, OFFBOARD_USERS, SUBMIT_LEAVE_ON_BEHALF, MANAGE_PAYROLL
 	},
 	"MANAGEMENT": {
 		ID:          4,
 		Name:        "MANAGEMENT",
 		Description: "Management with high-level permissions",
 		IsActive:    true,
-		Permissions: UintArray{1, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 22, 23, 24, 25}, // Management permissions + all user CRUD
+		Permissions: UintArray{1, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 22, 23, 24, 25, 26, 27, 28}, // Management permissions + all user CRUD + OFFBOARD_USERS, SUBMIT_LEAVE_ON_BEHALF, MANAGE_PAYROLL
 	},
 	"ADMIN": {
 		ID:          5,
 		Name:        "ADMIN",
 		Description: "System administrator with all permissions",
 		IsActive:    true,
-		Permissions: UintArray{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25}, // All permissions including user CRUD
+		Permissions: UintArray{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28}, // All permissions including user CRUD
 	},
 }
 
@@ -176,6 +176,33 @@ func (r *RoleModel) GetAllRoles() ([]Role, error) {
 	return roles, nil
 }
 
+// RoleListSpec declares the sorting, filters and search supported when listing roles
+var RoleListSpec = ListSpec{
+	SortColumns: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	DefaultSort: []SortField{{Field: "id"}},
+	Filters: map[string]FilterFunc{
+		"is_active":      BoolFilter("is_active"),
+		"created_after":  TimeAfterFilter("created_at"),
+		"created_before": TimeBeforeFilter("created_at"),
+	},
+	SearchColumns: []string{"name", "description"},
+}
+
+// ListRoles returns one page of roles matching the list query
+func (r *RoleModel) ListRoles(q ListQuery) ([]Role, *ListResult, error) {
+	var roles []Role
+	result, err := Paginate(r.db.Model(&Role{}), q, RoleListSpec, &roles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles, result, nil
+}
+
 // UpdateRole updates a role in the database
 func (r *RoleModel) UpdateRole(role *Role) error {
 	return r.db.Save(role).Error
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobRunStatus represents the outcome of a background job run
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "RUNNING"
+	JobRunSucceeded JobRunStatus = "SUCCEEDED"
+	JobRunFailed    JobRunStatus = "FAILED"
+	JobRunSkipped   JobRunStatus = "SKIPPED" // Another instance was already running the job
+)
+
+// JobTrigger represents what started a job run
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "SCHEDULE"
+	JobTriggerManual   JobTrigger = "MANUAL"
+)
+
+// ScheduledJob is the persisted state of a recurring background job, shared by all instances
+type ScheduledJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"uniqueIndex;not null" json:"name"`
+	Schedule    string     `gorm:"not null" json:"schedule"` // Cron expression
+	Description string     `gorm:"type:text" json:"description"`
+	IsPaused    bool       `gorm:"not null;default:false" json:"is_paused"`
+	NextRunAt   *time.Time `json:"next_run_at"` // Claimed by the instance that starts the scheduled run
+	LastRunAt   *time.Time `json:"last_run_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobRun records one execution of a background job
+type JobRun struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	JobName     string       `gorm:"index;not null" json:"job_name"`
+	Trigger     JobTrigger   `gorm:"not null" json:"trigger"`
+	TriggeredBy *uint        `json:"triggered_by,omitempty"` // User who triggered a manual run
+	Status      JobRunStatus `gorm:"not null" json:"status"`
+	StartedAt   time.Time    `gorm:"not null" json:"started_at"`
+	FinishedAt  *time.Time   `json:"finished_at,omitempty"`
+	DurationMs  int64        `gorm:"not null;default:0" json:"duration_ms"`
+	Result      string       `gorm:"type:text" json:"result,omitempty"`
+	Error       string       `gorm:"type:text" json:"error,omitempty"`
+}
+
+// JobModel handles scheduled job database operations
+type JobModel struct {
+	db *gorm.DB
+}
+
+func NewJobModel(db *gorm.DB) *JobModel {
+	return &JobModel{
+		db: db,
+	}
+}
+
+// EnsureJob registers a job, keeping its paused state. The next run is (re)computed when the job is new
+// or its schedule changed.
+func (j *JobModel) EnsureJob(name, schedule, description string, nextRunAt time.Time) (*ScheduledJob, error) {
+	var job ScheduledJob
+	err := j.db.Where("name = ?", name).First(&job).Error
+	if err == gorm.ErrRecordNotFound {
+		job = ScheduledJob{
+			Name:        name,
+			Schedule:    schedule,
+			Description: description,
+			NextRunAt:   &nextRunAt,
+		}
+		return &job, j.db.Create(&job).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"description": description}
+	if job.Schedule != schedule || job.NextRunAt == nil {
+		updates["schedule"] = schedule
+		updates["next_run_at"] = nextRunAt
+	}
+	if err := j.db.Model(&job).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobs returns all registered jobs ordered by name
+func (j *JobModel) GetJobs() ([]ScheduledJob, error) {
+	var jobs []ScheduledJob
+	if err := j.db.Order("name ASC").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetJob returns a job by name
+func (j *JobModel) GetJob(name string) (*ScheduledJob, error) {
+	var job ScheduledJob
+	if err := j.db.Where("name = ?", name).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimScheduledRun moves the next run of a job from due to next. Only one instance can claim a given run.
+func (j *JobModel) ClaimScheduledRun(name string, due, next time.Time) (bool, error) {
+	result := j.db.Model(&ScheduledJob{}).
+		Where("name = ? AND is_paused = ? AND next_run_at = ?", name, false, due).
+		Update("next_run_at", next)
+	return result.RowsAffected == 1, result.Error
+}
+
+// SetJobPaused pauses or resumes a job; a resumed job continues at its next scheduled time
+func (j *JobModel) SetJobPaused(name string, paused bool, nextRunAt time.Time) error {
+	updates := map[string]interface{}{"is_paused": paused}
+	if !paused {
+		updates["next_run_at"] = nextRunAt
+	}
+	result := j.db.Model(&ScheduledJob{}).Where("name = ?", name).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// StartJobRun records that a job run has started
+func (j *JobModel) StartJobRun(name string, trigger JobTrigger, triggeredBy *uint) (*JobRun, error) {
+	run := &JobRun{
+		JobName:     name,
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		Status:      JobRunRunning,
+		StartedAt:   time.Now(),
+	}
+	if err := j.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// FinishJobRun records the outcome of a job run and the job's last run time
+func (j *JobModel) FinishJobRun(run *JobRun, status JobRunStatus, result string, runErr error) error {
+	finishedAt := time.Now()
+	run.Status = status
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Result = result
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	if err := j.db.Save(run).Error; err != nil {
+		return err
+	}
+
+	if status == JobRunSkipped {
+		return nil
+	}
+	return j.db.Model(&ScheduledJob{}).Where("name = ?", run.JobName).Update("last_run_at", run.StartedAt).Error
+}
+
+// GetJobRuns returns the most recent runs of a job
+func (j *JobModel) GetJobRuns(name string, limit int) ([]JobRun, error) {
+	var runs []JobRun
+	if err := j.db.Where("job_name = ?", name).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetLatestJobRuns returns the latest run of every job, keyed by job name
+func (j *JobModel) GetLatestJobRuns() (map[string]JobRun, error) {
+	var runs []JobRun
+	if err := j.db.Where("id IN (?)", j.db.Model(&JobRun{}).Select("MAX(id)").Group("job_name")).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]JobRun, len(runs))
+	for _, run := range runs {
+		latest[run.JobName] = run
+	}
+	return latest, nil
+}
@@ -160,6 +160,34 @@ func (t *TeamModel) GetAllTeams() ([]Team, error) {
 	return teams, nil
 }
 
+// TeamListSpec declares the sorting, filters and search supported when listing teams
+var TeamListSpec = ListSpec{
+	SortColumns: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	DefaultSort: []SortField{{Field: "id"}},
+	Filters: map[string]FilterFunc{
+		"is_active":      BoolFilter("is_active"),
+		"team_lead_id":   UintFilter("team_lead_id"),
+		"created_after":  TimeAfterFilter("created_at"),
+		"created_before": TimeBeforeFilter("created_at"),
+	},
+	SearchColumns: []string{"name", "description"},
+}
+
+// ListTeams returns one page of teams matching the list query
+func (t *TeamModel) ListTeams(q ListQuery) ([]Team, *ListResult, error) {
+	var teams []Team
+	result, err := Paginate(t.db.Model(&Team{}), q, TeamListSpec, &teams)
+	if err != nil {
+		return nil, nil, err
+	}
+	return teams, result, nil
+}
+
 // UpdateTeam updates a team in the database
 func (t *TeamModel) UpdateTeam(team *Team) error {
 	return t.db.Save(team).Error
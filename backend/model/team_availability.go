@@ -0,0 +1,148 @@
+package model
+
+import "time"
+
+// AvailabilityStatus is what a team member is doing on a day
+type AvailabilityStatus string
+
+const (
+	AvailabilityPresent AvailabilityStatus = "PRESENT"
+	AvailabilityHalfDay AvailabilityStatus = "HALF_DAY" // On leave for half of the day
+	AvailabilityOnLeave AvailabilityStatus = "ON_LEAVE"
+	AvailabilityHoliday AvailabilityStatus = "HOLIDAY" // Company holiday
+)
+
+// AvailabilityCell is one member on one day of the matrix
+type AvailabilityCell struct {
+	Date           time.Time          `json:"date"`
+	Status         AvailabilityStatus `json:"status"`
+	LeaveRequestID uint               `json:"leave_request_id,omitempty"`
+	LeaveType      LeaveType          `json:"leave_type,omitempty"`
+	LeaveStatus    LeaveRequestStatus `json:"leave_status,omitempty"`
+	IsHalfDay      bool               `json:"is_half_day,omitempty"`
+	IsMorning      bool               `json:"is_morning,omitempty"` // Which half, for half days
+}
+
+// AvailabilityRow is the days of one team member
+type AvailabilityRow struct {
+	UserID    uint               `json:"user_id"`
+	FirstName string             `json:"first_name"`
+	LastName  string             `json:"last_name"`
+	Days      []AvailabilityCell `json:"days"`
+}
+
+// DailyHeadcount counts who of a team is at work on a day; half days count as present
+type DailyHeadcount struct {
+	Date      time.Time `json:"date"`
+	IsHoliday bool      `json:"is_holiday"`
+	Total     int       `json:"total"`
+	Present   int       `json:"present"`
+	OnLeave   int       `json:"on_leave"`
+	HalfDay   int       `json:"half_day"`
+}
+
+// TeamAvailability is a team × date matrix of who is away and why
+type TeamAvailability struct {
+	TeamID    uint              `json:"team_id"`
+	StartDate time.Time         `json:"start_date"`
+	EndDate   time.Time         `json:"end_date"`
+	Holidays  []CompanyHoliday  `json:"holidays"`
+	Members   []AvailabilityRow `json:"members"`
+	Headcount []DailyHeadcount  `json:"headcount"`
+}
+
+// GetTeamAvailability builds the availability matrix of a team's active members between two dates,
+// counting pending as well as approved leave
+func (l *LeaveCalendarModel) GetTeamAvailability(teamID uint, startDate, endDate time.Time) (*TeamAvailability, error) {
+	members, err := NewUserModel(l.db).GetUsersByTeam(teamID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := l.GetCalendarEntriesForTeam(teamID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := NewCompanyHolidayModel(l.db).GetHolidays(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	holidayDates := make(map[string]bool, len(holidays))
+	for _, holiday := range holidays {
+		holidayDates[holiday.Date.Format("2006-01-02")] = true
+	}
+	leaveByUserDay := make(map[uint]map[string]LeaveCalendarEntry)
+	for _, entry := range entries {
+		if !isActiveLeaveStatus(entry.Status) {
+			continue
+		}
+		if leaveByUserDay[entry.UserID] == nil {
+			leaveByUserDay[entry.UserID] = make(map[string]LeaveCalendarEntry)
+		}
+		leaveByUserDay[entry.UserID][entry.Date.Format("2006-01-02")] = entry
+	}
+
+	availability := &TeamAvailability{
+		TeamID:    teamID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Holidays:  holidays,
+		Members:   []AvailabilityRow{},
+		Headcount: []DailyHeadcount{},
+	}
+	if availability.Holidays == nil {
+		availability.Holidays = []CompanyHoliday{}
+	}
+
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		availability.Headcount = append(availability.Headcount, DailyHeadcount{
+			Date:      day,
+			IsHoliday: holidayDates[day.Format("2006-01-02")],
+		})
+	}
+
+	for _, member := range members {
+		if !member.IsActiveUser {
+			continue
+		}
+		row := AvailabilityRow{
+			UserID:    member.ID,
+			FirstName: member.FirstName,
+			LastName:  member.LastName,
+		}
+		for i := range availability.Headcount {
+			count := &availability.Headcount[i]
+			key := count.Date.Format("2006-01-02")
+			cell := AvailabilityCell{Date: count.Date, Status: AvailabilityPresent}
+			if entry, ok := leaveByUserDay[member.ID][key]; ok {
+				cell.Status = AvailabilityOnLeave
+				if entry.IsHalfDay {
+					cell.Status = AvailabilityHalfDay
+				}
+				cell.LeaveRequestID = entry.LeaveRequestID
+				cell.LeaveType = entry.LeaveType
+				cell.LeaveStatus = entry.Status
+				cell.IsHalfDay = entry.IsHalfDay
+				cell.IsMorning = entry.IsMorning
+			}
+			if holidayDates[key] {
+				cell.Status = AvailabilityHoliday
+			}
+			row.Days = append(row.Days, cell)
+
+			count.Total++
+			switch cell.Status {
+			case AvailabilityPresent:
+				count.Present++
+			case AvailabilityHalfDay:
+				count.Present++
+				count.HalfDay++
+			case AvailabilityOnLeave:
+				count.OnLeave++
+			}
+		}
+		availability.Members = append(availability.Members, row)
+	}
+
+	return availability, nil
+}
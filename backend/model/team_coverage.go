@@ -0,0 +1,316 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CoverageRuleType represents the kind of staffing rule of a team
+type CoverageRuleType string
+
+const (
+	CoverageMinPresent CoverageRuleType = "MIN_PRESENT" // At least MinPresent members at work every day
+	CoverageKeyMembers CoverageRuleType = "KEY_MEMBERS" // At most MaxAbsent of the key members out on the same day
+)
+
+var ErrInvalidCoverageRule = errors.New("invalid coverage rule")
+
+// TeamCoverageRule is a staffing rule checked against the leave of a team's members
+type TeamCoverageRule struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	TeamID      uint             `gorm:"not null;index" json:"team_id"`
+	Name        string           `gorm:"not null" json:"name"`
+	RuleType    CoverageRuleType `gorm:"not null" json:"rule_type"`
+	MinPresent  int              `gorm:"not null;default:0" json:"min_present,omitempty"`
+	MemberIDs   UintArray        `gorm:"type:jsonb" json:"member_ids,omitempty"` // Key members
+	MaxAbsent   int              `gorm:"not null;default:1" json:"max_absent,omitempty"`
+	IsMandatory bool             `gorm:"default:false" json:"is_mandatory"` // Blocks submission instead of only warning
+	IsActive    bool             `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt   `json:"deleted_at,omitempty"`
+}
+
+// Validate checks that the rule's settings match its type
+func (r *TeamCoverageRule) Validate() error {
+	switch r.RuleType {
+	case CoverageMinPresent:
+		if r.MinPresent < 1 {
+			return fmt.Errorf("%w: min_present must be at least 1", ErrInvalidCoverageRule)
+		}
+	case CoverageKeyMembers:
+		if len(r.MemberIDs) < 2 {
+			return fmt.Errorf("%w: key member rules need at least 2 members", ErrInvalidCoverageRule)
+		}
+		if r.MaxAbsent < 1 || r.MaxAbsent >= len(r.MemberIDs) {
+			return fmt.Errorf("%w: max_absent must be between 1 and the number of key members minus 1", ErrInvalidCoverageRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule type %q", ErrInvalidCoverageRule, r.RuleType)
+	}
+	return nil
+}
+
+// CoverageConflict is a day on which a team would break one of its rules
+type CoverageConflict struct {
+	RuleID      uint             `json:"rule_id"`
+	RuleName    string           `json:"rule_name"`
+	RuleType    CoverageRuleType `json:"rule_type"`
+	TeamID      uint             `json:"team_id"`
+	Date        time.Time        `json:"date"`
+	IsMandatory bool             `json:"is_mandatory"`
+	AbsentIDs   []uint           `json:"absent_user_ids"`
+	Message     string           `json:"message"`
+}
+
+// TeamDayAbsence lists who of a team is away on a day
+type TeamDayAbsence struct {
+	Date      time.Time `json:"date"`
+	Present   int       `json:"present"`
+	AbsentIDs []uint    `json:"absent_user_ids"`
+}
+
+// TeamCoverage is the staffing of one team over a leave request's dates
+type TeamCoverage struct {
+	TeamID   uint             `json:"team_id"`
+	TeamName string           `json:"team_name"`
+	TeamSize int              `json:"team_size"`
+	Days     []TeamDayAbsence `json:"days"`
+}
+
+// CoverageReport shows how a leave request affects the staffing of the requester's teams
+type CoverageReport struct {
+	LeaveRequestID uint               `json:"leave_request_id,omitempty"`
+	StartDate      time.Time          `json:"start_date"`
+	EndDate        time.Time          `json:"end_date"`
+	Teams          []TeamCoverage     `json:"teams"`
+	Conflicts      []CoverageConflict `json:"conflicts"`
+}
+
+// MandatoryConflicts returns the conflicts that block the request
+func (r *CoverageReport) MandatoryConflicts() []CoverageConflict {
+	var conflicts []CoverageConflict
+	for _, conflict := range r.Conflicts {
+		if conflict.IsMandatory {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	return conflicts
+}
+
+// TeamCoverageModel handles team coverage rules and staffing checks
+type TeamCoverageModel struct {
+	db *gorm.DB
+}
+
+func NewTeamCoverageModel(db *gorm.DB) *TeamCoverageModel {
+	return &TeamCoverageModel{
+		db: db,
+	}
+}
+
+// CreateRule validates and stores a coverage rule
+func (t *TeamCoverageModel) CreateRule(rule *TeamCoverageRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	// Select all columns so that an inactive rule is not stored with the column default
+	return t.db.Select("*").Create(rule).Error
+}
+
+// GetRule retrieves a coverage rule of a team
+func (t *TeamCoverageModel) GetRule(teamID, ruleID uint) (*TeamCoverageRule, error) {
+	var rule TeamCoverageRule
+	if err := t.db.Where("team_id = ?", teamID).First(&rule, ruleID).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// GetTeamRules lists the coverage rules of a team
+func (t *TeamCoverageModel) GetTeamRules(teamID uint) ([]TeamCoverageRule, error) {
+	var rules []TeamCoverageRule
+	if err := t.db.Where("team_id = ?", teamID).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// UpdateRule validates and saves a coverage rule
+func (t *TeamCoverageModel) UpdateRule(rule *TeamCoverageRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return t.db.Save(rule).Error
+}
+
+// DeleteRule soft deletes a coverage rule of a team
+func (t *TeamCoverageModel) DeleteRule(teamID, ruleID uint) error {
+	result := t.db.Where("team_id = ?", teamID).Delete(&TeamCoverageRule{}, ruleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CheckCoverage builds the staffing report of the requester's teams over the request's dates, counting the
+// request itself as leave, and lists every day that breaks an active rule
+func (t *TeamCoverageModel) CheckCoverage(request *LeaveRequest) (*CoverageReport, error) {
+	report := &CoverageReport{
+		LeaveRequestID: request.ID,
+		StartDate:      request.StartDate,
+		EndDate:        request.EndDate,
+		Teams:          []TeamCoverage{},
+		Conflicts:      []CoverageConflict{},
+	}
+
+	teamIDs, err := NewUserModel(t.db).GetUserTeamIDs(request.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	userModel := NewUserModel(t.db)
+	calendarModel := NewLeaveCalendarModel(t.db)
+	for _, teamID := range teamIDs {
+		var rules []TeamCoverageRule
+		if err := t.db.Where("team_id = ? AND is_active = ?", teamID, true).Find(&rules).Error; err != nil {
+			return nil, err
+		}
+
+		members, err := userModel.GetUsersByTeam(teamID)
+		if err != nil {
+			return nil, err
+		}
+		memberIDs := make(map[uint]bool)
+		for _, member := range members {
+			if member.IsActiveUser {
+				memberIDs[member.ID] = true
+			}
+		}
+		memberIDs[request.UserID] = true
+
+		entries, err := calendarModel.GetCalendarEntriesForTeam(teamID, request.StartDate, request.EndDate)
+		if err != nil {
+			return nil, err
+		}
+
+		absent := make(map[string]map[uint]bool)
+		for _, entry := range entries {
+			if entry.LeaveRequestID == request.ID || !isActiveLeaveStatus(entry.Status) || !memberIDs[entry.UserID] {
+				continue
+			}
+			addAbsence(absent, entry.Date, entry.UserID)
+		}
+		for day := request.StartDate; !day.After(request.EndDate); day = day.AddDate(0, 0, 1) {
+			addAbsence(absent, day, request.UserID)
+		}
+
+		coverage := TeamCoverage{
+			TeamID:   teamID,
+			TeamSize: len(memberIDs),
+		}
+		if team, err := NewTeamModel(t.db).GetTeam(teamID); err == nil {
+			coverage.TeamName = team.Name
+		}
+
+		for day := request.StartDate; !day.After(request.EndDate); day = day.AddDate(0, 0, 1) {
+			absentIDs := sortedIDs(absent[day.Format("2006-01-02")])
+			coverage.Days = append(coverage.Days, TeamDayAbsence{
+				Date:      day,
+				Present:   len(memberIDs) - len(absentIDs),
+				AbsentIDs: absentIDs,
+			})
+
+			for _, rule := range rules {
+				if conflict := rule.check(day, len(memberIDs), absentIDs, request.UserID); conflict != nil {
+					report.Conflicts = append(report.Conflicts, *conflict)
+				}
+			}
+		}
+		report.Teams = append(report.Teams, coverage)
+	}
+
+	return report, nil
+}
+
+// check returns the conflict of a day with the rule, or nil when the rule holds or the requester is not involved
+func (r *TeamCoverageRule) check(day time.Time, teamSize int, absentIDs []uint, requesterID uint) *CoverageConflict {
+	conflict := &CoverageConflict{
+		RuleID:      r.ID,
+		RuleName:    r.Name,
+		RuleType:    r.RuleType,
+		TeamID:      r.TeamID,
+		Date:        day,
+		IsMandatory: r.IsMandatory,
+	}
+
+	switch r.RuleType {
+	case CoverageMinPresent:
+		present := teamSize - len(absentIDs)
+		if present >= r.MinPresent {
+			return nil
+		}
+		conflict.AbsentIDs = absentIDs
+		conflict.Message = fmt.Sprintf("%s: only %d of %d team members present on %s, at least %d required",
+			r.Name, present, teamSize, day.Format("2006-01-02"), r.MinPresent)
+
+	case CoverageKeyMembers:
+		keyMembers := make(map[uint]bool, len(r.MemberIDs))
+		for _, id := range r.MemberIDs {
+			keyMembers[id] = true
+		}
+		if !keyMembers[requesterID] {
+			return nil
+		}
+		for _, id := range absentIDs {
+			if keyMembers[id] {
+				conflict.AbsentIDs = append(conflict.AbsentIDs, id)
+			}
+		}
+		if len(conflict.AbsentIDs) <= r.MaxAbsent {
+			return nil
+		}
+		conflict.Message = fmt.Sprintf("%s: %d key members away on %s, at most %d allowed",
+			r.Name, len(conflict.AbsentIDs), day.Format("2006-01-02"), r.MaxAbsent)
+
+	default:
+		return nil
+	}
+	return conflict
+}
+
+// isActiveLeaveStatus reports whether leave in this status is still going to be taken
+func isActiveLeaveStatus(status LeaveRequestStatus) bool {
+	for _, active := range activeLeaveStatuses {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
+
+// addAbsence marks a user as away on a day
+func addAbsence(absent map[string]map[uint]bool, day time.Time, userID uint) {
+	key := day.Format("2006-01-02")
+	if absent[key] == nil {
+		absent[key] = make(map[uint]bool)
+	}
+	absent[key][userID] = true
+}
+
+// sortedIDs returns the keys of a set in ascending order
+func sortedIDs(set map[uint]bool) []uint {
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -1,12 +1,34 @@
 package model
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// EmploymentType represents the contract a user is employed on
+type EmploymentType string
+
+const (
+	EmploymentFullTime   EmploymentType = "FULL_TIME"
+	EmploymentPartTime   EmploymentType = "PART_TIME"
+	EmploymentContractor EmploymentType = "CONTRACTOR"
+	EmploymentIntern     EmploymentType = "INTERN"
+)
+
+// Gender is recorded only where leave eligibility depends on it; empty when not recorded
+type Gender string
+
+const (
+	GenderFemale Gender = "FEMALE"
+	GenderMale   Gender = "MALE"
+	GenderOther  Gender = "OTHER"
+)
+
 type User struct {
 	ID             uint           `gorm:"primaryKey" json:"id"`
 	Email          string         `gorm:"unique;not null" json:"email"`
@@ -26,15 +48,33 @@ type User struct {
 	PrimaryRoleID uint   `gorm:"not null" json:"primary_role_id"` // Main role for the user
 	PrimaryTeamID uint   `gorm:"not null" json:"primary_team_id"` // Main team for the user
 
+	// Employment, used to pro-rate leave allocations and to check leave eligibility
+	EmploymentStartDate *time.Time     `json:"employment_start_date,omitempty"`
+	EmploymentEndDate   *time.Time     `json:"employment_end_date,omitempty"`
+	EmploymentType      EmploymentType `gorm:"not null;default:'FULL_TIME'" json:"employment_type"`
+	ProbationEndDate    *time.Time     `json:"probation_end_date,omitempty"` // Last day of probation
+	Gender              Gender         `json:"gender,omitempty"`
+
 	// Authentication
 	LastLoginTime      *time.Time `json:"last_login,omitempty"`
 	RefreshToken       string     `json:"-"` // Never expose refresh token in JSON
 	RefreshTokenExpiry *time.Time `json:"-"` // Never expose refresh token expiry in JSON
 
+	// Offboarding
+	TerminationDate   *time.Time `json:"termination_date,omitempty"` // Last working day; login is disabled from the day after
+	TerminationReason string     `gorm:"type:text" json:"termination_reason,omitempty"`
+	SuccessorID       *uint      `json:"successor_id,omitempty"` // User who took over approvals and team leads
+
 	// Relationships (commented out to avoid circular dependency)
 	// PrimaryRole Role `gorm:"foreignKey:PrimaryRoleID"`
 	// PrimaryTeam Team `gorm:"foreignKey:PrimaryTeamID"`
 }
+
+// IsTerminated reports whether the user's termination date, their last working day, has passed
+func (u *User) IsTerminated(now time.Time) bool {
+	return u.TerminationDate != nil && truncateToDate(*u.TerminationDate).Before(truncateToDate(now))
+}
+
 type UserModel struct {
 	db *gorm.DB
 }
@@ -60,6 +100,127 @@ func (u *UserModel) GetAllUsers() ([]User, error) {
 	return users, nil
 }
 
+// UserListSpec declares the sorting, filters and search supported when listing users
+var UserListSpec = ListSpec{
+	SortColumns: map[string]string{
+		"id":         "users.id",
+		"email":      "users.email",
+		"first_name": "users.first_name",
+		"last_name":  "users.last_name",
+		"created_at": "users.created_at",
+		"updated_at": "users.updated_at",
+		"last_login": "users.last_login_time",
+	},
+	DefaultSort: []SortField{{Field: "id"}},
+	Filters: map[string]FilterFunc{
+		"is_active":       BoolFilter("users.is_active_user"),
+		"primary_team_id": UintFilter("users.primary_team_id"),
+		"primary_role_id": UintFilter("users.primary_role_id"),
+		"role":            userRoleFilter,
+		"team_id":         userTeamFilter,
+		"created_after":   TimeAfterFilter("users.created_at"),
+		"created_before":  TimeBeforeFilter("users.created_at"),
+	},
+	SearchColumns: []string{"users.email", "users.first_name", "users.last_name"},
+}
+
+// userRoleFilter keeps users holding a role, given by ID or by name
+func userRoleFilter(value string) (func(*gorm.DB) *gorm.DB, error) {
+	if value == "" {
+		return nil, fmt.Errorf("expected a role ID or name")
+	}
+	if roleID, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where("users.id IN (SELECT user_id FROM user_roles WHERE role_id = ?)", uint(roleID))
+		}, nil
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("users.id IN (SELECT user_roles.user_id FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE roles.name = ?)", strings.ToUpper(value))
+	}, nil
+}
+
+// userTeamFilter keeps users that are members of a team
+func userTeamFilter(value string) (func(*gorm.DB) *gorm.DB, error) {
+	teamID, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("expected a positive number")
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("users.id IN (SELECT user_id FROM team_members WHERE team_id = ?)", uint(teamID))
+	}, nil
+}
+
+// ListUsers returns one page of users matching the list query
+func (u *UserModel) ListUsers(q ListQuery) ([]User, *ListResult, error) {
+	var users []User
+	result, err := Paginate(u.db.Model(&User{}), q, UserListSpec, &users)
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, result, nil
+}
+
+// ExportUsers returns every user matching the filters, search and sorting of the list query,
+// with roles and teams loaded
+func (u *UserModel) ExportUsers(q ListQuery) ([]User, error) {
+	var users []User
+	if err := FindAll(u.db.Model(&User{}).Preload("Roles").Preload("Teams"), q, UserListSpec, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UserImport is a validated user ready to be created by ImportUsers
+type UserImport struct {
+	User    User
+	RoleIDs []uint
+	TeamIDs []uint
+}
+
+// GetExistingEmails returns which of the given emails are already taken, including by deleted users
+func (u *UserModel) GetExistingEmails(emails []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(emails) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	if err := u.db.Unscoped().Model(&User{}).Where("LOWER(email) IN ?", emails).Pluck("LOWER(email)", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, email := range found {
+		existing[email] = true
+	}
+	return existing, nil
+}
+
+// ImportUsers creates the users with their roles, teams and leave balances for the year
+// in a single transaction; either all of them are created or none
+func (u *UserModel) ImportUsers(imports []UserImport, year int) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		balanceModel := NewLeaveBalanceModel(tx)
+		for i := range imports {
+			user := &imports[i].User
+			for _, roleID := range imports[i].RoleIDs {
+				user.Roles = append(user.Roles, Role{ID: roleID})
+			}
+			for _, teamID := range imports[i].TeamIDs {
+				user.Teams = append(user.Teams, Team{ID: teamID})
+			}
+
+			// Only write the join rows; the roles and teams themselves already exist
+			if err := tx.Omit("Roles.*", "Teams.*").Create(user).Error; err != nil {
+				return fmt.Errorf("failed to create user %s: %w", user.Email, err)
+			}
+
+			if err := balanceModel.InitializeUserLeaveBalances(user.ID, year); err != nil {
+				return fmt.Errorf("failed to initialize leave balances for %s: %w", user.Email, err)
+			}
+		}
+		return nil
+	})
+}
+
 func (u *UserModel) GetUserByEmail(email string) (*User, error) {
 	var user User
 	if err := u.db.Where("email = ?", email).First(&user).Error; err != nil {
@@ -202,6 +363,28 @@ func (u *UserModel) GetUserTeams(userID uint) ([]Team, error) {
 	return user.Teams, nil
 }
 
+// GetUserTeamIDs returns the IDs of the teams a user belongs to, starting with their primary team
+func (u *UserModel) GetUserTeamIDs(userID uint) ([]uint, error) {
+	var user User
+	if err := u.db.Preload("Teams").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	var teamIDs []uint
+	if user.PrimaryTeamID != 0 {
+		seen[user.PrimaryTeamID] = true
+		teamIDs = append(teamIDs, user.PrimaryTeamID)
+	}
+	for _, team := range user.Teams {
+		if !seen[team.ID] {
+			seen[team.ID] = true
+			teamIDs = append(teamIDs, team.ID)
+		}
+	}
+	return teamIDs, nil
+}
+
 // GetUserRoles returns all roles a user has
 func (u *UserModel) GetUserRoles(userID uint) ([]Role, error) {
 	var user User
@@ -297,6 +480,30 @@ func (u *UserModel) GetUsersByRole(roleID uint) ([]User, error) {
 	return users, nil
 }
 
+// GetUserIDsWithPermission returns the IDs of the active users holding a permission through any of their roles
+func (u *UserModel) GetUserIDsWithPermission(permissionKey string) ([]uint, error) {
+	var roleIDs []uint
+	for _, role := range predefinedRoles {
+		if HasRolePermission(role.ID, permissionKey) {
+			roleIDs = append(roleIDs, role.ID)
+		}
+	}
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+
+	var userIDs []uint
+	if err := u.db.Model(&User{}).
+		Distinct("users.id").
+		Joins("JOIN user_roles ON users.id = user_roles.user_id").
+		Where("user_roles.role_id IN ? AND users.is_active_user = ?", roleIDs, true).
+		Order("users.id").
+		Pluck("users.id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
 // GetUsersByTeam returns all users in a specific team
 func (u *UserModel) GetUsersByTeam(teamID uint) ([]User, error) {
 	var users []User
@@ -325,6 +532,27 @@ func (u *UserModel) UpdateUser(user *User) error {
 	return u.db.Save(user).Error
 }
 
+// DeactivateUser disables login for a user and revokes their refresh token
+func (u *UserModel) DeactivateUser(userID uint) error {
+	return u.db.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"is_active_user":       false,
+		"refresh_token":        "",
+		"refresh_token_expiry": nil,
+	}).Error
+}
+
+// DeactivateTerminatedUsers deactivates every user whose termination date, their last working day, has passed
+func (u *UserModel) DeactivateTerminatedUsers(now time.Time) (int64, error) {
+	result := u.db.Model(&User{}).
+		Where("termination_date IS NOT NULL AND termination_date < ? AND is_active_user = ?", truncateToDate(now), true).
+		Updates(map[string]interface{}{
+			"is_active_user":       false,
+			"refresh_token":        "",
+			"refresh_token_expiry": nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
 // DeleteUser soft deletes a user
 func (u *UserModel) DeleteUser(id uint) error {
 	return u.db.Delete(&User{}, id).Error
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationRetentionDays is how long read notifications are kept
+	notificationRetentionDays = 90
+	// lowBalanceThreshold is the remaining days at or below which users get a low-balance alert
+	lowBalanceThreshold = 2
+)
+
+// RegisterLeaveJobs registers the recurring leave operations with the scheduler
+func RegisterLeaveJobs(s *Scheduler, db *gorm.DB) error {
+	jobs := []struct {
+		name        string
+		spec        string
+		description string
+		run         JobFunc
+	}{
+		{"leave-accrual", "0 * * * *", "Posts leave accrual periods that have ended", leaveAccrualJob(db)},
+		{"carry-over-expiry", "15 0 * * *", "Warns about and forfeits expiring carried-over leave", carryOverExpiryJob(db)},
+		{"comp-off-expiry", "20 0 * * *", "Forfeits unused comp-off days whose claims have expired", compOffExpiryJob(db)},
+		{"year-end-rollover", "5 0 1 1 *", "Copies last year's leave policies and rolls balances over with carry-over", yearEndRolloverJob(db)},
+		{"delete-old-notifications", "30 3 * * *", "Deletes read notifications older than 90 days", deleteOldNotificationsJob(db)},
+		{"low-balance-alerts", "0 8 * * 1", "Notifies users whose leave balance is running low", lowBalanceAlertsJob(db)},
+		{"leave-reminders", "0 7 * * *", "Reminds users of approved leave starting tomorrow", leaveRemindersJob(db)},
+		{"approval-sla", "*/15 * * * *", "Reminds approvers of waiting requests, escalates overdue ones and auto-handles undecided leave that has started", approvalSLAJob(db)},
+		{"deactivate-terminated-users", "*/15 * * * *", "Deactivates users whose termination date has passed", deactivateTerminatedUsersJob(db)},
+	}
+
+	for _, job := range jobs {
+		if err := s.Register(job.name, job.spec, job.description, job.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func leaveAccrualJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		posted, err := model.NewLeaveAccrualModel(db).RunAccruals(now)
+		return fmt.Sprintf("%d accrual credits posted", posted), err
+	}
+}
+
+func carryOverExpiryJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		balanceModel := model.NewLeaveBalanceModel(db)
+		sent, err := balanceModel.SendCarryOverExpiryNotices(now)
+		if err != nil {
+			return "", err
+		}
+		expired, err := balanceModel.ExpireCarryOverDays(now)
+		return fmt.Sprintf("%d expiry notices sent, %d balances expired", sent, expired), err
+	}
+}
+
+func compOffExpiryJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		expired, err := model.NewCompOffClaimModel(db).ExpireCompOffCredits(now)
+		return fmt.Sprintf("%d comp-off claims expired", expired), err
+	}
+}
+
+func yearEndRolloverJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		year := now.Year()
+		policyModel := model.NewLeavePolicyModel(db)
+
+		policies, err := policyModel.GetLeavePoliciesByYear(year)
+		if err != nil {
+			return "", err
+		}
+		copied := false
+		if len(policies) == 0 {
+			if err := policyModel.CopyPoliciesFromPreviousYear(year-1, year); err != nil {
+				return "", err
+			}
+			copied = true
+		}
+
+		users, err := model.NewLeaveBalanceModel(db).RollOverToYear(year)
+		return fmt.Sprintf("policies copied: %t, %d users rolled over to %d", copied, users, year), err
+	}
+}
+
+func deleteOldNotificationsJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		err := model.NewLeaveNotificationModel(db).DeleteOldNotifications(notificationRetentionDays)
+		return "old notifications deleted", err
+	}
+}
+
+func lowBalanceAlertsJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		balances, err := model.NewLeaveBalanceModel(db).GetUsersWithLowLeaveBalance(now.Year(), lowBalanceThreshold)
+		if err != nil {
+			return "", err
+		}
+
+		notificationModel := model.NewLeaveNotificationModel(db)
+		sent := 0
+		for _, balance := range balances {
+			// Leave types without an allocation, such as unpaid leave, are always "low"
+			if balance.TotalAllocated+balance.CarryOverDays == 0 || !balance.User.IsActiveUser {
+				continue
+			}
+			if err := notificationModel.CreateBalanceLowNotification(balance.UserID, balance.LeaveType, balance.RemainingDays); err != nil {
+				return "", err
+			}
+			sent++
+		}
+		return fmt.Sprintf("%d low-balance alerts sent", sent), nil
+	}
+}
+
+func leaveRemindersJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		requests, err := model.NewLeaveRequestModel(db).GetApprovedLeaveStartingOn(now.AddDate(0, 0, 1))
+		if err != nil {
+			return "", err
+		}
+
+		notificationModel := model.NewLeaveNotificationModel(db)
+		sent := 0
+		for _, request := range requests {
+			reminded, err := notificationModel.HasNotificationForRequest(request.UserID, request.ID, model.NotificationTypeLeaveReminder, now.AddDate(0, 0, -1))
+			if err != nil {
+				return "", err
+			}
+			if reminded {
+				continue
+			}
+
+			message := fmt.Sprintf("Your %s leave starts tomorrow (%s) and ends on %s.",
+				request.LeaveType, request.StartDate.Format("2006-01-02"), request.EndDate.Format("2006-01-02"))
+			if err := notificationModel.CreateReminderNotification(request.UserID, request.ID, message); err != nil {
+				return "", err
+			}
+			sent++
+		}
+		return fmt.Sprintf("%d leave reminders sent", sent), nil
+	}
+}
+
+func approvalSLAJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		result, err := model.NewApprovalSLAModel(db).ProcessApprovalSLAs(now)
+		if result == nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d reminders, %d escalations, %d auto-approved, %d auto-rejected",
+			result.Reminders, result.Escalations, result.AutoApproved, result.AutoRejected), err
+	}
+}
+
+func deactivateTerminatedUsersJob(db *gorm.DB) JobFunc {
+	return func(ctx context.Context, now time.Time) (string, error) {
+		deactivated, err := model.NewUserModel(db).DeactivateTerminatedUsers(now)
+		return fmt.Sprintf("%d users deactivated", deactivated), err
+	}
+}
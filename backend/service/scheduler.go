@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/amupxm/xmus-crm/backend/model"
+	xmuslogger "github.com/amupxm/xmus-logger"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// schedulerPollInterval is how often the scheduler looks for due jobs
+const schedulerPollInterval = 30 * time.Second
+
+// ErrJobNotFound is returned for a job name that was never registered
+var ErrJobNotFound = errors.New("job not found")
+
+// JobFunc runs one job and returns a short summary of what it did
+type JobFunc func(ctx context.Context, now time.Time) (string, error)
+
+type registeredJob struct {
+	name        string
+	spec        string
+	description string
+	schedule    cron.Schedule
+	run         JobFunc
+}
+
+// Scheduler runs registered jobs on cron schedules. Job state lives in the database so that every
+// replica sees the same schedule; a run is claimed by a single replica and guarded by a Postgres
+// advisory lock so the same job never runs twice at once.
+type Scheduler struct {
+	db       *gorm.DB
+	log      *xmuslogger.Logger
+	jobModel *model.JobModel
+	jobs     map[string]*registeredJob
+	mu       sync.RWMutex
+	stop     chan struct{}
+}
+
+// NewScheduler creates a scheduler; register jobs before calling Start
+func NewScheduler(db *gorm.DB, log *xmuslogger.Logger) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		log:      log,
+		jobModel: model.NewJobModel(db),
+		jobs:     make(map[string]*registeredJob),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register adds a job with a standard five-field cron expression
+func (s *Scheduler) Register(name, spec, description string, run JobFunc) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for job %s: %w", name, err)
+	}
+
+	if _, err := s.jobModel.EnsureJob(name, spec, description, schedule.Next(time.Now())); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[name] = &registeredJob{
+		name:        name,
+		spec:        spec,
+		description: description,
+		schedule:    schedule,
+		run:         run,
+	}
+	return nil
+}
+
+// Start polls for due jobs in the background until Stop is called
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(schedulerPollInterval)
+		defer ticker.Stop()
+		for {
+			s.runDueJobs(time.Now())
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling; runs already in progress finish on their own
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+// JobNames returns the names of the registered jobs
+func (s *Scheduler) JobNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Trigger starts a manual run of a job in the background and returns the run record
+func (s *Scheduler) Trigger(name string, triggeredBy uint) (*model.JobRun, error) {
+	job, err := s.job(name)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.jobModel.StartJobRun(name, model.JobTriggerManual, &triggeredBy)
+	if err != nil {
+		return nil, err
+	}
+	go s.execute(job, run)
+	return run, nil
+}
+
+// Pause stops scheduled runs of a job; manual triggers still work
+func (s *Scheduler) Pause(name string) error {
+	if _, err := s.job(name); err != nil {
+		return err
+	}
+	return s.jobModel.SetJobPaused(name, true, time.Time{})
+}
+
+// Resume restarts scheduled runs of a job from its next scheduled time
+func (s *Scheduler) Resume(name string) error {
+	job, err := s.job(name)
+	if err != nil {
+		return err
+	}
+	return s.jobModel.SetJobPaused(name, false, job.schedule.Next(time.Now()))
+}
+
+// job returns a registered job by name
+func (s *Scheduler) job(name string) (*registeredJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// runDueJobs claims and starts every job whose next run time has passed
+func (s *Scheduler) runDueJobs(now time.Time) {
+	states, err := s.jobModel.GetJobs()
+	if err != nil {
+		s.log.Error().Err(err).Msg("failed to load scheduled jobs")
+		return
+	}
+
+	for _, state := range states {
+		if state.IsPaused || state.NextRunAt == nil || state.NextRunAt.After(now) {
+			continue
+		}
+		job, err := s.job(state.Name)
+		if err != nil {
+			continue // Registered by another version of the service
+		}
+
+		claimed, err := s.jobModel.ClaimScheduledRun(state.Name, *state.NextRunAt, job.schedule.Next(now))
+		if err != nil {
+			s.log.Error().Err(err).Str("job", state.Name).Msg("failed to claim scheduled job run")
+			continue
+		}
+		if !claimed {
+			continue // Another replica took this run
+		}
+
+		run, err := s.jobModel.StartJobRun(state.Name, model.JobTriggerSchedule, nil)
+		if err != nil {
+			s.log.Error().Err(err).Str("job", state.Name).Msg("failed to record job run")
+			continue
+		}
+		go s.execute(job, run)
+	}
+}
+
+// execute runs a job while holding its advisory lock and records the outcome
+func (s *Scheduler) execute(job *registeredJob, run *model.JobRun) {
+	var result string
+	var runErr error
+	locked := false
+
+	// The transaction pins one connection, which holds the advisory lock until the job returns
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", advisoryLockKey(job.name)).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				runErr = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		result, runErr = job.run(context.Background(), time.Now())
+		return nil
+	})
+	if err != nil {
+		runErr = err
+	}
+
+	status := model.JobRunSucceeded
+	switch {
+	case runErr != nil:
+		status = model.JobRunFailed
+		s.log.Error().Err(runErr).Str("job", job.name).Msg("job run failed")
+	case !locked:
+		status = model.JobRunSkipped
+		result = "job is already running on another instance"
+	default:
+		s.log.Info().Str("job", job.name).Str("result", result).Msg("job run finished")
+	}
+
+	if err := s.jobModel.FinishJobRun(run, status, result, runErr); err != nil {
+		s.log.Error().Err(err).Str("job", job.name).Msg("failed to record job run result")
+	}
+}
+
+// advisoryLockKey maps a job name to a stable Postgres advisory lock key
+func advisoryLockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("xmus-crm-job:" + name))
+	return int64(hash.Sum64())
+}
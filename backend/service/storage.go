@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrFileNotFound is returned when a stored file does not exist
+var ErrFileNotFound = errors.New("stored file not found")
+
+// FileStorage stores uploaded files under opaque keys
+type FileStorage interface {
+	Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFileStorageFromEnv returns the storage selected by STORAGE_DRIVER: "local" (default) keeps files under
+// STORAGE_LOCAL_PATH, "s3" uses an S3-compatible bucket configured by the S3_* variables
+func NewFileStorageFromEnv() (FileStorage, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_PATH")
+		if root == "" {
+			root = "uploads"
+		}
+		return NewLocalStorage(root)
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q, expected local or s3", driver)
+	}
+}
+
+//---------- LOCAL FILESYSTEM ----------
+
+// LocalStorage keeps files in a directory on the server
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates the root directory if needed
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+func (s *LocalStorage) Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key to a file below the root, refusing keys that escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if filepath.IsAbs(cleaned) || cleaned == "." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) || cleaned == ".." {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.root, cleaned), nil
+}
+
+//---------- S3-COMPATIBLE ----------
+
+// S3Config configures an S3-compatible bucket such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint        string // E.g. https://s3.eu-central-1.amazonaws.com or http://minio:9000
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3Storage keeps files in an S3-compatible bucket using path-style requests signed with Signature Version 4
+type S3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Storage checks the configuration
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("S3 storage needs an endpoint, bucket, access key ID and secret access key")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	return &S3Storage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s *S3Storage) Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	// Signing needs the payload hash, so the body is buffered; attachments are small and size limited
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s.check(resp)
+}
+
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s.check(resp)
+}
+
+// do sends a signed request for an object
+func (s *S3Storage) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+	objectURL := *s.endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + s.config.Bucket + "/" + key
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// check turns an unsuccessful response into an error
+func (s *S3Storage) check(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrFileNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), day)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
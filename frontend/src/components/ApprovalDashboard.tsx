@@ -13,8 +13,12 @@ export const ApprovalDashboard: React.FC<ApprovalDashboardProps> = ({ onRequestU
   const [selectedRequest, setSelectedRequest] = useState<LeaveRequest | null>(null);
   const [approvalComments, setApprovalComments] = useState('');
   const [processing, setProcessing] = useState<number | null>(null);
+  const [selectedIds, setSelectedIds] = useState<number[]>([]);
+  const [bulkComments, setBulkComments] = useState('');
+  const [bulkProcessing, setBulkProcessing] = useState(false);
 
   useEffect(() => {
+    setSelectedIds([]);
     loadPendingRequests();
   }, [activeTab]);
 
@@ -70,6 +74,50 @@ export const ApprovalDashboard: React.FC<ApprovalDashboardProps> = ({ onRequestU
     }
   };
 
+  const toggleSelected = (id: number) => {
+    setSelectedIds((ids) => (ids.includes(id) ? ids.filter((selectedId) => selectedId !== id) : [...ids, id]));
+  };
+
+  const toggleSelectAll = () => {
+    setSelectedIds(selectedIds.length === requests.length ? [] : requests.map((request) => request.id));
+  };
+
+  const handleBulkDecision = async (action: 'approve' | 'reject') => {
+    const verb = action === 'approve' ? 'approve' : 'reject';
+    if (!window.confirm(`Are you sure you want to ${verb} ${selectedIds.length} leave request(s)?`)) {
+      return;
+    }
+    try {
+      setBulkProcessing(true);
+      const result = await leaveRequestsApi.bulkDecideLeaveRequests({
+        ids: selectedIds,
+        action,
+        comments: bulkComments
+      });
+      result.data
+        .filter((item) => item.success)
+        .forEach((item) => {
+          const request = requests.find((r) => r.id === item.id);
+          if (request) {
+            onRequestUpdated({ ...request, status: item.status || request.status });
+          }
+        });
+      if (result.failed > 0) {
+        const failures = result.data
+          .filter((item) => !item.success)
+          .map((item) => `#${item.id}: ${item.errors?.join(', ') || item.message}`);
+        alert(`${result.message}\n\n${failures.join('\n')}`);
+      }
+      setSelectedIds([]);
+      setBulkComments('');
+      loadPendingRequests();
+    } catch (err: any) {
+      alert(err.response?.data?.message || `Failed to ${verb} requests`);
+    } finally {
+      setBulkProcessing(false);
+    }
+  };
+
   const formatDate = (dateString: string) => {
     return new Date(dateString).toLocaleDateString();
   };
@@ -150,6 +198,44 @@ export const ApprovalDashboard: React.FC<ApprovalDashboardProps> = ({ onRequestU
         </nav>
       </div>
 
+      {/* Bulk Actions */}
+      {requests.length > 0 && (
+        <div className="flex flex-col md:flex-row md:items-center gap-3 bg-gray-800/50 rounded-lg p-4 border border-gray-700">
+          <label className="flex items-center text-gray-300 text-sm">
+            <input
+              type="checkbox"
+              checked={selectedIds.length === requests.length}
+              onChange={toggleSelectAll}
+              className="mr-2"
+            />
+            {selectedIds.length > 0 ? `${selectedIds.length} selected` : 'Select all'}
+          </label>
+          <input
+            type="text"
+            value={bulkComments}
+            onChange={(e) => setBulkComments(e.target.value)}
+            placeholder="Comments for the selected requests (optional)"
+            className="flex-1 bg-gray-700 border border-gray-600 rounded-md px-3 py-2 text-white text-sm focus:outline-none focus:ring-2 focus:ring-blue-500"
+          />
+          <div className="flex space-x-3">
+            <button
+              onClick={() => handleBulkDecision('reject')}
+              disabled={selectedIds.length === 0 || bulkProcessing}
+              className="px-4 py-2 bg-red-600 hover:bg-red-700 disabled:bg-gray-600 text-white rounded-md text-sm"
+            >
+              {bulkProcessing ? 'Processing...' : 'Reject Selected'}
+            </button>
+            <button
+              onClick={() => handleBulkDecision('approve')}
+              disabled={selectedIds.length === 0 || bulkProcessing}
+              className="px-4 py-2 bg-green-600 hover:bg-green-700 disabled:bg-gray-600 text-white rounded-md text-sm"
+            >
+              {bulkProcessing ? 'Processing...' : 'Approve Selected'}
+            </button>
+          </div>
+        </div>
+      )}
+
       {/* Requests List */}
       <div className="space-y-4">
         {requests.length === 0 ? (
@@ -164,13 +250,21 @@ export const ApprovalDashboard: React.FC<ApprovalDashboardProps> = ({ onRequestU
           requests.map((request) => (
             <div key={request.id} className="bg-gray-800/50 rounded-lg p-6 border border-gray-700">
               <div className="flex justify-between items-start mb-4">
-                <div>
-                  <h3 className="text-lg font-semibold text-white">
-                    {LEAVE_TYPES[request.leave_type as keyof typeof LEAVE_TYPES]} Request
-                  </h3>
-                  <p className="text-gray-400 text-sm">
-                    by {request.user?.first_name} {request.user?.last_name}
-                  </p>
+                <div className="flex items-start">
+                  <input
+                    type="checkbox"
+                    checked={selectedIds.includes(request.id)}
+                    onChange={() => toggleSelected(request.id)}
+                    className="mt-2 mr-3"
+                  />
+                  <div>
+                    <h3 className="text-lg font-semibold text-white">
+                      {LEAVE_TYPES[request.leave_type as keyof typeof LEAVE_TYPES]} Request
+                    </h3>
+                    <p className="text-gray-400 text-sm">
+                      by {request.user?.first_name} {request.user?.last_name}
+                    </p>
+                  </div>
                 </div>
                 <div className="flex items-center space-x-3">
                   {getStatusBadge(request.status)}
@@ -61,6 +61,27 @@ export interface ApprovalData {
   comments?: string;
 }
 
+export interface BulkDecisionData {
+  ids: number[];
+  action: 'approve' | 'reject';
+  comments?: string;
+}
+
+export interface BulkDecisionResult {
+  id: number;
+  success: boolean;
+  status?: string;
+  message?: string;
+  errors?: string[];
+}
+
+export interface BulkDecisionResponse {
+  message: string;
+  data: BulkDecisionResult[];
+  succeeded: number;
+  failed: number;
+}
+
 export interface LeaveCalendarEntry {
   id: number;
   leave_request_id: number;
@@ -164,6 +185,11 @@ export const leaveRequestsApi = {
     await apiClient.post(`/leave-requests/${id}/reject`, data);
   },
 
+  bulkDecideLeaveRequests: async (data: BulkDecisionData): Promise<BulkDecisionResponse> => {
+    const response = await apiClient.post('/leave-requests/bulk-decision', data);
+    return response.data;
+  },
+
   // Workflow status and timeline
   getWorkflowStatus: async (id: number): Promise<WorkflowStatus> => {
     const response = await apiClient.get(`/leave-requests/${id}/workflow`);